@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+
+	"power-system-analysis-labs/psa"
+)
+
+// 按ANSI C37的E/X法校验网络文件中给出的各断路器
+func runBreaker(args []string) error {
+	o := newOptions("breaker")
+	e := o.flags.Float64("e", 1, "短路前电压(标幺值)")
+	if err := o.parse(args); err != nil {
+		return err
+	}
+	parser, err := o.loadParser()
+	if err != nil {
+		return err
+	}
+	duties, err := parser.ComputeBreakerDuty(*e)
+	if err != nil {
+		return err
+	}
+	result, err := o.newResult(parser, parser.Vav)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(o.out, "断路器\t母线\tE/X\t第一周期E/X\tX/R\tNACD\tMF\t开断电流(kA)\t额定(kA)\t关合峰值(kA)\t额定(kA)\t结论")
+	for i, d := range duties {
+		name := d.Breaker.Name
+		if name == "" {
+			name = fmt.Sprintf("CB%d", i+1)
+		}
+		verdict := "通过"
+		if !d.Pass {
+			verdict = "不通过"
+		}
+		closingRating := "-"
+		if d.Breaker.ClosingKA != 0 {
+			closingRating = fmt.Sprintf("%.2f", d.Breaker.ClosingKA)
+		}
+		fmt.Fprintf(o.out, "%s\t%d\t%.3f\t%.3f\t%.2f\t%.3f\t%.3f\t%.2f\t%.2f\t%.2f\t%s\t%s\n",
+			name, d.Breaker.Node, d.EX, d.FirstCycleEX, d.XR, d.NACD, d.MF, d.InterruptingKA, d.Breaker.InterruptingKA,
+			d.ClosingKA, closingRating, verdict)
+		result.AddQuantity(name+".E/X", complex(d.EX, 0), psa.Current)
+		result.AddQuantity(name+".E/X1", complex(d.FirstCycleEX, 0), psa.Current)
+		result.AddValue(name+".X/R", complex(d.XR, 0), "")
+		result.AddValue(name+".NACD", complex(d.NACD, 0), "")
+		result.AddValue(name+".MF", complex(d.MF, 0), "")
+		result.AddValue(name+".interrupting", complex(d.InterruptingKA, 0), "kA")
+		result.AddValue(name+".closing", complex(d.ClosingKA, 0), "kA")
+		pass := 0.0
+		if d.Pass {
+			pass = 1
+		}
+		result.AddValue(name+".pass", complex(pass, 0), "")
+	}
+	return o.write(result)
+}
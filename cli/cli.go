@@ -0,0 +1,246 @@
+// Package cli 实现psa命令行, 各实验的程序以交互方式运行其中的子命令
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"power-system-analysis-labs/psa"
+)
+
+// 子命令
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"ybus", "节点导纳矩阵, 可以给出三相短路的节点", runYbus},
+		{"midline", "线路中点三相短路后的节点导纳矩阵", runMidline},
+		{"zbus", "LDU分解和节点阻抗矩阵", runZbus},
+		{"fault3ph", "三相短路电流、节点电压和支路电流", runFault3ph},
+		{"contrib", "三相短路时各电源的电流分布系数和提供的短路电流", runContrib},
+		{"faultseq", "用对称分量法计算不对称短路", runFaultseq},
+		{"iec60909", "按IEC 60909计算三相短路电流", runIEC60909},
+		{"trace", "三相短路电流随时间的变化和采样波形", runTrace},
+		{"stability", "三相短路的暂态稳定仿真和临界切除时间", runStability},
+		{"eac", "单机无穷大系统的等面积定则和临界切除角", runEAC},
+		{"modes", "小干扰稳定分析的特征值、阻尼比和参与因子", runModes},
+		{"cpf", "连续潮流的P-V曲线、负荷裕度和Q-V曲线", runCPF},
+		{"coord", "过电流继电器主保护和后备保护的配合校验", runCoord},
+		{"distance", "距离继电器的测量阻抗和各段保护范围校验", runDistance},
+		{"breaker", "按ANSI C37的E/X法校验断路器的开断能力", runBreaker},
+		{"thevenin", "节点对地或两个节点之间的戴维南等值", runThevenin},
+		{"equiv", "Kron等值或Ward等值到给定的保留节点", runEquiv},
+		{"convert", "将网络换算为标幺值支路并导出到文件", runConvert},
+		{"serve", "以HTTP/JSON的方式提供计算服务", runServe},
+	}
+}
+
+// 运行子命令, 返回进程的退出码
+func Run(args []string) int {
+	if len(args) == 0 {
+		usage(os.Stderr)
+		return 2
+	}
+	for _, c := range commands {
+		if c.name == args[0] {
+			if err := c.run(args[1:]); err != nil {
+				if err != flag.ErrHelp {
+					fmt.Fprintln(os.Stderr, err)
+				}
+				return 1
+			}
+			return 0
+		}
+	}
+	if args[0] == "help" || args[0] == "-h" || args[0] == "-help" {
+		usage(os.Stdout)
+		return 0
+	}
+	fmt.Fprintf(os.Stderr, "未知的子命令: %s\n", args[0])
+	usage(os.Stderr)
+	return 2
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "用法: psa <子命令> [参数] [网络文件]")
+	fmt.Fprintln(w, "子命令:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s%s\n", c.name, c.usage)
+	}
+	fmt.Fprintln(w, "使用 psa <子命令> -h 查看子命令的参数")
+}
+
+// 各子命令共用的参数
+type options struct {
+	path        string
+	format      string
+	output      string
+	unit        string
+	vb          float64
+	interactive bool
+	// LDU分解的主元选择和容差
+	pivoting  bool
+	tolerance float64
+	// 计算阻抗矩阵的求解器, 以及是否用另一种求解器交叉验证
+	solver string
+	verify bool
+	// 计算最小短路电流时不计电动机
+	noMotors bool
+	// 文本结果和提示, json和csv结果单独写到标准输出时改为标准错误
+	out io.Writer
+	// json和csv结果
+	stdout io.Writer
+	flags  *flag.FlagSet
+}
+
+func newOptions(name string) *options {
+	o := &options{flags: flag.NewFlagSet(name, flag.ContinueOnError)}
+	o.flags.StringVar(&o.path, "in", "", "网络文件的路径, 也可以作为最后一个参数给出")
+	o.flags.StringVar(&o.format, "format", "", "输入文件格式: json, cdf, matpower, raw, csv, 默认按扩展名判断")
+	o.flags.StringVar(&o.output, "output", psa.OutputText, "结果的输出格式: text, json, csv")
+	o.flags.StringVar(&o.unit, "unit", psa.UnitPU, "json和csv结果的单位制: pu, si")
+	o.flags.Float64Var(&o.vb, "vb", 0, "换算有名值的基准电压(kV), 默认使用网络的Vav, fault3ph在网络没有Vav时取115")
+	o.flags.BoolVar(&o.interactive, "i", false, "交互方式运行, 缺少的参数从标准输入读取")
+	o.flags.BoolVar(&o.pivoting, "pivot", false, "LDU分解时按对称主元选择交换行和列")
+	o.flags.Float64Var(&o.tolerance, "tol", psa.DefaultPivotTolerance, "LDU分解判断主元为0的相对容差")
+	o.flags.StringVar(&o.solver, "solver", psa.SolverLDU, "计算阻抗矩阵的求解器: ldu(手写的LDU分解), gonum")
+	o.flags.BoolVar(&o.verify, "verify", false, "用另一种求解器交叉验证阻抗矩阵, 也可以设置环境变量PSA_DEBUG")
+	o.flags.BoolVar(&o.noMotors, "nomotors", false, "不计电动机, 用于计算最小短路电流")
+	return o
+}
+
+func (o *options) parse(args []string) error {
+	if err := o.flags.Parse(args); err != nil {
+		return err
+	}
+	if o.path == "" && o.flags.NArg() > 0 {
+		o.path = o.flags.Arg(0)
+	}
+	o.stdout = os.Stdout
+	o.out = os.Stdout
+	switch o.output {
+	case psa.OutputText:
+	case psa.OutputJSON, psa.OutputCSV:
+		o.out = os.Stderr
+	default:
+		return fmt.Errorf("未知的输出格式: %s", o.output)
+	}
+	if o.path == "" {
+		if !o.interactive {
+			return fmt.Errorf("缺少网络文件")
+		}
+		o.ask("输入文件的路径:", &o.path)
+	}
+	return nil
+}
+
+func (o *options) ask(prompt string, v interface{}) {
+	fmt.Fprintln(o.out, prompt)
+	fmt.Fscanln(os.Stdin, v)
+}
+
+// 参数没有给出时, 交互方式下读取, 否则返回错误
+func (o *options) require(name string, prompt string, v *int) error {
+	if *v != 0 {
+		return nil
+	}
+	if !o.interactive {
+		return fmt.Errorf("缺少参数 -%s", name)
+	}
+	o.ask(prompt, v)
+	return nil
+}
+
+func (o *options) loadParser() (*psa.Parser, error) {
+	return o.loadParserWith(psa.NewParser)
+}
+
+// 用给定的方式由网络建立Parser, 例如psa.NewIEC60909Parser
+func (o *options) loadParserWith(newParser func(psa.PowerNetwork) (*psa.Parser, error)) (*psa.Parser, error) {
+	network, err := psa.ImportPowerNetworkFromFile(o.path, o.format)
+	if err != nil {
+		return nil, err
+	}
+	if o.noMotors {
+		network = network.WithoutMotors()
+	}
+	parser, err := newParser(network)
+	if err != nil {
+		return nil, err
+	}
+	if err := o.configure(parser); err != nil {
+		return nil, err
+	}
+	if !parser.Topology().Identity() {
+		fmt.Fprintln(o.out, "母线组成: ")
+		printBuses(o.out, parser.Topology())
+	}
+	if parser.NodeNum() == 0 {
+		return nil, fmt.Errorf("网络中没有节点")
+	}
+	return parser, nil
+}
+
+func (o *options) configure(parser *psa.Parser) error {
+	parser.Pivoting = o.pivoting
+	parser.PivotTolerance = o.tolerance
+	if o.verify {
+		parser.Verify = true
+	}
+	return parser.UseSolver(o.solver)
+}
+
+// 没有网络时(序网参数已经是标幺值)不能换算有名值
+func (o *options) newResult(parser *psa.Parser, vb float64) (*psa.Result, error) {
+	if o.vb != 0 {
+		vb = o.vb
+	}
+	if parser == nil {
+		return psa.NewResult(o.unit, 0, vb)
+	}
+	return parser.NewResult(o.unit, vb)
+}
+
+// json和csv格式的结果写到标准输出, text格式已经打印
+func (o *options) write(result *psa.Result) error {
+	if o.output == psa.OutputText {
+		return nil
+	}
+	return result.Write(o.stdout, o.output)
+}
+
+// 输入的是物理节点, 换算为所在母线
+func busOf(parser *psa.Parser, node int) (int, error) {
+	bus := parser.Topology().BusOf(node)
+	if bus <= 0 || bus > parser.NodeNum() {
+		return 0, fmt.Errorf("节点%d不在网络中、已接地或不带电", node)
+	}
+	return bus, nil
+}
+
+// 解析"i,j"形式的两个节点
+func parseLine(s string) (int, int, error) {
+	fields := strings.Split(s, ",")
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("线路应为i,j的形式: %s", s)
+	}
+	i, err := strconv.Atoi(strings.TrimSpace(fields[0]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("线路应为i,j的形式: %s", s)
+	}
+	j, err := strconv.Atoi(strings.TrimSpace(fields[1]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("线路应为i,j的形式: %s", s)
+	}
+	return i, j, nil
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 电源x=0.1接在节点1, 1-2的线路x=0.2, 节点2短路时 If = 1/j0.3
+const twoNodeNetwork = `{"SB": 100, "branches": [
+	{"node_1": 1, "node_2": 0, "reactance": 0.1, "E": 1},
+	{"node_1": 1, "node_2": 2, "reactance": 0.2}
+]}`
+
+// 把网络写到临时目录中, 返回文件路径
+func writeNetwork(t *testing.T, name, text string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "psa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// 运行子命令, 返回标准输出和退出码
+func runCommand(t *testing.T, args ...string) (string, int) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout = w
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stderr = devNull
+	output := make(chan string)
+	go func() {
+		data, _ := ioutil.ReadAll(r)
+		output <- string(data)
+	}()
+	code := Run(args)
+	w.Close()
+	os.Stdout, os.Stderr = stdout, stderr
+	devNull.Close()
+	return <-output, code
+}
+
+func TestRunExitCodes(t *testing.T) {
+	if _, code := runCommand(t); code != 2 {
+		t.Errorf("no subcommand: exit code %d, want 2", code)
+	}
+	if _, code := runCommand(t, "lab9"); code != 2 {
+		t.Errorf("unknown subcommand: exit code %d, want 2", code)
+	}
+	if out, code := runCommand(t, "help"); code != 0 || !strings.Contains(out, "fault3ph") {
+		t.Errorf("help: exit code %d, output %q", code, out)
+	}
+	path := writeNetwork(t, "two.json", twoNodeNetwork)
+	// 非交互方式下缺少的参数是错误, 不从标准输入读取
+	if _, code := runCommand(t, "fault3ph", path); code != 1 {
+		t.Errorf("fault3ph without -node: exit code %d, want 1", code)
+	}
+	if _, code := runCommand(t, "fault3ph", "-node", "2"); code != 1 {
+		t.Errorf("fault3ph without a network file: exit code %d, want 1", code)
+	}
+	if _, code := runCommand(t, "fault3ph", "-node", "2", "-output", "xml", path); code != 1 {
+		t.Errorf("unknown output format: exit code %d, want 1", code)
+	}
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"fmt"
+	"math/cmplx"
+
+	"power-system-analysis-labs/psa"
+)
+
+// 三相短路时各电源的转移阻抗、电流分布系数和提供的短路电流
+func runContrib(args []string) error {
+	o := newOptions("contrib")
+	node := o.flags.Int("node", 0, "短路点")
+	if err := o.parse(args); err != nil {
+		return err
+	}
+	parser, err := o.loadParser()
+	if err != nil {
+		return err
+	}
+	if err := parser.ComputeResult(); err != nil {
+		return err
+	}
+	if err := o.require("node", "输入短路点:", node); err != nil {
+		return err
+	}
+	f, err := busOf(parser, *node)
+	if err != nil {
+		return err
+	}
+	c, err := parser.ComputeContributions(f, o.vb)
+	if err != nil {
+		return err
+	}
+	result, err := o.newResult(parser, parser.Vav)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(o.out, "Zff = %v, Uf = %v, If = %v\n", c.Zff, c.Uf, c.If)
+	fmt.Fprintln(o.out, "电源\t母线\tE\t转移阻抗\t分布系数\t电流(标幺值)\t电流(kA)")
+	for _, s := range c.Sources {
+		fmt.Fprintf(o.out, "%s\t%d\t%.2f\t%.4f\t%.4f\t%.4f\t%.4f\n",
+			s.Name(), s.Node, s.E, s.Zf, s.C, cmplx.Abs(s.I), s.KA)
+	}
+	fmt.Fprintf(o.out, "各电源电流之和: %v, 分布系数之和: %.4f\n", c.Sum, c.CSum)
+	addContributions(result, c)
+	return o.write(result)
+}
+
+func addContributions(result *psa.Result, c *psa.FaultContributions) {
+	result.AddQuantity("Zff", c.Zff, psa.Impedance)
+	result.AddQuantity("Uf", c.Uf, psa.Voltage)
+	result.AddQuantity("If", c.If, psa.Current)
+	for _, s := range c.Sources {
+		name := s.Name()
+		result.AddQuantity(name+".zf", s.Zf, psa.Impedance)
+		result.AddValue(name+".c", s.C, "")
+		result.AddQuantity(name+".I", s.I, psa.Current)
+		if s.KA != 0 {
+			result.AddValue(name+".kA", complex(s.KA, 0), "kA")
+		}
+	}
+	result.AddQuantity("sum", c.Sum, psa.Current)
+	result.AddValue("c.sum", c.CSum, "")
+}
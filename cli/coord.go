@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"fmt"
+	"math"
+
+	"power-system-analysis-labs/psa"
+)
+
+// 依次在各母线三相短路, 校验过电流继电器主保护和后备保护的配合
+func runCoord(args []string) error {
+	o := newOptions("coord")
+	faults := o.flags.String("faults", "", "短路点, 形式为i,j,k, 默认为全部母线")
+	cti := o.flags.Float64("cti", psa.DefaultCTI, "配合时间间隔(s)")
+	if err := o.parse(args); err != nil {
+		return err
+	}
+	parser, err := o.loadParser()
+	if err != nil {
+		return err
+	}
+	if err := parser.ComputeResult(); err != nil {
+		return err
+	}
+	var buses []int
+	if *faults != "" {
+		nodes, err := parseNodes(*faults)
+		if err != nil {
+			return err
+		}
+		for _, node := range nodes {
+			bus, err := busOf(parser, node)
+			if err != nil {
+				return err
+			}
+			buses = append(buses, bus)
+		}
+	}
+	c, err := parser.ComputeCoordination(buses, *cti)
+	if err != nil {
+		return err
+	}
+	result, err := o.newResult(parser, parser.Vav)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(o.out, "短路母线\t继电器\t电流(kA)\t倍数\t动作时间(s)")
+	for _, op := range c.Operations {
+		fmt.Fprintf(o.out, "%d\t%s\t%.3f\t%.2f\t%s\n", op.Fault, op.Name, op.KA, op.Multiple, relayTime(op.Time))
+	}
+	fmt.Fprintf(o.out, "主保护和后备保护的配合(CTI = %.2fs):\n", c.CTI)
+	fmt.Fprintln(o.out, "短路母线\t主保护\t动作时间(s)\t后备保护\t动作时间(s)\t时间间隔(s)\t结论")
+	for _, pair := range c.Pairs {
+		verdict := "满足"
+		if pair.Violation {
+			verdict = "不满足"
+		}
+		fmt.Fprintf(o.out, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", pair.Fault, pair.Primary.Name, relayTime(pair.Primary.Time),
+			pair.Backup.Name, relayTime(pair.Backup.Time), relayTime(pair.Margin), verdict)
+	}
+	fmt.Fprintf(o.out, "不满足配合要求: %d处\n", len(c.Violations()))
+	addCoordination(result, c)
+	return o.write(result)
+}
+
+// 不动作时显示为"-"
+func relayTime(t float64) string {
+	if math.IsInf(t, 0) || math.IsNaN(t) {
+		return "-"
+	}
+	return fmt.Sprintf("%.3f", t)
+}
+
+// JSON不能表示无穷大, 不动作的继电器不输出动作时间
+func addCoordination(result *psa.Result, c *psa.Coordination) {
+	result.AddValue("CTI", complex(c.CTI, 0), "s")
+	for _, op := range c.Operations {
+		name := fmt.Sprintf("F%d.%s", op.Fault, op.Name)
+		result.AddValue(name+".I", complex(op.KA, 0), "kA")
+		if !math.IsInf(op.Time, 0) {
+			result.AddValue(name+".t", complex(op.Time, 0), "s")
+		}
+	}
+	for _, pair := range c.Pairs {
+		name := fmt.Sprintf("F%d.%s/%s", pair.Fault, pair.Primary.Name, pair.Backup.Name)
+		if !math.IsInf(pair.Margin, 0) && !math.IsNaN(pair.Margin) {
+			result.AddValue(name+".margin", complex(pair.Margin, 0), "s")
+		}
+		violation := 0.0
+		if pair.Violation {
+			violation = 1
+		}
+		result.AddValue(name+".violation", complex(violation, 0), "")
+	}
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"power-system-analysis-labs/psa"
+)
+
+// 连续潮流的P-V曲线和负荷裕度, 以及给定节点的Q-V曲线
+func runCPF(args []string) error {
+	o := newOptions("cpf")
+	buses := o.flags.String("buses", "", "负荷增长的节点, 形式为i,j,k, 默认所有有负荷的节点同比例增长")
+	qv := o.flags.String("qv", "", "计算Q-V曲线的PQ节点, 形式为i,j,k")
+	var opts psa.CPFOptions
+	var qvOpts psa.QVOptions
+	o.flags.BoolVar(&opts.Generation, "gen", false, "增加的负荷由PV节点按有功出力分担, 默认由平衡节点承担")
+	o.flags.Float64Var(&opts.Step, "step", 0.1, "连续潮流的弧长步长")
+	o.flags.IntVar(&opts.MaxSteps, "max-steps", 1000, "连续潮流最多的步数")
+	o.flags.Float64Var(&qvOpts.Vmax, "vmax", 1.2, "Q-V曲线的最高电压(标幺值)")
+	o.flags.Float64Var(&qvOpts.Vmin, "vmin", 0.5, "Q-V曲线的最低电压(标幺值)")
+	o.flags.Float64Var(&qvOpts.Step, "vstep", 0.01, "Q-V曲线的电压步长(标幺值)")
+	if err := o.parse(args); err != nil {
+		return err
+	}
+	parser, err := o.loadParser()
+	if err != nil {
+		return err
+	}
+	if *buses != "" {
+		nodes, err := parseNodes(*buses)
+		if err != nil {
+			return err
+		}
+		for _, node := range nodes {
+			bus, err := busOf(parser, node)
+			if err != nil {
+				return err
+			}
+			opts.Buses = append(opts.Buses, bus)
+		}
+	}
+	var qvBuses []int
+	if *qv != "" {
+		nodes, err := parseNodes(*qv)
+		if err != nil {
+			return err
+		}
+		for _, node := range nodes {
+			bus, err := busOf(parser, node)
+			if err != nil {
+				return err
+			}
+			qvBuses = append(qvBuses, bus)
+		}
+	}
+	c, err := parser.ComputePVCurve(opts)
+	if err != nil {
+		return err
+	}
+	var qvCurves []*psa.QVCurve
+	for _, bus := range qvBuses {
+		q, err := parser.ComputeQVCurve(bus, qvOpts)
+		if err != nil {
+			return err
+		}
+		qvCurves = append(qvCurves, q)
+	}
+	result, err := o.newResult(parser, parser.Vav)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(o.out, "λmax = %.4f, 最大负荷 %.2fMW, 临界节点 %d\n", c.LambdaMax, c.LoadMax, c.CriticalBus)
+	if !c.Complete {
+		fmt.Fprintln(o.out, "连续潮流没有越过鼻点, 可以减小 -step 或增大 -max-steps")
+	}
+	header := []string{"λ", "P(MW)"}
+	for i := range c.V {
+		header = append(header, fmt.Sprintf("V%d", i+1))
+	}
+	fmt.Fprintln(o.out, strings.Join(header, "\t"))
+	for k, lambda := range c.Lambda {
+		fmt.Fprintf(o.out, "%.4f\t%.2f", lambda, c.Load[k])
+		for i := range c.V {
+			fmt.Fprintf(o.out, "\t%.4f", c.V[i][k])
+		}
+		fmt.Fprintln(o.out)
+	}
+	for _, q := range qvCurves {
+		fmt.Fprintf(o.out, "节点%d的Q-V曲线: Qmin = %.4f (V = %.2f)\n", q.Bus, q.Qmin, q.VAtQmin)
+		fmt.Fprintln(o.out, "V\tQ")
+		for k, v := range q.V {
+			fmt.Fprintf(o.out, "%.4f\t%.4f\n", v, q.Q[k])
+		}
+	}
+	addPVCurve(result, c)
+	for _, q := range qvCurves {
+		addQVCurve(result, q)
+	}
+	return o.write(result)
+}
+
+func addPVCurve(result *psa.Result, c *psa.PVCurve) {
+	result.AddValue("lambda_max", complex(c.LambdaMax, 0), "")
+	result.AddValue("P_max", complex(c.LoadMax, 0), "MW")
+	result.AddValue("critical_bus", complex(float64(c.CriticalBus), 0), "")
+	// P-V曲线以lambda为横坐标
+	result.AddWaveformValue("lambda", c.Lambda, "")
+	result.AddWaveformValue("P", c.Load, "MW")
+	for i, v := range c.V {
+		result.AddWaveform(fmt.Sprintf("V%d", i+1), v, psa.Voltage)
+	}
+}
+
+func addQVCurve(result *psa.Result, q *psa.QVCurve) {
+	name := fmt.Sprintf("QV%d", q.Bus)
+	result.AddQuantity(name+".Qmin", complex(q.Qmin, 0), psa.Power)
+	result.AddQuantity(name+".V_Qmin", complex(q.VAtQmin, 0), psa.Voltage)
+	result.AddWaveform(name+".V", q.V, psa.Voltage)
+	result.AddWaveform(name+".Q", q.Q, psa.Power)
+}
@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"power-system-analysis-labs/psa"
+)
+
+// 距离继电器的测量阻抗和各段保护范围的校验
+func runDistance(args []string) error {
+	o := newOptions("distance")
+	seq := o.flags.Bool("seq", false, "网络文件为各序网络(实验四), 可以计算不对称短路")
+	faultType := o.flags.String("type", psa.Fault3Ph, "短路类型, 只有-seq时可以不是3ph: 1ph, 2ph, 2phg, 3ph")
+	pointsFlag := o.flags.String("points", "", "线路上短路点的位置(占线路全长的比例), 形式为0.2,0.5,0.8, 默认为0.1到0.9")
+	if err := o.parse(args); err != nil {
+		return err
+	}
+	points, err := parseFloats(*pointsFlag)
+	if err != nil {
+		return err
+	}
+	var checks []psa.DistanceCheck
+	if *seq {
+		network, err := psa.ImportSequenceNetworkFromFile(o.path, o.format)
+		if err != nil {
+			return err
+		}
+		if err := network.Validate(); err != nil {
+			return err
+		}
+		parsers, _, err := o.sequenceParsers(&network)
+		if err != nil {
+			return err
+		}
+		if checks, err = network.CheckDistanceRelays(parsers[0], parsers[1], parsers[2], *faultType, points); err != nil {
+			return err
+		}
+	} else {
+		if *faultType != psa.Fault3Ph {
+			return fmt.Errorf("不对称短路需要各序网络, 使用 -seq")
+		}
+		parser, err := o.loadParser()
+		if err != nil {
+			return err
+		}
+		if err := parser.ComputeResult(); err != nil {
+			return err
+		}
+		if checks, err = parser.CheckDistanceRelays(points); err != nil {
+			return err
+		}
+	}
+	// 测量阻抗是标幺值, 只有给出-vb时才能换算有名值
+	result, err := o.newResult(nil, 0)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(o.out, "继电器\t短路点\t测量阻抗\t占线路阻抗(%)\tI段\tII段\tIII段\t结论")
+	violations := 0
+	for _, c := range checks {
+		verdict := "正确"
+		if len(c.Violations) > 0 {
+			verdict = strings.Join(c.Violations, ", ")
+			violations++
+		}
+		fmt.Fprintf(o.out, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", c.Relay, distanceLocation(c), distanceZ(c.Z),
+			relayTime(c.Percent), zoneMark(c, 0), zoneMark(c, 1), zoneMark(c, 2), verdict)
+	}
+	fmt.Fprintf(o.out, "保护范围不正确: %d处\n", violations)
+	addDistanceChecks(result, checks)
+	return o.write(result)
+}
+
+// 解析"0.2,0.5,0.8"形式的实数, 为空时返回nil
+func parseFloats(s string) ([]float64, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var values []float64
+	for _, field := range strings.Split(s, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, fmt.Errorf("应为x,y,z形式的数: %s", s)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+func distanceLocation(c psa.DistanceCheck) string {
+	if c.Location == psa.FaultInternal {
+		return fmt.Sprintf("线路%.0f%%", c.X*100)
+	}
+	return fmt.Sprintf("母线%d(%s)", c.Node, c.Location)
+}
+
+func distanceZ(Z complex128) string {
+	if math.IsInf(real(Z), 0) || math.IsInf(imag(Z), 0) {
+		return "-"
+	}
+	return fmt.Sprintf("%.4f", Z)
+}
+
+// 动作的段标记为+, 应动作或不应动作的段后面加上要求
+func zoneMark(c psa.DistanceCheck, k int) string {
+	mark := "-"
+	if c.Operate[k] {
+		mark = "+"
+	}
+	switch c.Expected[k] {
+	case 1:
+		mark += "(应动)"
+	case -1:
+		mark += "(不应动)"
+	}
+	return mark
+}
+
+func addDistanceChecks(result *psa.Result, checks []psa.DistanceCheck) {
+	for _, c := range checks {
+		name := fmt.Sprintf("%s.%s", c.Relay, distanceName(c))
+		if !math.IsInf(c.Percent, 0) {
+			result.AddQuantity(name+".Z", c.Z, psa.Impedance)
+		}
+		for k := range c.Operate {
+			operate := 0.0
+			if c.Operate[k] {
+				operate = 1
+			}
+			result.AddValue(fmt.Sprintf("%s.zone%d", name, k+1), complex(operate, 0), "")
+		}
+		result.AddValue(name+".violations", complex(float64(len(c.Violations)), 0), "")
+	}
+}
+
+// 结果中短路点的名称, 例如x0.5、bus3
+func distanceName(c psa.DistanceCheck) string {
+	if c.Location == psa.FaultInternal {
+		return fmt.Sprintf("x%g", c.X)
+	}
+	return fmt.Sprintf("bus%d", c.Node)
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"fmt"
+	"math"
+
+	"power-system-analysis-labs/psa"
+)
+
+// 单机无穷大系统的等面积定则, 输出功角特性、临界切除角和临界切除时间
+func runEAC(args []string) error {
+	o := newOptions("eac")
+	node := o.flags.Int("node", 0, "短路点")
+	trip := o.flags.String("trip", "", "切除短路时断开的线路, 形式为i,j, 默认只切除短路")
+	var opts psa.EqualAreaOptions
+	o.flags.IntVar(&opts.Generator, "gen", 0, "发电机的序号(从1开始), 网络中只有一台发电机时可以不给出")
+	o.flags.Float64Var(&opts.ClearTime, "clear", 0, "短路切除时间(s), 给出时判断是否稳定")
+	o.flags.Float64Var(&opts.Frequency, "f", 50, "系统频率(Hz)")
+	if err := o.parse(args); err != nil {
+		return err
+	}
+	parser, err := o.loadParser()
+	if err != nil {
+		return err
+	}
+	if err := o.require("node", "输入短路点:", node); err != nil {
+		return err
+	}
+	if opts.Fault, err = busOf(parser, *node); err != nil {
+		return err
+	}
+	if *trip != "" {
+		if opts.TripNode1, opts.TripNode2, err = tripNodes(parser, *trip); err != nil {
+			return err
+		}
+	}
+	a, err := parser.ComputeEqualArea(opts)
+	if err != nil {
+		return err
+	}
+	result, err := o.newResult(parser, parser.Vav)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(o.out, "%s: E' = %.4f, V = %.4f, H = %.4fs, Pm = %.4f\n", a.Machine, a.E, a.V, a.H, a.Pm)
+	fmt.Fprintln(o.out, "\t转移阻抗\tPc\tPmax\tγ(°)")
+	for _, c := range []struct {
+		name  string
+		curve psa.PowerAngleCurve
+	}{{"短路前", a.Pre}, {"短路中", a.During}, {"短路后", a.Post}} {
+		fmt.Fprintf(o.out, "%s\t%.4f\t%.4f\t%.4f\t%.2f\n", c.name, c.curve.Z, c.curve.Pc, c.curve.Pmax, c.curve.Gamma*180/math.Pi)
+	}
+	fmt.Fprintf(o.out, "δ0 = %.2f°, δmax = %.2f°, 临界切除角 δc = %.2f°\n", a.Delta0, a.DeltaMax, a.CriticalAngle)
+	if math.IsInf(a.CriticalTime, 1) {
+		fmt.Fprintln(o.out, "临界切除时间: 持续短路也不失稳")
+	} else {
+		fmt.Fprintf(o.out, "临界切除时间: %.4fs\n", a.CriticalTime)
+	}
+	if opts.ClearTime > 0 {
+		verdict := "稳定"
+		if !a.Stable {
+			verdict = "失稳"
+		}
+		fmt.Fprintf(o.out, "切除时间 %.4fs, 切除角 %.2f°, %s\n", opts.ClearTime, a.ClearingAngle, verdict)
+	}
+	fmt.Fprintln(o.out, "δ(°)\t短路前\t短路中\t短路后")
+	for i, d := range a.Angles {
+		fmt.Fprintf(o.out, "%.0f\t%.4f\t%.4f\t%.4f\n", d, a.PePre[i], a.PeDuring[i], a.PePost[i])
+	}
+	addEqualArea(result, a, opts.ClearTime > 0)
+	return o.write(result)
+}
+
+func addEqualArea(result *psa.Result, a *psa.EqualArea, cleared bool) {
+	result.AddQuantity(a.Machine+".E'", complex(a.E, 0), psa.Voltage)
+	result.AddQuantity("V", complex(a.V, 0), psa.Voltage)
+	result.AddValue("H", complex(a.H, 0), "s")
+	result.AddQuantity("Pm", complex(a.Pm, 0), psa.Power)
+	for _, c := range []struct {
+		name  string
+		curve psa.PowerAngleCurve
+	}{{"pre", a.Pre}, {"during", a.During}, {"post", a.Post}} {
+		result.AddQuantity(c.name+".Z", c.curve.Z, psa.Impedance)
+		result.AddQuantity(c.name+".Pc", complex(c.curve.Pc, 0), psa.Power)
+		result.AddQuantity(c.name+".Pmax", complex(c.curve.Pmax, 0), psa.Power)
+		result.AddValue(c.name+".gamma", complex(c.curve.Gamma*180/math.Pi, 0), "°")
+	}
+	result.AddValue("delta0", complex(a.Delta0, 0), "°")
+	result.AddValue("delta_max", complex(a.DeltaMax, 0), "°")
+	result.AddValue("delta_c", complex(a.CriticalAngle, 0), "°")
+	// JSON不能表示无穷大, 持续短路也不失稳时不输出tcr
+	if !math.IsInf(a.CriticalTime, 1) {
+		result.AddValue("tcr", complex(a.CriticalTime, 0), "s")
+	}
+	if cleared {
+		result.AddValue("delta_clear", complex(a.ClearingAngle, 0), "°")
+		stable := 0.0
+		if a.Stable {
+			stable = 1
+		}
+		result.AddValue("stable", complex(stable, 0), "")
+	}
+	// 功角特性曲线以delta为横坐标
+	result.AddWaveformValue("delta", a.Angles, "°")
+	result.AddWaveform("Pe_pre", a.PePre, psa.Power)
+	result.AddWaveform("Pe_during", a.PeDuring, psa.Power)
+	result.AddWaveform("Pe_post", a.PePost, psa.Power)
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"power-system-analysis-labs/psa"
+)
+
+// 网络等值: 消去保留节点以外的节点
+func runEquiv(args []string) error {
+	o := newOptions("equiv")
+	retain := o.flags.String("retain", "", "保留的节点, 形式为i,j,k")
+	ward := o.flags.Bool("ward", false, "Ward等值(用于潮流计算), 默认为含等值电源的Kron等值")
+	out := o.flags.String("out", "", "将等值网络导出到文件")
+	if err := o.parse(args); err != nil {
+		return err
+	}
+	if *retain == "" {
+		if !o.interactive {
+			return fmt.Errorf("缺少参数 -retain")
+		}
+		o.ask("输入保留的节点(i,j,k):", retain)
+	}
+	nodes, err := parseNodes(*retain)
+	if err != nil {
+		return err
+	}
+	parser, err := o.loadParser()
+	if err != nil {
+		return err
+	}
+	buses := make([]int, len(nodes))
+	for i, node := range nodes {
+		if buses[i], err = busOf(parser, node); err != nil {
+			return err
+		}
+	}
+	var eq *psa.Equivalent
+	if *ward {
+		eq, err = parser.WardEquivalent(buses)
+	} else {
+		eq, err = parser.KronEquivalent(buses)
+	}
+	if err != nil {
+		return err
+	}
+	result, err := o.newResult(parser, parser.Vav)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(o.out, "保留的节点: %v\n", eq.Retained)
+	fmt.Fprintln(o.out, "等值导纳矩阵:")
+	printResultMatrix(o.out, eq.Y)
+	result.AddMatrix("Y_eq", eq.Y, psa.Admittance)
+	for i, node := range eq.Retained {
+		fmt.Fprintf(o.out, "节点%d: 等值对地导纳 %v, 等值注入电流 %v", node, eq.Shunts[i], eq.Injections[i])
+		result.AddQuantity(fmt.Sprintf("y%d0", node), eq.Shunts[i], psa.Admittance)
+		result.AddQuantity(fmt.Sprintf("I%d", node), eq.Injections[i], psa.Current)
+		if eq.Powers != nil {
+			fmt.Fprintf(o.out, ", 等值注入功率 %v", eq.Powers[i])
+			result.AddQuantity(fmt.Sprintf("S%d", node), eq.Powers[i], psa.Power)
+		}
+		fmt.Fprintln(o.out)
+	}
+	if *out != "" {
+		// 等值网络的节点重新编号为1到保留节点数
+		if err := psa.ExportPowerNetwork(*out, psa.PowerNetwork{SB: parser.SB, Vav: parser.Vav, Branches: eq.Branches()}); err != nil {
+			return err
+		}
+	}
+	return o.write(result)
+}
+
+// 解析"i,j,k"形式的节点列表
+func parseNodes(s string) ([]int, error) {
+	var nodes []int
+	for _, field := range strings.Split(s, ",") {
+		node, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("节点应为i,j,k的形式: %s", s)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
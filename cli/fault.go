@@ -0,0 +1,249 @@
+package cli
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+
+	"power-system-analysis-labs/psa"
+)
+
+// 网络和参数都没有给出基准电压时短路点所在段的电压(kV)
+const defaultFaultVB = 115
+
+// 实验二、三: 三相短路
+func runFault3ph(args []string) error {
+	o := newOptions("fault3ph")
+	node := o.flags.Int("node", 0, "短路点")
+	ldu := o.flags.Bool("ldu", false, "同时给出导纳矩阵的LDU分解")
+	if err := o.parse(args); err != nil {
+		return err
+	}
+	parser, err := o.loadParser()
+	if err != nil {
+		return err
+	}
+	vb := parser.Vav
+	if o.vb != 0 {
+		vb = o.vb
+	}
+	// 实验二的网络没有给出Vav, 与原来的实验程序一样按115kV换算有名值
+	if vb == 0 {
+		vb = defaultFaultVB
+	}
+	result, err := o.newResult(parser, vb)
+	if err != nil {
+		return err
+	}
+	if err := zbus(o, parser, result, *ldu); err != nil {
+		return err
+	}
+	if err := o.require("node", "输入短路点:", node); err != nil {
+		return err
+	}
+	f, err := busOf(parser, *node)
+	if err != nil {
+		return err
+	}
+	If, err := parser.ComputeShortIf(f)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(o.out, "短路电流: %v\n", If)
+	result.AddQuantity("If", If, psa.Current)
+	U, err := parser.ComputeAllNodeShortU(f)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(o.out, "各节点电压: %v\n", parser.Topology().ToNodeValues(U))
+	result.SetBusVoltages(parser.Topology().ToNodeValues(U))
+	Iij, err := parser.ComputeIij(U)
+	if err != nil {
+		return err
+	}
+	// 支路电流和转移阻抗按母线计算, 以母线中最小的物理节点号标注
+	t := parser.Topology()
+	fmt.Fprintln(o.out, "各支路电流: ")
+	Y := parser.ResultY()
+	for i := 1; i <= parser.NodeNum(); i++ {
+		for j := i + 1; j <= parser.NodeNum(); j++ {
+			if Y.At(i, j) != 0 {
+				fmt.Fprintf(o.out, "I%d-%d = %v\n", t.NodeOf(i), t.NodeOf(j), Iij.At(i, j))
+				result.AddBranchCurrent(t.NodeOf(i), t.NodeOf(j), Iij.At(i, j))
+			}
+		}
+	}
+
+	// 各电源对短路点的转移阻抗
+	zf, _, err := parser.ComputeAllzfiAndI(f)
+	if err != nil {
+		return err
+	}
+	if parser.ComputeI(zf) != 0 {
+		fmt.Fprintln(o.out, "转移阻抗:")
+		for i := 0; i < len(zf); i++ {
+			if zf[i] != 0 {
+				name := fmt.Sprintf("z%d%d", *node, t.NodeOf(i+1))
+				fmt.Fprintf(o.out, "%s: %v\n", name, zf[i])
+				result.AddQuantity(name, zf[i], psa.Impedance)
+			}
+		}
+	}
+
+	if parser.SB == 0 {
+		return o.write(result)
+	}
+	Ib := parser.SB / (math.Sqrt(3) * vb)
+	Ik := cmplx.Abs(If) * Ib
+	fmt.Fprintln(o.out, "三相次暂态电流有名值:")
+	fmt.Fprintln(o.out, Ik)
+	// 冲击系数取1.8
+	ish := math.Sqrt2 * 1.8 * Ik
+	fmt.Fprintln(o.out, "冲击电流有名值:")
+	fmt.Fprintln(o.out, ish)
+	Sk := real(parser.ComputeP(vb, complex(Ik, 0)))
+	fmt.Fprintln(o.out, "短路功率有名值：")
+	fmt.Fprintln(o.out, Sk)
+	result.AddValue("Ik", complex(Ik, 0), "kA")
+	result.AddValue("ish", complex(ish, 0), "kA")
+	result.AddValue("Sk", complex(Sk, 0), "MVA")
+	return o.write(result)
+}
+
+// 实验四: 不对称短路
+func runFaultseq(args []string) error {
+	o := newOptions("faultseq")
+	faultType := o.flags.String("type", psa.Fault1Ph, "短路类型: 1ph(单相接地), 2ph(两相), 2phg(两相接地), 3ph(三相)")
+	node := o.flags.Int("node", 0, "短路点, 给出时代替文件中各序网络的f1、f2和f0")
+	if err := o.parse(args); err != nil {
+		return err
+	}
+	network, err := psa.ImportSequenceNetworkFromFile(o.path, o.format)
+	if err != nil {
+		return err
+	}
+	if *node != 0 {
+		network.F1, network.F2, network.F0 = *node, *node, *node
+	}
+	if err := network.Validate(); err != nil {
+		return err
+	}
+	parsers, topologies, err := o.sequenceParsers(&network)
+	if err != nil {
+		return err
+	}
+	parser1, parser2, parser0 := parsers[0], parsers[1], parsers[2]
+	Zff1 := parser1.ResultZ().At(network.F1, network.F1)
+	Zff2 := parser2.ResultZ().At(network.F2, network.F2)
+	Zff0 := parser0.ResultZ().At(network.F0, network.F0)
+	fmt.Fprintf(o.out, "Zff(1): %v\n", Zff1)
+	fmt.Fprintf(o.out, "Zff(2): %v\n", Zff2)
+	fmt.Fprintf(o.out, "Zff(0): %v\n", Zff0)
+	Ifa1, Ifa2, Ifa0, err := psa.ComputeSequenceFault(*faultType, Zff1, Zff2, Zff0, 0)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(o.out, "Ifa(1) = %v\n", Ifa1)
+	fmt.Fprintf(o.out, "Ifa(2) = %v\n", Ifa2)
+	fmt.Fprintf(o.out, "Ifa(0) = %v\n", Ifa0)
+	Ifa, Ifb, Ifc := psa.PhaseComponents(Ifa1, Ifa2, Ifa0)
+	fmt.Fprintf(o.out, "Ifa = %v\n", Ifa)
+	fmt.Fprintf(o.out, "Ifb = %v\n", Ifb)
+	fmt.Fprintf(o.out, "Ifc = %v\n", Ifc)
+	// 短路点的各序电压
+	Vfa1 := 1 - Zff1*Ifa1
+	Vfa2 := -Zff2 * Ifa2
+	Vfa0 := -Zff0 * Ifa0
+	Vfa, Vfb, Vfc := psa.PhaseComponents(Vfa1, Vfa2, Vfa0)
+	fmt.Fprintf(o.out, "Vfa = %v\n", Vfa)
+	fmt.Fprintf(o.out, "Vfb = %v\n", Vfb)
+	fmt.Fprintf(o.out, "Vfc = %v\n", Vfc)
+	// 序网参数已经是标幺值, 只有给出-vb时才能换算有名值
+	result, err := o.newResult(nil, 0)
+	if err != nil {
+		return err
+	}
+	result.AddQuantity("Zff(1)", Zff1, psa.Impedance)
+	result.AddQuantity("Zff(2)", Zff2, psa.Impedance)
+	result.AddQuantity("Zff(0)", Zff0, psa.Impedance)
+	result.AddQuantity("Ifa(1)", Ifa1, psa.Current)
+	result.AddQuantity("Ifa(2)", Ifa2, psa.Current)
+	result.AddQuantity("Ifa(0)", Ifa0, psa.Current)
+	result.AddQuantity("Ifa", Ifa, psa.Current)
+	result.AddQuantity("Ifb", Ifb, psa.Current)
+	result.AddQuantity("Ifc", Ifc, psa.Current)
+	result.AddQuantity("Vfa", Vfa, psa.Voltage)
+	result.AddQuantity("Vfb", Vfb, psa.Voltage)
+	result.AddQuantity("Vfc", Vfc, psa.Voltage)
+
+	// 各序网络按母线计算的电压映射回物理节点
+	V1 := printSequenceVoltages(o, result, "正序", "1", topologies[0], parser1, network.F1, -Ifa1, 1)
+	V2 := printSequenceVoltages(o, result, "负序", "2", topologies[1], parser2, network.F2, -Ifa2, 0)
+	printSequenceVoltages(o, result, "零序", "0", topologies[2], parser0, network.F0, -Ifa0, 0)
+	// 正序网络中的接地支路为电源, 零序电流不流过发电机端, 端电压a相为正序和负序电压之和
+	k := 0
+	for _, branch := range network.Grid1 {
+		if branch.Node1 != 0 && branch.Node2 != 0 {
+			continue
+		}
+		k++
+		node := topologies[0].NodeOf(branch.Node1 + branch.Node2)
+		Vg := V1[node-1]
+		if node <= len(V2) {
+			Vg += V2[node-1]
+		}
+		fmt.Fprintf(o.out, "Vg%d = %v\n", k, Vg)
+		result.AddQuantity(fmt.Sprintf("Vg%d", k), Vg, psa.Voltage)
+	}
+	return o.write(result)
+}
+
+// 短路后序网各节点的电压 V = E + Z(i,f)*I, 正序网络的E为1, 负序和零序为0, 返回按物理节点的电压
+func printSequenceVoltages(o *options, result *psa.Result, name, seq string, t *psa.Topology, parser *psa.Parser, f int, I, E complex128) []complex128 {
+	V := make([]complex128, parser.NodeNum())
+	for i := range V {
+		V[i] = E + parser.ResultZ().At(i+1, f)*I
+	}
+	fmt.Fprintf(o.out, "%s网络各节点电压:\n", name)
+	values := t.ToNodeValues(V)
+	for node, v := range values {
+		if t.BusOf(node+1) <= 0 {
+			continue
+		}
+		label := fmt.Sprintf("V%d(%s)", node+1, seq)
+		fmt.Fprintf(o.out, "%s = %v\n", label, v)
+		result.AddQuantity(label, v, psa.Voltage)
+	}
+	return values
+}
+
+// 合并各序网络中由闭合开关连接的节点后建立正序、负序和零序网络的Parser,
+// network中的短路点和距离继电器换算为母线号
+func (o *options) sequenceParsers(network *psa.SequenceNetwork) ([3]*psa.Parser, [3]*psa.Topology, error) {
+	var parsers [3]*psa.Parser
+	reduced, topologies, err := network.ReduceTopology()
+	if err != nil {
+		return parsers, topologies, err
+	}
+	*network = reduced
+	for i, grid := range [][]psa.Branch{network.Grid1, network.Grid2, network.Grid0} {
+		if parsers[i], err = o.sequenceParser(grid); err != nil {
+			return parsers, topologies, err
+		}
+	}
+	return parsers, topologies, nil
+}
+
+func (o *options) sequenceParser(branches []psa.Branch) (*psa.Parser, error) {
+	parser, err := psa.NewBranchParser(branches)
+	if err != nil {
+		return nil, err
+	}
+	if err := o.configure(parser); err != nil {
+		return nil, err
+	}
+	if err := parser.ComputeResult(); err != nil {
+		return nil, err
+	}
+	return parser, nil
+}
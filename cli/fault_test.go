@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"power-system-analysis-labs/psa"
+)
+
+func TestFault3phDefaultBaseVoltage(t *testing.T) {
+	path := writeNetwork(t, "two.json", twoNodeNetwork)
+	out, code := runCommand(t, "fault3ph", "-node", "2", path)
+	if code != 0 {
+		t.Fatalf("exit code %d, output %s", code, out)
+	}
+	// 网络没有Vav时按115kV: Ik = 1/0.3 * 100/(√3*115) = 1.6735kA
+	if !strings.Contains(out, "短路电流: (0-3.3333333333333335i)") {
+		t.Errorf("missing If in\n%s", out)
+	}
+	if !strings.Contains(out, "三相次暂态电流有名值:\n1.67347904") {
+		t.Errorf("missing Ik at 115 kV in\n%s", out)
+	}
+}
+
+func TestFault3phJSON(t *testing.T) {
+	path := writeNetwork(t, "two.json", twoNodeNetwork)
+	out, code := runCommand(t, "fault3ph", "-node", "2", "-output", "json", "-unit", "si", path)
+	if code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+	var result psa.Result
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("%v in\n%s", err, out)
+	}
+	quantities := map[string]psa.Quantity{}
+	for _, q := range result.Quantities {
+		quantities[q.Name] = q
+	}
+	ik := 100 / (math.Sqrt(3) * 115) / 0.3
+	want := map[string]float64{
+		"If": ik,
+		"Ik": ik,
+		// ish = 1.8√2 Ik
+		"ish": 1.8 * math.Sqrt2 * ik,
+		// Sk = SB/|Zff|
+		"Sk": 100 / 0.3,
+		// z21 = 0.3 * 115²/100
+		"z21": 0.3 * 115 * 115 / 100,
+	}
+	for name, mag := range want {
+		q, exist := quantities[name]
+		if !exist {
+			t.Errorf("missing %s", name)
+			continue
+		}
+		if math.Abs(q.Mag-mag) > 1e-9*mag {
+			t.Errorf("|%s| = %v %s, want %v", name, q.Mag, q.Unit, mag)
+		}
+	}
+	// 短路后节点1的电压 1 - 0.1/0.3
+	if len(result.BusVoltages) != 2 || math.Abs(result.BusVoltages[0].Mag-115*2.0/3) > 1e-9 {
+		t.Errorf("bus voltages = %+v", result.BusVoltages)
+	}
+}
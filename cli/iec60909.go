@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"fmt"
+	"math"
+
+	"power-system-analysis-labs/psa"
+)
+
+// 按IEC 60909计算三相短路电流
+func runIEC60909(args []string) error {
+	o := newOptions("iec60909")
+	node := o.flags.Int("node", 0, "短路点")
+	calc := o.flags.String("case", "max", "计算最大(max)或最小(min)短路电流")
+	var s psa.IEC60909
+	o.flags.Float64Var(&s.C, "c", 0, "电压系数, 默认按标称电压和计算情况选取")
+	o.flags.StringVar(&s.Method, "method", psa.IECMethodB, "冲击系数的计算方法: A, B, C")
+	o.flags.Float64Var(&s.Un, "un", 0, "标称电压(kV), 默认使用网络的Vav")
+	o.flags.Float64Var(&s.Tmin, "tmin", 0.1, "最小开断时间(s)")
+	o.flags.Float64Var(&s.Tk, "tk", 1, "短路持续时间(s)")
+	o.flags.Float64Var(&s.Frequency, "f", 50, "系统频率(Hz)")
+	if err := o.parse(args); err != nil {
+		return err
+	}
+	switch *calc {
+	case "max":
+		s.Max = true
+	case "min":
+	default:
+		return fmt.Errorf("未知的计算情况: %s", *calc)
+	}
+	parser, err := o.loadParserWith(func(network psa.PowerNetwork) (*psa.Parser, error) {
+		return psa.NewIEC60909Parser(network, s)
+	})
+	if err != nil {
+		return err
+	}
+	if err := parser.ComputeResult(); err != nil {
+		return err
+	}
+	if err := o.require("node", "输入短路点:", node); err != nil {
+		return err
+	}
+	f, err := busOf(parser, *node)
+	if err != nil {
+		return err
+	}
+	r, err := parser.ComputeIEC60909(f)
+	if err != nil {
+		return err
+	}
+	// 电流是以Vav为基准的标幺值, 已经按Un/Vav计入了标称电压; 没有Vav时按Un换算有名值
+	vb := parser.Vav
+	if vb == 0 {
+		vb = s.Un
+	}
+	if o.vb != 0 {
+		vb = o.vb
+	}
+	result, err := o.newResult(parser, vb)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(o.out, "电压系数 c = %.2f\n", r.C)
+	fmt.Fprintf(o.out, "短路阻抗 Zk = %v\n", r.Zk)
+	fmt.Fprintf(o.out, "R/X = %.4f, κ = %.4f\n", r.RX, r.Kappa)
+	fmt.Fprintln(o.out, "标幺值:")
+	printIEC60909(o, r, 1)
+	if vb != 0 && parser.SB != 0 {
+		fmt.Fprintln(o.out, "有名值(kA):")
+		printIEC60909(o, r, parser.SB/(math.Sqrt(3)*vb))
+	}
+	result.AddValue("c", complex(r.C, 0), "")
+	result.AddQuantity("Zk", r.Zk, psa.Impedance)
+	// JSON不能表示无穷大, 纯电阻时不输出R/X
+	if !math.IsInf(r.RX, 0) {
+		result.AddValue("R/X", complex(r.RX, 0), "")
+	}
+	result.AddValue("kappa", complex(r.Kappa, 0), "")
+	result.AddQuantity("Ik''", complex(r.Ik, 0), psa.Current)
+	result.AddQuantity("ip", complex(r.Ip, 0), psa.Current)
+	result.AddQuantity("Ib", complex(r.Ib, 0), psa.Current)
+	result.AddQuantity("Ith", complex(r.Ith, 0), psa.Current)
+	return o.write(result)
+}
+
+func printIEC60909(o *options, r *psa.IEC60909Result, k float64) {
+	fmt.Fprintf(o.out, "Ik'' = %.4f\n", r.Ik*k)
+	fmt.Fprintf(o.out, "ip = %.4f\n", r.Ip*k)
+	fmt.Fprintf(o.out, "Ib = %.4f\n", r.Ib*k)
+	fmt.Fprintf(o.out, "Ith = %.4f\n", r.Ith*k)
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"fmt"
+
+	"power-system-analysis-labs/psa"
+)
+
+// 小干扰稳定分析: 状态矩阵的特征值、振荡频率、阻尼比和参与因子
+func runModes(args []string) error {
+	o := newOptions("modes")
+	frequency := o.flags.Float64("f", 50, "系统频率(Hz)")
+	if err := o.parse(args); err != nil {
+		return err
+	}
+	parser, err := o.loadParser()
+	if err != nil {
+		return err
+	}
+	s, err := parser.ComputeSmallSignal(*frequency)
+	if err != nil {
+		return err
+	}
+	result, err := o.newResult(parser, parser.Vav)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(o.out, "模式\t特征值\t频率(Hz)\t阻尼比\t主要参与的状态")
+	for i, mode := range s.Modes {
+		// 参与因子最大的状态变量
+		k := 0
+		for j, p := range mode.Participation {
+			if p > mode.Participation[k] {
+				k = j
+			}
+		}
+		fmt.Fprintf(o.out, "%d\t%.4f\t%.4f\t%.4f\t%s(%.2f)\n", i+1, mode.Eigenvalue, mode.Frequency, mode.Damping, s.States[k], mode.Participation[k])
+	}
+	verdict := "稳定"
+	if !s.Stable {
+		verdict = "不稳定"
+	}
+	fmt.Fprintf(o.out, "小干扰%s\n", verdict)
+	addSmallSignal(result, s)
+	return o.write(result)
+}
+
+func addSmallSignal(result *psa.Result, s *psa.SmallSignal) {
+	result.AddMatrix("A", s.A, psa.Dimensionless)
+	result.AddMatrix("K", s.K, psa.Power)
+	participation := psa.NewComplexMatrix(len(s.States), len(s.Modes))
+	for i, mode := range s.Modes {
+		name := fmt.Sprintf("mode%d", i+1)
+		result.AddValue(name+".lambda", mode.Eigenvalue, "")
+		result.AddValue(name+".f", complex(mode.Frequency, 0), "Hz")
+		result.AddValue(name+".zeta", complex(mode.Damping, 0), "")
+		for k, p := range mode.Participation {
+			participation.Set(k+1, i+1, complex(p, 0))
+		}
+	}
+	// 行为状态变量, 依次为各发电机的Δδ和Δω, 列为模式
+	result.AddMatrix("participation", participation, psa.Dimensionless)
+	stable := 0.0
+	if s.Stable {
+		stable = 1
+	}
+	result.AddValue("stable", complex(stable, 0), "")
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"power-system-analysis-labs/psa"
+)
+
+// 导纳矩阵和阻抗矩阵的行列为母线号, 与节点号不同时由printBuses给出母线的组成
+func printResultMatrix(w io.Writer, m *psa.ComplexMatrix) {
+	result := m.Values()
+	for i := 0; i < len(result); i++ {
+		for j := 0; j < len(result[i]); j++ {
+			c := result[i][j]
+			fmt.Fprintf(w, "%.3f", real(c))
+			if imag(c) >= 0 {
+				fmt.Fprintf(w, " + ")
+			} else {
+				fmt.Fprintf(w, " - ")
+			}
+			fmt.Fprintf(w, "j%.3f\t\t", math.Abs(imag(c)))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func printBuses(w io.Writer, t *psa.Topology) {
+	busNodes := t.BusNodes()
+	for i := 0; i < len(busNodes); i++ {
+		fmt.Fprintf(w, "母线%d: 节点%v\n", i+1, busNodes[i])
+	}
+}
@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"power-system-analysis-labs/server"
+)
+
+// 启动HTTP服务, 接口见server包
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", ":8080", "监听的地址")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	s := &http.Server{
+		Addr:         *addr,
+		Handler:      server.NewHandler(),
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 60 * time.Second,
+	}
+	log.Printf("监听 %s", *addr)
+	return s.ListenAndServe()
+}
@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"strings"
+
+	"power-system-analysis-labs/psa"
+)
+
+// 三相短路的暂态稳定仿真, 输出各发电机的功角曲线, 可以搜索临界切除时间
+func runStability(args []string) error {
+	o := newOptions("stability")
+	node := o.flags.Int("node", 0, "短路点")
+	trip := o.flags.String("trip", "", "切除短路时断开的线路, 形式为i,j, 默认只切除短路")
+	cct := o.flags.Bool("cct", false, "用二分法搜索临界切除时间")
+	var opts psa.StabilityOptions
+	o.flags.Float64Var(&opts.ClearTime, "clear", 0.1, "短路切除时间(s)")
+	o.flags.Float64Var(&opts.Duration, "duration", 2, "仿真时间(s)")
+	o.flags.Float64Var(&opts.Step, "step", 0.001, "积分步长(s)")
+	o.flags.Float64Var(&opts.Frequency, "f", 50, "系统频率(Hz)")
+	o.flags.StringVar(&opts.Method, "method", psa.IntegratorRK4, "积分方法: rk4, trapezoidal")
+	o.flags.Float64Var(&opts.MaxAngle, "max-angle", 180, "判为失稳的功角差(度)")
+	if err := o.parse(args); err != nil {
+		return err
+	}
+	parser, err := o.loadParser()
+	if err != nil {
+		return err
+	}
+	if err := o.require("node", "输入短路点:", node); err != nil {
+		return err
+	}
+	if opts.Fault, err = busOf(parser, *node); err != nil {
+		return err
+	}
+	if *trip != "" {
+		if opts.TripNode1, opts.TripNode2, err = tripNodes(parser, *trip); err != nil {
+			return err
+		}
+	}
+	s, err := parser.SimulateStability(opts)
+	if err != nil {
+		return err
+	}
+	result, err := o.newResult(parser, parser.Vav)
+	if err != nil {
+		return err
+	}
+	for i, name := range s.Machines {
+		fmt.Fprintf(o.out, "%s: E' = %.4f∠%.2f°, Pm = %.4f\n", name, cmplx.Abs(s.E[i]), cmplx.Phase(s.E[i])*180/math.Pi, s.Pm[i])
+	}
+	verdict := "稳定"
+	if !s.Stable {
+		verdict = "失稳"
+	}
+	fmt.Fprintf(o.out, "切除时间 %.4fs, 最大功角差 %.2f°, %s\n", opts.ClearTime, s.MaxSeparation, verdict)
+	if *cct {
+		tc, err := parser.CriticalClearingTime(opts)
+		if err != nil {
+			return err
+		}
+		if math.IsInf(tc, 1) {
+			fmt.Fprintln(o.out, "临界切除时间: 仿真时间内短路不切除也不失稳")
+		} else {
+			fmt.Fprintf(o.out, "临界切除时间: %.4fs\n", tc)
+			result.AddValue("tcr", complex(tc, 0), "s")
+		}
+	}
+	fmt.Fprintf(o.out, "t(s)\t%s\n", strings.Join(s.Machines, "\t"))
+	for k, time := range s.Time {
+		fmt.Fprintf(o.out, "%.4f", time)
+		for i := range s.Machines {
+			fmt.Fprintf(o.out, "\t%.2f", s.Angles[i][k])
+		}
+		fmt.Fprintln(o.out)
+	}
+	addStability(result, s)
+	return o.write(result)
+}
+
+// -trip给出的线路两端节点
+func tripNodes(parser *psa.Parser, s string) (int, int, error) {
+	nodes, err := parseNodes(s)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(nodes) != 2 {
+		return 0, 0, fmt.Errorf("-trip应为i,j的形式: %s", s)
+	}
+	node1, err := busOf(parser, nodes[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	node2, err := busOf(parser, nodes[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	return node1, node2, nil
+}
+
+func addStability(result *psa.Result, s *psa.StabilityResult) {
+	for i, name := range s.Machines {
+		result.AddQuantity(name+".E'", s.E[i], psa.Voltage)
+		result.AddQuantity(name+".Pm", complex(s.Pm[i], 0), psa.Power)
+	}
+	result.AddValue("max_separation", complex(s.MaxSeparation, 0), "°")
+	stable := 0.0
+	if s.Stable {
+		stable = 1
+	}
+	result.AddValue("stable", complex(stable, 0), "")
+	result.AddMatrix("Y_pre", s.Pre, psa.Admittance)
+	result.AddMatrix("Y_during", s.During, psa.Admittance)
+	result.AddMatrix("Y_post", s.Post, psa.Admittance)
+	result.SetTime(s.Time)
+	for i, name := range s.Machines {
+		result.AddWaveformValue(name+".delta", s.Angles[i], "°")
+		result.AddWaveform(name+".omega", s.Speeds[i], psa.Dimensionless)
+	}
+}
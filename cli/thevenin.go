@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"fmt"
+	"math"
+
+	"power-system-analysis-labs/psa"
+)
+
+// 节点对地或两个节点之间的戴维南等值, 用于新电源接入的研究
+func runThevenin(args []string) error {
+	o := newOptions("thevenin")
+	node := o.flags.Int("node", 0, "节点, 各序网络时默认为文件中的f1、f2和f0")
+	node2 := o.flags.Int("node2", 0, "另一个节点, 给出时计算两个节点之间的等值")
+	seq := o.flags.Bool("seq", false, "输入为各序网络, 同时给出正序、负序和零序的等值")
+	if err := o.parse(args); err != nil {
+		return err
+	}
+	if *seq {
+		return theveninSequence(o, *node, *node2)
+	}
+	if err := o.require("node", "输入节点:", node); err != nil {
+		return err
+	}
+	parser, err := o.loadParser()
+	if err != nil {
+		return err
+	}
+	if err := parser.ComputeResult(); err != nil {
+		return err
+	}
+	bus1, err := busOf(parser, *node)
+	if err != nil {
+		return err
+	}
+	bus2 := 0
+	if *node2 != 0 {
+		if bus2, err = busOf(parser, *node2); err != nil {
+			return err
+		}
+	}
+	U, err := parser.PrefaultVoltages()
+	if err != nil {
+		return err
+	}
+	th, err := parser.Thevenin(bus1, bus2, U)
+	if err != nil {
+		return err
+	}
+	th.Node1, th.Node2 = *node, *node2
+	result, err := o.newResult(parser, parser.Vav)
+	if err != nil {
+		return err
+	}
+	printThevenin(o, result, "", th)
+	return o.write(result)
+}
+
+// 各序网络的节点编号可能不同, node2对三个网络相同
+func theveninSequence(o *options, node, node2 int) error {
+	network, err := psa.ImportSequenceNetworkFromFile(o.path, o.format)
+	if err != nil {
+		return err
+	}
+	if node != 0 {
+		network.F1, network.F2, network.F0 = node, node, node
+	}
+	if err := network.Validate(); err != nil {
+		return err
+	}
+	// 戴维南等值按物理节点标注
+	nodes := [3]int{network.F1, network.F2, network.F0}
+	parsers, topologies, err := o.sequenceParsers(&network)
+	if err != nil {
+		return err
+	}
+	var buses2 [3]int
+	if node2 != 0 {
+		for i, t := range topologies {
+			if buses2[i] = t.BusOf(node2); buses2[i] <= 0 {
+				return fmt.Errorf("节点%d不在网络中、已接地或不带电", node2)
+			}
+		}
+	}
+	th1, err := parsers[0].Thevenin(network.F1, buses2[0], nil)
+	if err != nil {
+		return err
+	}
+	// 负序和零序网络没有电源, 开路电压为0
+	th2, err := parsers[1].Thevenin(network.F2, buses2[1], make([]complex128, parsers[1].NodeNum()))
+	if err != nil {
+		return err
+	}
+	th0, err := parsers[2].Thevenin(network.F0, buses2[2], make([]complex128, parsers[2].NodeNum()))
+	if err != nil {
+		return err
+	}
+	for i, th := range []*psa.TheveninEquivalent{th1, th2, th0} {
+		th.Node1, th.Node2 = nodes[i], node2
+	}
+	// 序网参数已经是标幺值, 只有给出-vb时才能换算有名值
+	result, err := o.newResult(nil, 0)
+	if err != nil {
+		return err
+	}
+	printThevenin(o, result, "(1)", th1)
+	printThevenin(o, result, "(2)", th2)
+	printThevenin(o, result, "(0)", th0)
+	return o.write(result)
+}
+
+func printThevenin(o *options, result *psa.Result, suffix string, th *psa.TheveninEquivalent) {
+	if th.Node2 == 0 {
+		fmt.Fprintf(o.out, "节点%d的戴维南等值%s:\n", th.Node1, suffix)
+	} else {
+		fmt.Fprintf(o.out, "节点%d-%d之间的戴维南等值%s:\n", th.Node1, th.Node2, suffix)
+	}
+	fmt.Fprintf(o.out, "Zth = %v\n", th.Zth)
+	fmt.Fprintf(o.out, "Voc = %v\n", th.Voc)
+	fmt.Fprintf(o.out, "X/R = %.3f\n", th.XR)
+	fmt.Fprintf(o.out, "Sk = %.3f\n", th.Sk)
+	result.AddQuantity("Zth"+suffix, th.Zth, psa.Impedance)
+	result.AddQuantity("Voc"+suffix, th.Voc, psa.Voltage)
+	// JSON不能表示无穷大, 纯电抗时不输出X/R
+	if !math.IsInf(th.XR, 0) {
+		result.AddValue("X/R"+suffix, complex(th.XR, 0), "")
+	}
+	result.AddQuantity("Sk"+suffix, complex(th.Sk, 0), psa.Power)
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"fmt"
+	"math"
+
+	"power-system-analysis-labs/psa"
+)
+
+// 三相短路电流随时间的变化, 输出次暂态、暂态和稳态电流以及采样波形
+func runTrace(args []string) error {
+	o := newOptions("trace")
+	node := o.flags.Int("node", 0, "短路点")
+	var opts psa.TraceOptions
+	o.flags.Float64Var(&opts.Duration, "duration", 1, "时间窗口(s)")
+	o.flags.Float64Var(&opts.Step, "step", 0.001, "采样步长(s)")
+	o.flags.Float64Var(&opts.Frequency, "f", 50, "系统频率(Hz)")
+	if err := o.parse(args); err != nil {
+		return err
+	}
+	parser, err := o.loadParser()
+	if err != nil {
+		return err
+	}
+	if err := parser.ComputeResult(); err != nil {
+		return err
+	}
+	if err := o.require("node", "输入短路点:", node); err != nil {
+		return err
+	}
+	f, err := busOf(parser, *node)
+	if err != nil {
+		return err
+	}
+	t, err := parser.ComputeFaultTrace(f, opts)
+	if err != nil {
+		return err
+	}
+	result, err := o.newResult(parser, parser.Vav)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(o.out, "I'' = %.4f, I' = %.4f, I = %.4f\n", t.Isubtransient, t.Itransient, t.Isteady)
+	fmt.Fprintf(o.out, "Td'' = %.4fs, Td' = %.4fs, Ta = %.4fs\n", t.Tdpp, t.Tdp, t.Ta)
+	fmt.Fprintln(o.out, "t(s)\t包络线\t直流分量\ti(t)")
+	for i, time := range t.Time {
+		fmt.Fprintf(o.out, "%.4f\t%.4f\t%.4f\t%.4f\n", time, t.Envelope[i], t.DC[i], t.Current[i])
+	}
+	addFaultTrace(result, t)
+	return o.write(result)
+}
+
+func addFaultTrace(result *psa.Result, t *psa.FaultTrace) {
+	result.AddQuantity("I''", complex(t.Isubtransient, 0), psa.Current)
+	result.AddQuantity("I'", complex(t.Itransient, 0), psa.Current)
+	result.AddQuantity("I", complex(t.Isteady, 0), psa.Current)
+	result.AddValue("Td''", complex(t.Tdpp, 0), "s")
+	result.AddValue("Td'", complex(t.Tdp, 0), "s")
+	// JSON不能表示无穷大, 直流分量不衰减时不输出Ta
+	if !math.IsInf(t.Ta, 0) {
+		result.AddValue("Ta", complex(t.Ta, 0), "s")
+	}
+	result.SetTime(t.Time)
+	result.AddWaveform("envelope", t.Envelope, psa.Current)
+	result.AddWaveform("dc", t.DC, psa.Current)
+	result.AddWaveform("i", t.Current, psa.Current)
+}
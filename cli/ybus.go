@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"fmt"
+
+	"power-system-analysis-labs/psa"
+)
+
+// 实验一: 节点导纳矩阵和节点三相短路后的导纳矩阵
+func runYbus(args []string) error {
+	o := newOptions("ybus")
+	node := o.flags.Int("node", 0, "发生三相短路的节点")
+	line := o.flags.String("line", "", "中点发生三相短路的线路, 形式为i,j")
+	if err := o.parse(args); err != nil {
+		return err
+	}
+	parser, err := o.loadParser()
+	if err != nil {
+		return err
+	}
+	parser.ComputeResultY()
+	result, err := o.newResult(parser, parser.Vav)
+	if err != nil {
+		return err
+	}
+	result.SetBuses(parser.Topology())
+	result.AddMatrix("Y", parser.ResultY(), psa.Admittance)
+	fmt.Fprintln(o.out, "节点导纳矩阵：")
+	printResultMatrix(o.out, parser.ResultY())
+	if o.interactive && *node == 0 {
+		o.ask("输入发生三相短路的节点: ", node)
+	}
+	if *node != 0 {
+		bus, err := busOf(parser, *node)
+		if err != nil {
+			return err
+		}
+		Y, err := parser.ShortCircuitMatrix(bus)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(o.out, "节点%d发生三相短路的节点导纳矩阵：\n", *node)
+		printResultMatrix(o.out, Y)
+		result.AddMatrix(fmt.Sprintf("Y_short_%d", *node), Y, psa.Admittance)
+	}
+	if *line != "" || o.interactive {
+		if err := midline(o, parser, result, *line); err != nil {
+			return err
+		}
+	}
+	return o.write(result)
+}
+
+// 线路中点发生三相短路
+func runMidline(args []string) error {
+	o := newOptions("midline")
+	line := o.flags.String("line", "", "中点发生三相短路的线路, 形式为i,j")
+	if err := o.parse(args); err != nil {
+		return err
+	}
+	if *line == "" && !o.interactive {
+		return fmt.Errorf("缺少参数 -line")
+	}
+	parser, err := o.loadParser()
+	if err != nil {
+		return err
+	}
+	parser.ComputeResultY()
+	result, err := o.newResult(parser, parser.Vav)
+	if err != nil {
+		return err
+	}
+	if err := midline(o, parser, result, *line); err != nil {
+		return err
+	}
+	return o.write(result)
+}
+
+func midline(o *options, parser *psa.Parser, result *psa.Result, line string) error {
+	var i, j int
+	if line != "" {
+		var err error
+		if i, j, err = parseLine(line); err != nil {
+			return err
+		}
+	} else {
+		o.ask("输入中点发生三相短路的两个节点的第一个", &i)
+		o.ask("输入中点发生三相短路的两个节点的第二个", &j)
+	}
+	bus1, err := busOf(parser, i)
+	if err != nil {
+		return err
+	}
+	bus2, err := busOf(parser, j)
+	if err != nil {
+		return err
+	}
+	Y, err := parser.HalfShortCircuitMatrix(bus1, bus2)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(o.out, "线路%d-%d中点发生三相短路的节点导纳矩阵: \n", i, j)
+	printResultMatrix(o.out, Y)
+	result.AddMatrix(fmt.Sprintf("Y_midline_%d_%d", i, j), Y, psa.Admittance)
+	return nil
+}
+
+// 实验二的前半部分: 导纳矩阵的LDU分解和阻抗矩阵
+func runZbus(args []string) error {
+	o := newOptions("zbus")
+	if err := o.parse(args); err != nil {
+		return err
+	}
+	parser, err := o.loadParser()
+	if err != nil {
+		return err
+	}
+	result, err := o.newResult(parser, parser.Vav)
+	if err != nil {
+		return err
+	}
+	if err := zbus(o, parser, result, true); err != nil {
+		return err
+	}
+	return o.write(result)
+}
+
+// 计算并打印导纳矩阵和阻抗矩阵, ldu为true时同时给出LDU分解
+func zbus(o *options, parser *psa.Parser, result *psa.Result, ldu bool) error {
+	if err := parser.ComputeResult(); err != nil {
+		return err
+	}
+	fmt.Fprintln(o.out, "节点导纳矩阵：")
+	printResultMatrix(o.out, parser.ResultY())
+	result.SetBuses(parser.Topology())
+	result.AddMatrix("Y", parser.ResultY(), psa.Admittance)
+	if ldu {
+		l, d, u, err := parser.LDU()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(o.out, "L:")
+		printResultMatrix(o.out, l)
+		fmt.Fprintln(o.out, "D:")
+		printResultMatrix(o.out, d)
+		fmt.Fprintln(o.out, "U:")
+		printResultMatrix(o.out, u)
+		result.AddMatrix("L", l, psa.Dimensionless)
+		result.AddMatrix("D", d, psa.Admittance)
+		result.AddMatrix("U", u, psa.Dimensionless)
+	}
+	Z := parser.ResultZ()
+	fmt.Fprintln(o.out, "阻抗矩阵: ")
+	printResultMatrix(o.out, Z)
+	result.AddMatrix("Z", Z, psa.Impedance)
+	cond, err := parser.ConditionNumber()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(o.out, "导纳矩阵的条件数: %.3e\n", cond)
+	if cond > 1e12 {
+		fmt.Fprintln(o.out, "警告: 导纳矩阵接近奇异, 阻抗矩阵可能不准确")
+	}
+	result.AddValue("cond", complex(cond, 0), "")
+	return nil
+}
+
+// 将网络换算为标幺值支路后导出, 格式按扩展名判断(.m为matpower)
+func runConvert(args []string) error {
+	o := newOptions("convert")
+	out := o.flags.String("out", "", "导出文件的路径")
+	if err := o.parse(args); err != nil {
+		return err
+	}
+	if *out == "" {
+		if !o.interactive {
+			return fmt.Errorf("缺少参数 -out")
+		}
+		o.ask("输入导出文件的路径:", out)
+	}
+	parser, err := o.loadParser()
+	if err != nil {
+		return err
+	}
+	return psa.ExportPowerNetwork(*out, parser.ExportNetwork())
+}
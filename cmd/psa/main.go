@@ -0,0 +1,18 @@
+// psa 电力系统分析命令行
+//
+//	psa ybus -node 3 lab1/test.json
+//	psa fault3ph -node 4 -output json lab3/test1.json
+//	psa faultseq -type 2phg lab4/test1.json
+//
+// 加上-i参数时缺少的参数从标准输入读取
+package main
+
+import (
+	"os"
+
+	"power-system-analysis-labs/cli"
+)
+
+func main() {
+	os.Exit(cli.Run(os.Args[1:]))
+}
@@ -0,0 +1,82 @@
+package psa
+
+import (
+	"testing"
+)
+
+func TestBreakerDutyRemoteSource(t *testing.T) {
+	// 115kV母线上的远方电源 z = 0.005 + j0.1, 5周波断路器
+	p, err := NewParser(PowerNetwork{
+		SB:       100,
+		Vav:      115,
+		Branches: []Branch{{Node1: 1, Resistance: 0.005, Reactance: 0.1, E: 1}},
+		Breakers: []Breaker{{Name: "CB1", Node: 1, InterruptingKA: 5, Cycles: 5}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	duties, err := p.ComputeBreakerDuty(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := duties[0]
+	// E/X = 1/0.1, X/R = 0.1/0.005, 没有近端电源
+	assertFloat(t, "E/X", d.EX, 10, 1e-9)
+	assertFloat(t, "X/R", d.XR, 20, 1e-9)
+	assertFloat(t, "NACD", d.NACD, 1, 0)
+	// 触头分离3周波, MF = sqrt(1 + 2e^(-4π*3/20))/1.1
+	assertFloat(t, "MF", d.MF, 1.037985813, 1e-8)
+	// 电流基准 100/(√3*115) kA
+	assertFloat(t, "Iint", d.InterruptingKA, 5.211142511, 1e-8)
+	assertFloat(t, "Iclose", d.ClosingKA, 13.053136521, 1e-8)
+	// 开断电流超过额定值5kA
+	if d.Pass {
+		t.Error("breaker rated 5 kA passed a 5.21 kA duty")
+	}
+}
+
+func TestBreakerDutyLocalGenerator(t *testing.T) {
+	// 100MVA、xd”=0.2的发电机直接接在短路母线上, 是近端电源
+	p, err := NewParser(PowerNetwork{
+		SB:              100,
+		PowerGenerators: []PowerGenerator{{Node: 1, Sn: 100, Xd: 0.2}},
+		Breakers:        []Breaker{{Node: 1, KV: 115, InterruptingKA: 10, Cycles: 5}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	duties, err := p.ComputeBreakerDuty(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := duties[0]
+	assertFloat(t, "E/X", d.EX, 5, 1e-9)
+	assertFloat(t, "NACD", d.NACD, 0, 1e-12)
+	// 没有电阻时X/R取1000; 交流分量按典型值衰减到 0.6 + 0.4e^(-0.05*30)
+	assertFloat(t, "X/R", d.XR, ansiMaxXR, 1e-9)
+	assertFloat(t, "MF", d.MF, 1.408672581, 1e-8)
+	assertFloat(t, "Iint", d.InterruptingKA, 3.536076061, 1e-8)
+	if !d.Pass {
+		t.Error("breaker rated 10 kA failed a 3.54 kA duty")
+	}
+}
+
+func TestAnsiMotorMultipliers(t *testing.T) {
+	for _, c := range []struct {
+		kW, rpm                  float64
+		firstCycle, interrupting float64
+	}{
+		// 大于1000hp
+		{1000, 0, 1, 1.5},
+		// 250hp以上的两极电动机
+		{200, 3600, 1, 1.5},
+		{200, 1800, 1.2, 3},
+		// 50hp以下开断网络中不计
+		{20, 0, 1.67, 0},
+	} {
+		first, interrupting := ansiMotorMultipliers(Motor{KW: c.kW, RPM: c.rpm})
+		if first != c.firstCycle || interrupting != c.interrupting {
+			t.Errorf("%vkW %vrpm: %v, %v, want %v, %v", c.kW, c.rpm, first, interrupting, c.firstCycle, c.interrupting)
+		}
+	}
+}
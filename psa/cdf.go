@@ -0,0 +1,163 @@
+package psa
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// 读取IEEE通用数据格式(Common Data Format)的算例
+//
+// 母线按出现顺序重新编号为1..n, 原始母线号保存在Bus.Number中;
+// 线路充电电纳的一半放入Branch.Admittance.
+func ReadCDF(r io.Reader) (*PowerNetwork, error) {
+	network := &PowerNetwork{}
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	// 原始母线号 -> 节点号
+	nodes := map[int]int{}
+	section := ""
+	for scanner.Scan() {
+		line := scanner.Text()
+		lineNum++
+		if lineNum == 1 {
+			sb, err := parseCDFFloat(columns(line, 31, 37))
+			if err != nil {
+				return nil, fmt.Errorf("cdf第%d行: 基准容量: %v", lineNum, err)
+			}
+			network.SB = sb
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if section == "" {
+			switch {
+			case strings.HasPrefix(trimmed, "BUS DATA FOLLOWS"):
+				section = "bus"
+			case strings.HasPrefix(trimmed, "BRANCH DATA FOLLOWS"):
+				section = "branch"
+			case strings.HasPrefix(trimmed, "END OF DATA"):
+				return network, scanner.Err()
+			case strings.HasSuffix(trimmed, "FOLLOWS"):
+				// 损耗区、交换功率等数据不需要
+				section = "skip"
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "-9") {
+			section = ""
+			continue
+		}
+		var err error
+		switch section {
+		case "bus":
+			err = network.readCDFBus(line, nodes)
+		case "branch":
+			err = network.readCDFBranch(line, nodes)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cdf第%d行: %v", lineNum, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(network.Buses) == 0 {
+		return nil, fmt.Errorf("cdf文件中没有母线数据")
+	}
+	return network, nil
+}
+
+func (network *PowerNetwork) readCDFBus(line string, nodes map[int]int) error {
+	number, err := strconv.Atoi(strings.TrimSpace(columns(line, 0, 4)))
+	if err != nil {
+		return fmt.Errorf("母线号: %v", err)
+	}
+	// 名称在6-17列, 其后的字段按空白分隔
+	fields := strings.Fields(columns(line, 17, len(line)))
+	if len(fields) < 15 {
+		return fmt.Errorf("母线%d的字段不足", number)
+	}
+	values := make([]float64, 15)
+	for i := 0; i < len(values); i++ {
+		if values[i], err = parseCDFFloat(fields[i]); err != nil {
+			return fmt.Errorf("母线%d: %v", number, err)
+		}
+	}
+	if _, exist := nodes[number]; exist {
+		return fmt.Errorf("母线%d重复", number)
+	}
+	bus := Bus{
+		Node:   len(network.Buses) + 1,
+		Number: number,
+		Name:   strings.TrimSpace(columns(line, 5, 17)),
+		Type:   int(values[2]),
+		V:      values[3],
+		Angle:  values[4],
+		Pd:     values[5],
+		Qd:     values[6],
+		Pg:     values[7],
+		Qg:     values[8],
+		BaseKV: values[9],
+		Gs:     values[13],
+		Bs:     values[14],
+	}
+	// cdf中0和1都是PQ母线
+	if bus.Type == 0 {
+		bus.Type = BusPQ
+	}
+	nodes[number] = bus.Node
+	network.Buses = append(network.Buses, bus)
+	return nil
+}
+
+func (network *PowerNetwork) readCDFBranch(line string, nodes map[int]int) error {
+	fields := strings.Fields(line)
+	if len(fields) < 15 {
+		return fmt.Errorf("支路字段不足")
+	}
+	values := make([]float64, 15)
+	var err error
+	for i := 0; i < len(values); i++ {
+		if values[i], err = parseCDFFloat(fields[i]); err != nil {
+			return fmt.Errorf("支路: %v", err)
+		}
+	}
+	node1, exist := nodes[int(values[0])]
+	if !exist {
+		return fmt.Errorf("支路的母线%d不存在", int(values[0]))
+	}
+	node2, exist := nodes[int(values[1])]
+	if !exist {
+		return fmt.Errorf("支路的母线%d不存在", int(values[1]))
+	}
+	branch := Branch{
+		Node1:      node1,
+		Node2:      node2,
+		Resistance: values[6],
+		Reactance:  values[7],
+		Admittance: 0.5 * values[8],
+	}
+	// 类型大于0为变压器, 变比位于分接头侧母线(第一个母线)
+	if int(values[5]) > 0 && values[14] != 0 {
+		branch.Ratio = values[14]
+	}
+	network.Branches = append(network.Branches, branch)
+	return nil
+}
+
+// 取出从0开始的[begin, end)列, 超出行长的部分忽略
+func columns(line string, begin, end int) string {
+	if begin >= len(line) {
+		return ""
+	}
+	if end > len(line) {
+		end = len(line)
+	}
+	return line[begin:end]
+}
+
+func parseCDFFloat(s string) (float64, error) {
+	return strconv.ParseFloat(strings.TrimSpace(s), 64)
+}
@@ -0,0 +1,84 @@
+package psa
+
+import (
+	"strings"
+	"testing"
+)
+
+// 两条母线的cdf算例, 母线号10和20, 之间是x=0.1、B=0.04、变比0.95的变压器
+const twoBusCDF = ` 08/19/93 UW ARCHIVE           100.0  1962 W TWO BUS TEST
+BUS DATA FOLLOWS                            2 ITEMS
+  10 Bus 10    HV  1  1  3 1.060    0.0      0.0      0.0    232.4   -16.9     0.0 1.060     0.0     0.0   0.0    0.0        0
+  20 Bus 20    LV  1  1  0 1.056 -14.94     29.5     16.6      0.0     0.0     0.0 0.0       0.0     0.0   0.0    0.19       0
+-999
+BRANCH DATA FOLLOWS                         1 ITEMS
+  10   20  1  1 1 1  0.0       0.1         0.04       0     0     0    0 0  0.95      0.0 0.0    0.0     0.0    0.0   0.0
+-999
+END OF DATA
+`
+
+func TestReadCDF(t *testing.T) {
+	network, err := ReadCDF(strings.NewReader(twoBusCDF))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if network.SB != 100 {
+		t.Errorf("SB = %v, want 100", network.SB)
+	}
+	if len(network.Buses) != 2 || len(network.Branches) != 1 {
+		t.Fatalf("%d buses, %d branches, want 2 and 1", len(network.Buses), len(network.Branches))
+	}
+	bus := network.Buses[1]
+	if bus.Node != 2 || bus.Number != 20 || bus.Name != "Bus 20    LV" || bus.Type != BusPQ {
+		t.Errorf("bus = %+v", bus)
+	}
+	assertFloat(t, "Pd", bus.Pd, 29.5, 0)
+	assertFloat(t, "Bs", bus.Bs, 0.19, 0)
+	if network.Buses[0].Type != BusSlack {
+		t.Errorf("bus 10 type = %d, want %d", network.Buses[0].Type, BusSlack)
+	}
+	branch := network.Branches[0]
+	if branch.Node1 != 1 || branch.Node2 != 2 {
+		t.Errorf("branch nodes = %d-%d, want 1-2", branch.Node1, branch.Node2)
+	}
+	assertFloat(t, "X", branch.Reactance, 0.1, 0)
+	// 充电电纳的一半
+	assertFloat(t, "B/2", branch.Admittance, 0.02, 0)
+	assertFloat(t, "ratio", branch.Ratio, 0.95, 0)
+
+	p, err := NewParser(*network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.ComputeResultY()
+	// y = -j10, Y11 = y/k² + jB/2, Y22 = y + jB/2 + jBs, Y12 = -y/k
+	Y := p.ResultY()
+	assertComplex(t, "Y11", Y.At(1, 1), complex(0, -10/(0.95*0.95)+0.02))
+	assertComplex(t, "Y22", Y.At(2, 2), complex(0, -10+0.02+0.19))
+	assertComplex(t, "Y12", Y.At(1, 2), complex(0, 10/0.95))
+}
+
+func TestReadCDFErrors(t *testing.T) {
+	lines := strings.Split(twoBusCDF, "\n")
+	duplicate := strings.Replace(twoBusCDF, lines[3], strings.Replace(lines[3], "  20 ", "  10 ", 1), 1)
+	if _, err := ReadCDF(strings.NewReader(duplicate)); err == nil {
+		t.Error("duplicate bus was accepted")
+	}
+	unknown := strings.Replace(twoBusCDF, lines[6], strings.Replace(lines[6], "   20 ", "   30 ", 1), 1)
+	if _, err := ReadCDF(strings.NewReader(unknown)); err == nil {
+		t.Error("branch to an unknown bus was accepted")
+	}
+}
+
+func TestReadIEEE14CDF(t *testing.T) {
+	network, err := ImportPowerNetworkFromFile("../lab1/ieee14.cdf", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(network.Buses) != 14 || len(network.Branches) != 20 {
+		t.Fatalf("%d buses, %d branches, want 14 and 20", len(network.Buses), len(network.Branches))
+	}
+	// 4-7的变压器变比0.978, 9号母线并联电容0.19
+	assertFloat(t, "ratio 4-7", network.Branches[7].Ratio, 0.978, 0)
+	assertFloat(t, "Bs 9", network.Buses[8].Bs, 0.19, 0)
+}
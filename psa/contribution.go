@@ -0,0 +1,150 @@
+package psa
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// 电源的种类
+const (
+	// 系统电源SG
+	SourceSystem = "SG"
+	// 发电机
+	SourceGenerator = "G"
+	// 综合负荷, 电势为0.8
+	SourceLoad = "Ld"
+	// 异步电动机
+	SourceMotor = "M"
+	// 网络数据中直接给出电势的接地支路
+	SourceBranch = "B"
+)
+
+// 网络中的一个电源
+type Source struct {
+	Kind string
+	// 在同类电源中的序号, 从1开始; 支路电源为其在网络支路中的序号
+	Index int
+	// 所在母线
+	Node int
+	// 电势, 标幺值
+	E float64
+	// 电源支路的阻抗zi
+	Z complex128
+}
+
+// 电源的名称, 例如SG、G1、Ld2、M1
+func (s Source) Name() string {
+	if s.Kind == SourceSystem {
+		return s.Kind
+	}
+	return fmt.Sprintf("%s%d", s.Kind, s.Index)
+}
+
+// 一个电源对短路点的贡献
+type SourceContribution struct {
+	Source
+	// 转移阻抗 zfi = Zff * zi / Zfi
+	Zf complex128
+	// 电流分布系数 ci = Zff / zfi = Zfi / zi
+	C complex128
+	// 提供的短路电流 Ei / zfi, 标幺值
+	I complex128
+	// 短路电流的有名值(kA), 没有基准电压时为0
+	KA float64
+}
+
+// 三相短路时各电源提供的短路电流
+type FaultContributions struct {
+	Node int
+	Zff  complex128
+	// 短路前短路点的开路电压, 由各电源的电势按叠加原理得到
+	Uf complex128
+	// 短路电流 Uf / Zff
+	If      complex128
+	Sources []SourceContribution
+	// 各电源电流之和, 与If之差不超过VerifyTolerance
+	Sum complex128
+	// 各电流分布系数之和, 网络中没有无源接地支路时为1
+	CSum complex128
+}
+
+// 网络中的各电源, 按系统电源、发电机、负荷、电动机、支路电源的顺序
+func (p *Parser) Sources() []Source {
+	var sources []Source
+	add := func(kind string, index int, branch Branch) {
+		node, isGroundBranch := p.isGroundBranch(branch)
+		if !isGroundBranch || node == 0 || branch.E == 0 {
+			return
+		}
+		sources = append(sources, Source{
+			Kind:  kind,
+			Index: index,
+			Node:  node,
+			E:     branch.E,
+			Z:     complex(branch.Resistance, branch.Reactance),
+		})
+	}
+	if p.network.SG != nil && p.network.SG.Node != 0 {
+		add(SourceSystem, 1, p.sgBranch(*p.network.SG))
+	}
+	for i, generator := range p.network.PowerGenerators {
+		add(SourceGenerator, i+1, p.powerGeneratorBranch(generator))
+	}
+	for i, ld := range p.network.Lds {
+		add(SourceLoad, i+1, p.ldBranch(ld))
+	}
+	for i, motor := range p.network.Motors {
+		add(SourceMotor, i+1, p.motorBranch(motor))
+	}
+	for i, branch := range p.network.Branches {
+		if !branch.OutOfService {
+			add(SourceBranch, i+1, branch)
+		}
+	}
+	return sources
+}
+
+// 计算节点f三相短路时各电源的转移阻抗、电流分布系数和提供的短路电流,
+// vb为短路点的基准电压(kV), 为0时使用Vav, 都没有时不计算有名值
+func (p *Parser) ComputeContributions(f int, vb float64) (*FaultContributions, error) {
+	Zff, err := p.zff(f)
+	if err != nil {
+		return nil, err
+	}
+	sources := p.Sources()
+	if len(sources) == 0 {
+		return nil, &InvalidFaultError{Node1: f, Reason: "网络中没有电源"}
+	}
+	U, err := p.PrefaultVoltages()
+	if err != nil {
+		return nil, err
+	}
+	if vb == 0 {
+		vb = p.Vav
+	}
+	Ib := 0.0
+	if vb != 0 && p.SB != 0 {
+		Ib = p.SB / (math.Sqrt(3) * vb)
+	}
+	c := &FaultContributions{Node: f, Zff: Zff, Uf: U[f-1]}
+	c.If = c.Uf / Zff
+	for _, source := range sources {
+		Zfi := p.resultZ.rcAt(f, source.Node)
+		if Zfi == 0 {
+			return nil, &InvalidFaultError{Node1: f, Reason: fmt.Sprintf("与节点%d上的电源%s不连通", source.Node, source.Name())}
+		}
+		s := SourceContribution{Source: source}
+		s.Zf = Zff * source.Z / Zfi
+		s.C = Zfi / source.Z
+		s.I = complex(source.E, 0) / s.Zf
+		s.KA = cmplx.Abs(s.I) * Ib
+		c.Sources = append(c.Sources, s)
+		c.Sum += s.I
+		c.CSum += s.C
+	}
+	if cmplx.Abs(c.Sum-c.If) > VerifyTolerance*math.Max(cmplx.Abs(c.If), 1) {
+		return nil, &ContributionError{Node: f, Sum: c.Sum, Total: c.If}
+	}
+	return c, nil
+}
@@ -0,0 +1,70 @@
+package psa
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestComputeContributions(t *testing.T) {
+	// 电源A x=0.1接在节点1, 电源B x=0.4接在节点2, 1-2的线路x=0.2, 节点2短路
+	p, err := NewParser(PowerNetwork{SB: 100, Branches: []Branch{
+		{Node1: 1, Reactance: 0.1, E: 1},
+		{Node1: 2, Reactance: 0.4, E: 1},
+		{Node1: 1, Node2: 2, Reactance: 0.2},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ComputeResult(); err != nil {
+		t.Fatal(err)
+	}
+	c, err := p.ComputeContributions(2, 115)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Zff = 0.3 // 0.4 = j0.12/0.7
+	assertComplex(t, "Zff", c.Zff, 0.12i/0.7)
+	if len(c.Sources) != 2 || c.Sources[0].Name() != "B1" || c.Sources[1].Name() != "B2" {
+		t.Fatalf("sources = %+v", c.Sources)
+	}
+	a, b := c.Sources[0], c.Sources[1]
+	// 电源A经线路到短路点, 转移阻抗就是 0.1 + 0.2
+	assertComplex(t, "zfA", a.Zf, 0.3i)
+	assertComplex(t, "zfB", b.Zf, 0.4i)
+	// 分布系数 cA = 0.4/0.7, cB = 0.3/0.7
+	assertComplex(t, "cA", a.C, 0.4/0.7)
+	assertComplex(t, "cB", b.C, 0.3/0.7)
+	assertComplex(t, "IA", a.I, 1/0.3i)
+	assertComplex(t, "IB", b.I, 1/0.4i)
+	assertComplex(t, "If", c.If, c.Sum)
+	assertComplex(t, "ΣC", c.CSum, 1)
+	// 电流基准 100/(√3*115) kA
+	assertFloat(t, "IA kA", a.KA, 100/(math.Sqrt(3)*115)/0.3, 1e-9)
+}
+
+func TestComputeContributionsErrors(t *testing.T) {
+	// 节点3是孤立的接地支路, 短路点上没有电源
+	p, err := NewParser(PowerNetwork{Branches: []Branch{
+		{Node1: 1, Reactance: 0.1, E: 1},
+		{Node1: 2, Reactance: 0.5},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ComputeResult(); err != nil {
+		t.Fatal(err)
+	}
+	var invalid *InvalidFaultError
+	if _, err := p.ComputeContributions(2, 0); !errors.As(err, &invalid) {
+		t.Errorf("fault isolated from the source: %v", err)
+	}
+	c, err := p.ComputeContributions(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 没有基准电压时不计算有名值
+	if c.Sources[0].KA != 0 {
+		t.Errorf("KA = %v without a base voltage", c.Sources[0].KA)
+	}
+}
@@ -0,0 +1,555 @@
+package psa
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+const (
+	// 牛顿-拉夫逊法的功率不平衡量容差(标幺值)和最大迭代次数
+	powerFlowTolerance = 1e-8
+	powerFlowMaxIter   = 30
+	// 连续潮流越过鼻点后, 负荷增长系数降到最大值的这个比例时停止
+	cpfStopFraction = 0.5
+	// 连续潮流的步长缩小到这个值仍不收敛时停止
+	cpfMinStep = 1e-5
+)
+
+// 潮流方程 F(x, λ) = S0 + λd - S(x) = 0, x依次为非平衡节点的相角和PQ节点的电压幅值,
+// 导纳矩阵不含电源支路, 不计发电机的无功限制
+type powerFlow struct {
+	n     int
+	Y     *ComplexMatrix
+	types []int
+	// 给定的注入功率和负荷增长方向(标幺值)
+	P0, Q0, dP, dQ []float64
+	V, theta       []float64
+	// 相角和电压幅值作为未知量的节点(从0开始)
+	angles, magnitudes []int
+}
+
+// 按母线数据建立潮流方程, 没有母线数据的节点作为无负荷的PQ节点
+func (p *Parser) newPowerFlow() (*powerFlow, error) {
+	if len(p.network.Buses) == 0 {
+		return nil, fmt.Errorf("潮流计算需要母线数据")
+	}
+	if p.SB == 0 {
+		return nil, fmt.Errorf("没有基准容量SB")
+	}
+	// 只由线路、变压器和母线并联导纳建立网络, 发电机、系统电源、负荷和电动机的支路都不计入,
+	// 负荷只按母线的Pd、Qd计入一次
+	passive := p.network
+	passive.SG = nil
+	passive.PowerGenerators, passive.Lds, passive.Motors = nil, nil, nil
+	passive.Branches = nil
+	for _, branch := range p.network.Branches {
+		if branch.E == 0 {
+			passive.Branches = append(passive.Branches, branch)
+		}
+	}
+	network := &Parser{SB: p.SB, Vav: p.Vav, network: passive, nodeNum: p.nodeNum}
+	network.parsePowerNetwork()
+	network.ComputeResultY()
+	n := p.nodeNum
+	pf := &powerFlow{
+		n:     n,
+		Y:     network.resultY,
+		types: make([]int, n),
+		P0:    make([]float64, n),
+		Q0:    make([]float64, n),
+		dP:    make([]float64, n),
+		dQ:    make([]float64, n),
+		V:     make([]float64, n),
+		theta: make([]float64, n),
+	}
+	for i := range pf.V {
+		pf.types[i] = BusPQ
+		pf.V[i] = 1
+	}
+	for _, bus := range p.network.Buses {
+		if bus.Node <= 0 || bus.Node > n {
+			continue
+		}
+		i := bus.Node - 1
+		// 拓扑合并到同一节点的母线: 平衡节点优先于PV节点
+		if bus.Type == BusSlack || (bus.Type == BusPV && pf.types[i] != BusSlack) {
+			pf.types[i] = bus.Type
+		}
+		if bus.V != 0 {
+			pf.V[i] = bus.V
+			pf.theta[i] = bus.Angle * math.Pi / 180
+		}
+		pf.P0[i] += (bus.Pg - bus.Pd) / p.SB
+		pf.Q0[i] += (bus.Qg - bus.Qd) / p.SB
+	}
+	slack := 0
+	for i, t := range pf.types {
+		switch t {
+		case BusSlack:
+			slack++
+		case BusPV:
+			pf.angles = append(pf.angles, i)
+		default:
+			pf.angles = append(pf.angles, i)
+			pf.magnitudes = append(pf.magnitudes, i)
+		}
+	}
+	if slack != 1 {
+		return nil, fmt.Errorf("潮流计算需要一个平衡节点, 网络中有%d个", slack)
+	}
+	return pf, nil
+}
+
+// 各节点注入的有功和无功功率
+func (pf *powerFlow) injections() (P, Q []float64) {
+	U := make([]complex128, pf.n)
+	for i := range U {
+		U[i] = cmplx.Rect(pf.V[i], pf.theta[i])
+	}
+	I, _ := pf.Y.MulVec(U)
+	P = make([]float64, pf.n)
+	Q = make([]float64, pf.n)
+	for i := range U {
+		S := U[i] * cmplx.Conj(I[i])
+		P[i], Q[i] = real(S), imag(S)
+	}
+	return P, Q
+}
+
+func (pf *powerFlow) size() int {
+	return len(pf.angles) + len(pf.magnitudes)
+}
+
+func (pf *powerFlow) state() []float64 {
+	x := make([]float64, 0, pf.size())
+	for _, i := range pf.angles {
+		x = append(x, pf.theta[i])
+	}
+	for _, i := range pf.magnitudes {
+		x = append(x, pf.V[i])
+	}
+	return x
+}
+
+func (pf *powerFlow) setState(x []float64) {
+	for k, i := range pf.angles {
+		pf.theta[i] = x[k]
+	}
+	for k, i := range pf.magnitudes {
+		pf.V[i] = x[len(pf.angles)+k]
+	}
+}
+
+// 功率不平衡量 F(x, λ)
+func (pf *powerFlow) mismatch(lambda float64) []float64 {
+	P, Q := pf.injections()
+	F := make([]float64, 0, pf.size())
+	for _, i := range pf.angles {
+		F = append(F, pf.P0[i]+lambda*pf.dP[i]-P[i])
+	}
+	for _, i := range pf.magnitudes {
+		F = append(F, pf.Q0[i]+lambda*pf.dQ[i]-Q[i])
+	}
+	return F
+}
+
+// ∂F/∂λ
+func (pf *powerFlow) direction() []float64 {
+	d := make([]float64, 0, pf.size())
+	for _, i := range pf.angles {
+		d = append(d, pf.dP[i])
+	}
+	for _, i := range pf.magnitudes {
+		d = append(d, pf.dQ[i])
+	}
+	return d
+}
+
+// 注入功率对x的雅可比矩阵 ∂S/∂x, 即 -∂F/∂x
+func (pf *powerFlow) jacobian() *mat.Dense {
+	P, Q := pf.injections()
+	m := pf.size()
+	J := mat.NewDense(m, m, nil)
+	na := len(pf.angles)
+	// 节点i的有功(无功)对节点j的相角和电压幅值的偏导数
+	partial := func(i, j int) (dPdt, dPdV, dQdt, dQdV float64) {
+		Yij := pf.Y.rcAt(i+1, j+1)
+		G, B := real(Yij), imag(Yij)
+		if i == j {
+			Vi := pf.V[i]
+			return -Q[i] - B*Vi*Vi, P[i]/Vi + G*Vi, P[i] - G*Vi*Vi, Q[i]/Vi - B*Vi
+		}
+		t := pf.theta[i] - pf.theta[j]
+		s, c := math.Sin(t), math.Cos(t)
+		ViVj := pf.V[i] * pf.V[j]
+		return ViVj * (G*s - B*c), pf.V[i] * (G*c + B*s), -ViVj * (G*c + B*s), pf.V[i] * (G*s - B*c)
+	}
+	for r, i := range pf.angles {
+		for c, j := range pf.angles {
+			dPdt, _, _, _ := partial(i, j)
+			J.Set(r, c, dPdt)
+		}
+		for c, j := range pf.magnitudes {
+			_, dPdV, _, _ := partial(i, j)
+			J.Set(r, na+c, dPdV)
+		}
+	}
+	for r, i := range pf.magnitudes {
+		for c, j := range pf.angles {
+			_, _, dQdt, _ := partial(i, j)
+			J.Set(na+r, c, dQdt)
+		}
+		for c, j := range pf.magnitudes {
+			_, _, _, dQdV := partial(i, j)
+			J.Set(na+r, na+c, dQdV)
+		}
+	}
+	return J
+}
+
+func maxAbs(v []float64) float64 {
+	m := 0.0
+	for _, x := range v {
+		m = math.Max(m, math.Abs(x))
+	}
+	return m
+}
+
+// 解线性方程组, 条件数过大只作为警告
+func solveDense(A *mat.Dense, b []float64) ([]float64, error) {
+	var x mat.VecDense
+	if err := x.SolveVec(A, mat.NewVecDense(len(b), b)); err != nil {
+		if _, ok := err.(mat.Condition); !ok {
+			return nil, err
+		}
+	}
+	return x.RawVector().Data, nil
+}
+
+// 牛顿-拉夫逊法求解负荷增长系数为λ时的潮流, 从当前的电压开始迭代
+func (pf *powerFlow) solve(lambda float64) error {
+	for iter := 0; iter < powerFlowMaxIter; iter++ {
+		F := pf.mismatch(lambda)
+		if maxAbs(F) < powerFlowTolerance {
+			return nil
+		}
+		// ∂S/∂x Δx = F
+		dx, err := solveDense(pf.jacobian(), F)
+		if err != nil {
+			return err
+		}
+		x := pf.state()
+		for k := range x {
+			x[k] += dx[k]
+		}
+		pf.setState(x)
+	}
+	return fmt.Errorf("潮流计算迭代%d次不收敛", powerFlowMaxIter)
+}
+
+// 连续潮流的参数
+type CPFOptions struct {
+	// 负荷增长的节点, 为空时所有有负荷的节点按各自的负荷同比例增长(功率因数不变)
+	Buses []int
+	// 增加的负荷由PV节点按原有的有功出力分担, 否则全部由平衡节点承担
+	Generation bool
+	// 弧长参数的步长, 不收敛时自动减小, 为0时取0.1
+	Step float64
+	// 最多的步数, 为0时取1000
+	MaxSteps int
+}
+
+// P-V曲线: 负荷为基准负荷的(1+λ)倍时各节点的电压
+type PVCurve struct {
+	Lambda []float64
+	// 增长方向上的总负荷(MW)
+	Load []float64
+	// 各节点的电压幅值, V[i]为节点i+1在各点的值
+	V [][]float64
+	// 鼻点的负荷增长系数和总负荷(MW), 即负荷裕度
+	LambdaMax, LoadMax float64
+	// 鼻点在曲线中的序号
+	Nose int
+	// 鼻点处电压对负荷增长最敏感的节点, 即切向量中电压分量最大的PQ节点
+	CriticalBus int
+	// 是否越过了鼻点
+	Complete bool
+}
+
+// 连续潮流: 从母线数据的运行点开始, 负荷沿给定方向增长, 用切向量预测和弧长参数化的校正
+// 越过鼻点, 得到各节点的P-V曲线和负荷裕度
+func (p *Parser) ComputePVCurve(opts CPFOptions) (*PVCurve, error) {
+	if opts.Step == 0 {
+		opts.Step = 0.1
+	}
+	if opts.MaxSteps == 0 {
+		opts.MaxSteps = 1000
+	}
+	if !positive(opts.Step) || opts.MaxSteps < 0 {
+		return nil, fmt.Errorf("步长必须是大于0的有限值, 步数不能为负数")
+	}
+	if opts.Step < cpfMinStep {
+		return nil, fmt.Errorf("步长不能小于%g", cpfMinStep)
+	}
+	pf, err := p.newPowerFlow()
+	if err != nil {
+		return nil, err
+	}
+	// 增长方向上的基准负荷
+	Pd := make([]float64, p.nodeNum)
+	Qd := make([]float64, p.nodeNum)
+	Pg := make([]float64, p.nodeNum)
+	for _, bus := range p.network.Buses {
+		if bus.Node > 0 && bus.Node <= p.nodeNum {
+			Pd[bus.Node-1] += bus.Pd / p.SB
+			Qd[bus.Node-1] += bus.Qd / p.SB
+			Pg[bus.Node-1] += bus.Pg / p.SB
+		}
+	}
+	selected := make([]bool, p.nodeNum)
+	if len(opts.Buses) == 0 {
+		for i := range selected {
+			selected[i] = Pd[i] != 0 || Qd[i] != 0
+		}
+	}
+	for _, node := range opts.Buses {
+		if err := p.checkNode(node); err != nil {
+			return nil, err
+		}
+		if Pd[node-1] == 0 && Qd[node-1] == 0 {
+			return nil, fmt.Errorf("节点%d没有负荷, 不能作为负荷增长的方向", node)
+		}
+		selected[node-1] = true
+	}
+	total, generation := 0.0, 0.0
+	for i := range selected {
+		if selected[i] {
+			pf.dP[i] = -Pd[i]
+			pf.dQ[i] = -Qd[i]
+			total += Pd[i]
+		}
+		if pf.types[i] == BusPV {
+			generation += Pg[i]
+		}
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("负荷增长方向上没有有功负荷")
+	}
+	if opts.Generation && generation > 0 {
+		for i := range Pg {
+			if pf.types[i] == BusPV {
+				pf.dP[i] += total * Pg[i] / generation
+			}
+		}
+	}
+	if err := pf.solve(0); err != nil {
+		return nil, fmt.Errorf("初始运行点: %w", err)
+	}
+	c := &PVCurve{V: make([][]float64, p.nodeNum)}
+	record := func(lambda float64) {
+		c.Lambda = append(c.Lambda, lambda)
+		c.Load = append(c.Load, total*(1+lambda)*p.SB)
+		for i := range c.V {
+			c.V[i] = append(c.V[i], pf.V[i])
+		}
+		if lambda > c.LambdaMax || len(c.Lambda) == 1 {
+			c.LambdaMax = lambda
+			c.LoadMax = total * (1 + lambda) * p.SB
+			c.Nose = len(c.Lambda) - 1
+		}
+	}
+	record(0)
+	m := pf.size()
+	d := pf.direction()
+	// 增广矩阵 [∂F/∂x ∂F/∂λ; t'], ∂F/∂x = -∂S/∂x
+	augmented := func(t []float64) *mat.Dense {
+		J := pf.jacobian()
+		A := mat.NewDense(m+1, m+1, nil)
+		for r := 0; r < m; r++ {
+			for k := 0; k < m; k++ {
+				A.Set(r, k, -J.At(r, k))
+			}
+			A.Set(r, m, d[r])
+		}
+		for k := 0; k <= m; k++ {
+			A.Set(m, k, t[k])
+		}
+		return A
+	}
+	// 第一步以λ为参数, 之后以上一步的切向量保持方向
+	t := make([]float64, m+1)
+	t[m] = 1
+	var noseTangent []float64
+	lambda := 0.0
+	step := opts.Step
+	for k := 0; k < opts.MaxSteps && step >= cpfMinStep; k++ {
+		// 预测: [∂F/∂x ∂F/∂λ; t'] t_new = [0; 1], 再归一化
+		rhs := make([]float64, m+1)
+		rhs[m] = 1
+		tangent, err := solveDense(augmented(t), rhs)
+		if err != nil {
+			return nil, err
+		}
+		norm := math.Sqrt(dot(tangent, tangent))
+		for i := range tangent {
+			tangent[i] /= norm
+		}
+		// 鼻点处的切向量中电压变化最大的节点
+		if len(c.Lambda)-1 == c.Nose {
+			noseTangent = tangent
+		}
+		x0 := append(pf.state(), lambda)
+		accepted := false
+		for step >= cpfMinStep {
+			y := make([]float64, m+1)
+			for i := range y {
+				y[i] = x0[i] + step*tangent[i]
+			}
+			if y, accepted = pf.correct(y, x0, tangent, step, augmented); accepted {
+				lambda = y[m]
+				break
+			}
+			pf.setState(x0[:m])
+			step /= 2
+		}
+		if !accepted {
+			break
+		}
+		t = tangent
+		record(lambda)
+		// 校正收敛后恢复步长, 步长不超过初始值以保证曲线和鼻点的分辨率
+		step = math.Min(step*2, opts.Step)
+		if lambda < 0 || (c.Nose < len(c.Lambda)-1 && lambda < cpfStopFraction*c.LambdaMax) {
+			break
+		}
+	}
+	c.Complete = c.Nose < len(c.Lambda)-1
+	// 没有接受任何一步时没有鼻点的切向量
+	if noseTangent == nil {
+		return c, nil
+	}
+	dV := 0.0
+	for k, i := range pf.magnitudes {
+		if v := math.Abs(noseTangent[len(pf.angles)+k]); v > dV {
+			dV = v
+			c.CriticalBus = i + 1
+		}
+	}
+	return c, nil
+}
+
+// 弧长参数化的校正: F(x, λ) = 0, t'(y - y0) = step
+func (pf *powerFlow) correct(y, y0, t []float64, step float64, augmented func([]float64) *mat.Dense) ([]float64, bool) {
+	m := pf.size()
+	for iter := 0; iter < powerFlowMaxIter; iter++ {
+		pf.setState(y[:m])
+		F := pf.mismatch(y[m])
+		diff := make([]float64, m+1)
+		for i := range diff {
+			diff[i] = y[i] - y0[i]
+		}
+		g := dot(t, diff) - step
+		if maxAbs(F) < powerFlowTolerance && math.Abs(g) < powerFlowTolerance {
+			return y, true
+		}
+		// [∂F/∂x ∂F/∂λ; t'] Δy = -[F; g]
+		rhs := make([]float64, m+1)
+		for i := range F {
+			rhs[i] = -F[i]
+		}
+		rhs[m] = -g
+		dy, err := solveDense(augmented(t), rhs)
+		if err != nil {
+			return nil, false
+		}
+		for i := range y {
+			y[i] += dy[i]
+		}
+	}
+	return nil, false
+}
+
+func dot(a, b []float64) float64 {
+	s := 0.0
+	for i := range a {
+		s += a[i] * b[i]
+	}
+	return s
+}
+
+// Q-V曲线的参数
+type QVOptions struct {
+	// 节点电压的变化范围和步长(标幺值), 为0时取1.2、0.5和0.01
+	Vmax, Vmin, Step float64
+}
+
+// Q-V曲线: 节点电压给定时需要在该节点注入的无功功率(标幺值)
+type QVCurve struct {
+	Bus  int
+	V, Q []float64
+	// 曲线的最低点, Qmin < 0时 -Qmin为无功裕度
+	Qmin, VAtQmin float64
+}
+
+// 在初始运行点把PQ节点bus作为电压给定的PV节点, 从Vmax到Vmin逐点计算潮流,
+// 计算不收敛时停止
+func (p *Parser) ComputeQVCurve(bus int, opts QVOptions) (*QVCurve, error) {
+	if opts.Vmax == 0 {
+		opts.Vmax = 1.2
+	}
+	if opts.Vmin == 0 {
+		opts.Vmin = 0.5
+	}
+	if opts.Step == 0 {
+		opts.Step = 0.01
+	}
+	if !positive(opts.Vmin, opts.Vmax, opts.Step) || opts.Vmax <= opts.Vmin {
+		return nil, fmt.Errorf("电压范围应满足 0 < Vmin < Vmax, 步长必须是大于0的有限值")
+	}
+	if (opts.Vmax-opts.Vmin)/opts.Step > maxTraceSamples {
+		return nil, fmt.Errorf("Q-V曲线的点数超过%d, 需要增大步长", maxTraceSamples)
+	}
+	if err := p.checkNode(bus); err != nil {
+		return nil, err
+	}
+	pf, err := p.newPowerFlow()
+	if err != nil {
+		return nil, err
+	}
+	if pf.types[bus-1] != BusPQ {
+		return nil, fmt.Errorf("节点%d不是PQ节点, 不能计算Q-V曲线", bus)
+	}
+	if err := pf.solve(0); err != nil {
+		return nil, fmt.Errorf("初始运行点: %w", err)
+	}
+	pf.types[bus-1] = BusPV
+	pf.magnitudes = pf.magnitudes[:0]
+	for i, t := range pf.types {
+		if t == BusPQ {
+			pf.magnitudes = append(pf.magnitudes, i)
+		}
+	}
+	c := &QVCurve{Bus: bus, Qmin: math.Inf(1)}
+	n := int(math.Floor((opts.Vmax-opts.Vmin)/opts.Step+1e-9)) + 1
+	for k := 0; k < n; k++ {
+		v := opts.Vmax - float64(k)*opts.Step
+		pf.V[bus-1] = v
+		if err := pf.solve(0); err != nil {
+			break
+		}
+		_, Q := pf.injections()
+		q := Q[bus-1] - pf.Q0[bus-1]
+		c.V = append(c.V, v)
+		c.Q = append(c.Q, q)
+		if q < c.Qmin {
+			c.Qmin, c.VAtQmin = q, v
+		}
+	}
+	if len(c.V) == 0 {
+		return nil, fmt.Errorf("节点%d电压为%g时潮流计算不收敛", bus, opts.Vmax)
+	}
+	return c, nil
+}
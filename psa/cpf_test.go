@@ -0,0 +1,107 @@
+package psa
+
+import (
+	"math"
+	"testing"
+)
+
+// 平衡节点1经x=0.5的线路向节点2的50MW纯有功负荷供电
+func twoBusLoadNetwork() PowerNetwork {
+	return PowerNetwork{
+		SB: 100,
+		Buses: []Bus{
+			{Node: 1, Number: 1, Type: BusSlack, V: 1},
+			{Node: 2, Number: 2, Type: BusPQ, Pd: 50},
+		},
+		Branches: []Branch{{Node1: 1, Node2: 2, Reactance: 0.5}},
+	}
+}
+
+// 功率因数为1时 V⁴ - V² + (PX)² = 0, 鼻点 Pmax = 1/(2X) = 1, V = 1/√2, 即λmax = 1;
+// 初始运行点 V² = (1 + √(1 - 4×0.25²))/2, V = cos15°
+func TestPVCurve(t *testing.T) {
+	p, err := NewParser(twoBusLoadNetwork())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := p.ComputePVCurve(CPFOptions{Step: 0.01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFloat(t, "V2(0)", c.V[1][0], math.Cos(15*math.Pi/180), 1e-8)
+	assertFloat(t, "Load(0)", c.Load[0], 50, 1e-9)
+	assertFloat(t, "λmax", c.LambdaMax, 1, 1e-3)
+	assertFloat(t, "LoadMax", c.LoadMax, 100, 0.1)
+	assertFloat(t, "V2 nose", c.V[1][c.Nose], 1/math.Sqrt2, 0.01)
+	if !c.Complete {
+		t.Error("Complete = false, want true")
+	}
+	if c.CriticalBus != 2 {
+		t.Errorf("CriticalBus = %d, want 2", c.CriticalBus)
+	}
+	// 越过鼻点后的下半支上 V² = (1 - √(1 - 4(PX)²))/2
+	last := len(c.Lambda) - 1
+	px := 0.5 * (1 + c.Lambda[last]) * 0.5
+	assertFloat(t, "V2 lower", c.V[1][last], math.Sqrt((1-math.Sqrt(1-4*px*px))/2), 1e-6)
+	for i, v := range c.V[0] {
+		if v != 1 {
+			t.Fatalf("V1[%d] = %v, want 1", i, v)
+		}
+	}
+}
+
+// Q(V) = (V² - V cosδ)/X, sinδ = PX/V
+func TestQVCurve(t *testing.T) {
+	p, err := NewParser(twoBusLoadNetwork())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := p.ComputeQVCurve(2, QVOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.V) != 71 {
+		t.Fatalf("%d points, want 71 from 1.2 to 0.5", len(c.V))
+	}
+	assertFloat(t, "V[20]", c.V[20], 1, 1e-9)
+	assertFloat(t, "Q(1.0)", c.Q[20], 0.063508327, 1e-8)
+	assertFloat(t, "Q(0.8)", c.Q[40], -0.239868415, 1e-8)
+	assertFloat(t, "VAtQmin", c.VAtQmin, 0.56, 1e-9)
+	assertFloat(t, "Qmin", c.Qmin, -0.374997585, 1e-8)
+}
+
+func TestCPFErrors(t *testing.T) {
+	p, err := NewParser(twoBusLoadNetwork())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 步长过小时在开始计算前报错, 而不是一直减半
+	for _, step := range []float64{-0.1, cpfMinStep / 2, math.NaN(), math.Inf(1)} {
+		if _, err := p.ComputePVCurve(CPFOptions{Step: step}); err == nil {
+			t.Errorf("step %g: want error", step)
+		}
+	}
+	if _, err := p.ComputePVCurve(CPFOptions{Buses: []int{1}}); err == nil {
+		t.Error("bus without load: want error")
+	}
+	if _, err := p.ComputeQVCurve(1, QVOptions{}); err == nil {
+		t.Error("Q-V at the slack bus: want error")
+	}
+	if _, err := p.ComputeQVCurve(2, QVOptions{Vmax: 0.5, Vmin: 1}); err == nil {
+		t.Error("Vmax < Vmin: want error")
+	}
+	if _, err := p.ComputeQVCurve(2, QVOptions{Vmin: math.NaN()}); err == nil {
+		t.Error("NaN Vmin: want error")
+	}
+	if _, err := p.ComputeQVCurve(2, QVOptions{Step: 1e-7}); err == nil {
+		t.Error("too many Q-V points: want error")
+	}
+	network := twoBusLoadNetwork()
+	network.Buses[0].Type = BusPQ
+	if p, err = NewParser(network); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.ComputePVCurve(CPFOptions{}); err == nil {
+		t.Error("no slack bus: want error")
+	}
+}
@@ -0,0 +1,254 @@
+package psa
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 各数据段的字段及其标准单位, 字段名与json字段名相同
+var csvSections = map[string]map[string]string{
+	"network": {"SB": "MVA", "Vav": "kV"},
+	"SG":      {"node": "", "r": "Ω/km", "x": "Ω/km", "b": "S/km", "l": "km", "VB": "kV"},
+	"circuits": {"node_1": "", "node_2": "", "r": "Ω/km", "x": "Ω/km", "b": "S/km", "l": "km",
+		"VB": "kV"},
+	"transformers": {"node_1": "", "node_2": "", "Sn": "MVA", "Vs": "%", "V1n": "kV", "V2n": "kV",
+		"VB": "kV"},
+	"power_generators": {"node": "", "Sn": "MVA", "xd": "", "Pn": "MW", "cos": "", "VB": "kV"},
+	"lds":              {"node": "", "Ld": "MVA", "Xid": "", "VB": "kV"},
+	"motors": {"node": "", "kW": "kW", "eff": "", "cos": "", "ILR": "", "XR": "", "count": "",
+		"Vn": "kV", "VB": "kV", "T": "s"},
+}
+
+// 数据段(或文件名)的别名
+var csvSectionAliases = map[string]string{
+	"generators":  "power_generators",
+	"generator":   "power_generators",
+	"loads":       "lds",
+	"load":        "lds",
+	"ld":          "lds",
+	"system":      "SG",
+	"source":      "SG",
+	"circuit":     "circuits",
+	"lines":       "circuits",
+	"line":        "circuits",
+	"transformer": "transformers",
+	"motor":       "motors",
+}
+
+// 标准单位 -> 表头中可以使用的单位及换算到标准单位的系数
+var csvUnits = map[string]map[string]float64{
+	"":     {"pu": 1, "p.u.": 1, "%": 0.01},
+	"%":    {"%": 1, "pu": 100, "p.u.": 100},
+	"kV":   {"kv": 1, "v": 1e-3},
+	"MVA":  {"mva": 1, "kva": 1e-3, "va": 1e-6},
+	"MW":   {"mw": 1, "kw": 1e-3, "w": 1e-6},
+	"kW":   {"kw": 1, "mw": 1e3, "w": 1e-3},
+	"s":    {"s": 1, "ms": 1e-3},
+	"km":   {"km": 1, "m": 1e-3},
+	"Ω/km": {"ω/km": 1, "ohm/km": 1, "ω/m": 1e3, "ohm/m": 1e3},
+	"S/km": {"s/km": 1, "µs/km": 1e-6, "μs/km": 1e-6, "us/km": 1e-6, "s/m": 1e3},
+}
+
+var (
+	csvSectionLine = regexp.MustCompile(`^\s*\[(.+)\]\s*$`)
+	csvHeaderUnit  = regexp.MustCompile(`^(.*?)\s*[(\[](.*)[)\]]\s*$`)
+)
+
+// 读取表格形式的元件数据
+//
+// 每种元件一个数据段, 数据段以"[circuits]"这样的一行开始; 没有数据段标记时以文件名作为数据段.
+// 每个数据段的第一行为表头, 表头可以带单位, 例如"x (Ω/km)"、"Sn [kVA]", 数值换算到标准单位.
+func ReadCSV(r io.Reader, name string) (*PowerNetwork, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	sections := map[string]interface{}{}
+	section := csvSectionName(name)
+	var body []string
+	flush := func() error {
+		if len(body) == 0 {
+			return nil
+		}
+		err := readCSVSection(section, strings.Join(body, "\n"), sections)
+		body = nil
+		return err
+	}
+	for _, line := range strings.Split(csvText(data), "\n") {
+		if match := csvSectionLine.FindStringSubmatch(line); match != nil {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			section = csvSectionName(match[1])
+			continue
+		}
+		if strings.TrimSpace(line) != "" {
+			body = append(body, line)
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return csvNetwork(sections)
+}
+
+// 读取目录中每种元件一个的csv文件, 文件名为数据段名, 例如circuits.csv
+func ReadCSVDir(dir string) (*PowerNetwork, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.csv"))
+	if err != nil {
+		return nil, err
+	}
+	sections := map[string]interface{}{}
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := readCSVSection(csvSectionName(path), csvText(data), sections); err != nil {
+			return nil, err
+		}
+	}
+	return csvNetwork(sections)
+}
+
+// Excel等软件保存的UTF-8 csv文件以BOM开头, 不去掉会成为第一个表头或数据段标记的一部分
+func csvText(data []byte) string {
+	return strings.TrimPrefix(string(data), "\uFEFF")
+}
+
+// Decode读取csv时, 打开的是目录则按目录读取
+func readCSVFrom(r io.Reader) (*PowerNetwork, error) {
+	name := ""
+	if file, ok := r.(*os.File); ok {
+		name = file.Name()
+		if info, err := file.Stat(); err == nil && info.IsDir() {
+			return ReadCSVDir(name)
+		}
+	}
+	return ReadCSV(r, name)
+}
+
+func csvSectionName(name string) string {
+	name = strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	for section := range csvSections {
+		if strings.EqualFold(section, name) {
+			return section
+		}
+	}
+	if section, exist := csvSectionAliases[strings.ToLower(name)]; exist {
+		return section
+	}
+	return name
+}
+
+func readCSVSection(section string, text string, sections map[string]interface{}) error {
+	fields, exist := csvSections[section]
+	if !exist {
+		return fmt.Errorf("csv: 未知的数据段%q", section)
+	}
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return fmt.Errorf("csv: %s: %v", section, err)
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	// 表头的每一列对应的字段和单位换算系数
+	names := make([]string, len(records[0]))
+	factors := make([]float64, len(records[0]))
+	for i, header := range records[0] {
+		name, unit := strings.TrimSpace(header), ""
+		if match := csvHeaderUnit.FindStringSubmatch(name); match != nil {
+			name, unit = match[1], strings.TrimSpace(match[2])
+		}
+		for field, standard := range fields {
+			if !strings.EqualFold(field, name) {
+				continue
+			}
+			// 没有单位时按标准单位
+			factor, exist := 1.0, true
+			if unit != "" {
+				factor, exist = csvUnits[standard][strings.ToLower(unit)]
+			}
+			if !exist {
+				return fmt.Errorf("csv: %s: 列%q的单位%q不能换算为%q", section, name, unit, standard)
+			}
+			names[i] = field
+			factors[i] = factor
+		}
+		if names[i] == "" {
+			return fmt.Errorf("csv: %s: 未知的列%q", section, header)
+		}
+	}
+	var rows []map[string]float64
+	for n, record := range records[1:] {
+		row := map[string]float64{}
+		for i, value := range record {
+			if i >= len(names) {
+				return fmt.Errorf("csv: %s第%d行的列数多于表头", section, n+2)
+			}
+			value = strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("csv: %s第%d行%q: %v", section, n+2, names[i], err)
+			}
+			row[names[i]] = v * factors[i]
+		}
+		rows = append(rows, row)
+	}
+
+	switch section {
+	case "network":
+		if len(rows) != 1 {
+			return fmt.Errorf("csv: network只能有一行数据")
+		}
+		for k, v := range rows[0] {
+			sections[k] = v
+		}
+	case "SG":
+		if len(rows) != 1 {
+			return fmt.Errorf("csv: SG只能有一行数据")
+		}
+		circuit := map[string]float64{"node_1": rows[0]["node"]}
+		for k, v := range rows[0] {
+			if k != "node" {
+				circuit[k] = v
+			}
+		}
+		sections["SG"] = map[string]interface{}{"node": rows[0]["node"], "circuit": circuit}
+	default:
+		existing, _ := sections[section].([]map[string]float64)
+		sections[section] = append(existing, rows...)
+	}
+	return nil
+}
+
+// 各数据段的字段名与PowerNetwork的json字段名相同
+func csvNetwork(sections map[string]interface{}) (*PowerNetwork, error) {
+	data, err := json.Marshal(sections)
+	if err != nil {
+		return nil, err
+	}
+	network := &PowerNetwork{}
+	if err := json.Unmarshal(data, network); err != nil {
+		return nil, fmt.Errorf("csv: %v", err)
+	}
+	if network.SB == 0 {
+		return nil, fmt.Errorf("csv: 缺少基准容量SB")
+	}
+	return network, nil
+}
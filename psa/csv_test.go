@@ -0,0 +1,107 @@
+package psa
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadCSVUnits(t *testing.T) {
+	text := `[network]
+SB (MVA)
+100
+
+# 表头的单位换算到标准单位
+[lines]
+node_1,node_2,x (ohm/m),l (m),VB (kV)
+2,3,0.0004,60000,115
+
+[transformers]
+node_1,node_2,Sn (kVA),Vs (pu),V1n,V2n,VB
+1,2,31500,0.105,121,10,115
+`
+	network, err := ReadCSV(strings.NewReader(text), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(network.Circuits) != 1 || len(network.Transformers) != 1 {
+		t.Fatalf("%d circuits, %d transformers", len(network.Circuits), len(network.Transformers))
+	}
+	circuit := network.Circuits[0]
+	assertFloat(t, "x", circuit.X, 0.4, 1e-12)
+	assertFloat(t, "l", circuit.L, 60, 1e-9)
+	transformer := network.Transformers[0]
+	assertFloat(t, "Sn", transformer.Sn, 31.5, 1e-12)
+	assertFloat(t, "Vs", transformer.Vs, 10.5, 1e-12)
+
+	p, err := NewParser(*network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.ComputeResultY()
+	// X = 0.4 * 60 * 100/115², Y23 = -1/jX
+	x := 0.4 * 60 * 100 / (115 * 115)
+	assertComplex(t, "Y23", p.ResultY().At(2, 3), complex(0, 1/x))
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	for name, text := range map[string]string{
+		"unknown section": "[buses]\nnode\n1\n",
+		"unknown column":  "[network]\nSB,foo\n100,1\n",
+		"bad unit":        "[network]\nSB (kV)\n100\n",
+		"missing SB":      "[circuits]\nnode_1,node_2,x\n1,2,0.4\n",
+	} {
+		if _, err := ReadCSV(strings.NewReader(text), ""); err == nil {
+			t.Errorf("%s was accepted", name)
+		}
+	}
+}
+
+// Excel保存的UTF-8 csv文件以BOM开头
+func TestReadCSVBOM(t *testing.T) {
+	text := "\uFEFF[network]\nSB\n100\n[circuits]\nnode_1,node_2,x\n1,2,0.4\n"
+	network, err := ReadCSV(strings.NewReader(text), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if network.SB != 100 || len(network.Circuits) != 1 {
+		t.Errorf("SB = %v, %d circuits", network.SB, len(network.Circuits))
+	}
+
+	dir, err := ioutil.TempDir("", "csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	for name, text := range map[string]string{
+		"network.csv":  "\uFEFFSB\n100\n",
+		"circuits.csv": "\uFEFFnode_1,node_2,x\n1,2,0.4\n",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if network, err = ReadCSVDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if network.SB != 100 || len(network.Circuits) != 1 || network.Circuits[0].Node2 != 2 {
+		t.Errorf("SB = %v, circuits %+v", network.SB, network.Circuits)
+	}
+}
+
+func TestReadCSVMatchesJSON(t *testing.T) {
+	fromCSV, err := ImportPowerNetworkFromFile("../lab2/test1.csv", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromJSON, err := ImportPowerNetworkFromFile("../lab2/test1.json", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromCSV, fromJSON) {
+		t.Errorf("csv network %+v\njson network %+v", fromCSV, fromJSON)
+	}
+}
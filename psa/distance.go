@@ -0,0 +1,419 @@
+package psa
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// 距离继电器默认的各段整定值(线路正序阻抗的百分数)
+const (
+	DefaultZone1 = 80
+	DefaultZone2 = 120
+	DefaultZone3 = 200
+)
+
+// 短路点相对于继电器的位置
+const (
+	// 被保护线路上的点
+	FaultInternal = "internal"
+	// 线路末端母线
+	FaultRemote = "remote"
+	// 与末端母线相邻的母线(下一级线路的末端)
+	FaultExternal = "external"
+	// 与继电器所在母线相邻的其他母线, 即反方向
+	FaultReverse = "reverse"
+	// 其他母线
+	FaultOther = "other"
+)
+
+// 距离继电器, 装在线路Node1-Node2的Node1一端, 采用方向圆(mho)特性
+type DistanceRelay struct {
+	Name  string `json:"name,omitempty"`
+	Node1 int    `json:"node_1"`
+	Node2 int    `json:"node_2"`
+	// 线路两端在零序网络中的节点号, 为0时与正序网络相同
+	Node01 int `json:"node0_1,omitempty"`
+	Node02 int `json:"node0_2,omitempty"`
+	// I、II、III段的整定阻抗, 为线路正序阻抗的百分数, 为0时取默认值
+	Zone1 float64 `json:"zone1,omitempty"`
+	Zone2 float64 `json:"zone2,omitempty"`
+	Zone3 float64 `json:"zone3,omitempty"`
+	// 零序补偿系数k0的模和角度(°), 都为0时由线路的零序和正序阻抗计算 k0 = (Z0-Z1)/(3Z1)
+	K0      float64 `json:"k0,omitempty"`
+	K0Angle float64 `json:"k0_angle,omitempty"`
+}
+
+func (r DistanceRelay) name(i int) string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return fmt.Sprintf("D%d", i+1)
+}
+
+// 各段的整定值(%)
+func (r DistanceRelay) zones() [3]float64 {
+	zones := [3]float64{r.Zone1, r.Zone2, r.Zone3}
+	for i, d := range [3]float64{DefaultZone1, DefaultZone2, DefaultZone3} {
+		if zones[i] == 0 {
+			zones[i] = d
+		}
+	}
+	return zones
+}
+
+// 零序网络中的线路两端
+func (r DistanceRelay) zeroNodes() (int, int) {
+	n1, n2 := r.Node01, r.Node02
+	if n1 == 0 {
+		n1 = r.Node1
+	}
+	if n2 == 0 {
+		n2 = r.Node2
+	}
+	return n1, n2
+}
+
+func validateDistanceRelays(relays []DistanceRelay) error {
+	for i, relay := range relays {
+		if relay.Node1 <= 0 || relay.Node2 <= 0 || relay.Node1 == relay.Node2 {
+			return fmt.Errorf("第%d个距离继电器应装在两个不同节点之间的线路上", i+1)
+		}
+		if relay.Node01 < 0 || relay.Node02 < 0 || relay.K0 < 0 {
+			return fmt.Errorf("第%d个距离继电器的零序节点号和k0不能为负数", i+1)
+		}
+		zones := relay.zones()
+		if zones[0] <= 0 || zones[1] < zones[0] || zones[2] < zones[1] {
+			return fmt.Errorf("第%d个距离继电器的整定值应满足 0 < zone1 <= zone2 <= zone3", i+1)
+		}
+	}
+	return nil
+}
+
+// 一个短路点上一个距离继电器的测量结果
+type DistanceCheck struct {
+	Relay string
+	// 短路点的位置, Node为母线短路时的母线, 线路上短路时X为到继电器的距离占线路全长的比例
+	Location string
+	Node     int
+	X        float64
+	// 测量阻抗 V/I, 没有电流时为无穷大
+	Z complex128
+	// 测量阻抗的模占线路正序阻抗的百分数
+	Percent float64
+	// 各段是否动作
+	Operate [3]bool
+	// 各段应该动作(1)、不应该动作(-1)或不作要求(0)
+	Expected [3]int
+	// 与要求不符的段, 例如"I段超越"
+	Violations []string
+}
+
+// 按位置判断各段是否应该动作: 线路上I段整定范围以内的短路I段动作, 线路上的短路II段和III段都应动作;
+// I段不应该保护到末端母线及以外, III段作为相邻线路的远后备; 反方向短路各段都不应动作
+func expectedZones(location string, x float64, zones [3]float64) [3]int {
+	switch location {
+	case FaultInternal:
+		expected := [3]int{0, 1, 1}
+		if x*100 < zones[0] {
+			expected[0] = 1
+		}
+		return expected
+	case FaultRemote:
+		return [3]int{-1, 1, 1}
+	case FaultExternal:
+		return [3]int{-1, 0, 1}
+	case FaultReverse:
+		return [3]int{-1, -1, -1}
+	}
+	return [3]int{-1, 0, 0}
+}
+
+// 计算距离继电器所需的各序网络, 只计算三相短路时负序和零序网络为nil
+type distanceNetworks struct {
+	p1, p2, p0 *Parser
+	faultType  string
+}
+
+// 网络中的一个短路点: 母线node, 或者线路node-node2上距node为x的点
+type faultPoint struct {
+	node, node2 int
+	x           float64
+}
+
+// 短路点的自阻抗和各节点对短路点的互阻抗 Zkp
+// 线路i-j(阻抗z)上距i为x的点: Zkp = (1-x)Zki + xZkj, Zpp = (1-x)²Zii + x²Zjj + 2x(1-x)Zij + x(1-x)z
+func (p *Parser) pointImpedances(pt faultPoint) ([]complex128, complex128, error) {
+	if err := p.checkNode(pt.node); err != nil {
+		return nil, 0, err
+	}
+	Zk := make([]complex128, p.nodeNum)
+	if pt.node2 == 0 {
+		for k := 1; k <= p.nodeNum; k++ {
+			Zk[k-1] = p.resultZ.rcAt(k, pt.node)
+		}
+		return Zk, p.resultZ.rcAt(pt.node, pt.node), nil
+	}
+	z, err := p.lineImpedance(pt.node, pt.node2)
+	if err != nil {
+		return nil, 0, err
+	}
+	i, j, x := pt.node, pt.node2, complex(pt.x, 0)
+	for k := 1; k <= p.nodeNum; k++ {
+		Zk[k-1] = (1-x)*p.resultZ.rcAt(k, i) + x*p.resultZ.rcAt(k, j)
+	}
+	Zpp := (1-x)*(1-x)*p.resultZ.rcAt(i, i) + x*x*p.resultZ.rcAt(j, j) + 2*x*(1-x)*p.resultZ.rcAt(i, j) + x*(1-x)*z
+	return Zk, Zpp, nil
+}
+
+// 被保护线路(两个节点之间的第一条支路)的串联阻抗, 并联线路不合并
+func (p *Parser) lineImpedance(node1, node2 int) (complex128, error) {
+	if err := p.checkNode(node1); err != nil {
+		return 0, err
+	}
+	if err := p.checkNode(node2); err != nil {
+		return 0, err
+	}
+	branch, err := p.protectedBranch(node1, node2)
+	if err != nil {
+		return 0, err
+	}
+	if branch.Ratio != 0 && branch.Ratio != 1 {
+		return 0, fmt.Errorf("节点%d和%d之间的支路是变压器, 距离继电器应装在线路上", node1, node2)
+	}
+	return complex(branch.Resistance, branch.Reactance), nil
+}
+
+// 一个序网中短路后流过继电器(从n1流向n2或线路上的短路点)的电流, pre为短路前电压
+func (p *Parser) relayCurrent(n1, n2 int, pt faultPoint, pre, If complex128) (V, I complex128, err error) {
+	Zk, Zpp, err := p.pointImpedances(pt)
+	if err != nil {
+		return 0, 0, err
+	}
+	V = pre - Zk[n1-1]*If
+	if pt.node2 != 0 {
+		// 短路点在被保护线路上
+		z, _ := p.lineImpedance(n1, n2)
+		Vp := pre - Zpp*If
+		return V, (V - Vp) / (complex(pt.x, 0) * z), nil
+	}
+	branch, err := p.protectedBranch(n1, n2)
+	if err != nil {
+		return 0, 0, err
+	}
+	return V, branchCurrent(branch, n1, V, pre-Zk[n2-1]*If), nil
+}
+
+// 在正序网络的短路点pt和零序网络的短路点pt0上短路时继电器的测量阻抗
+func (nets distanceNetworks) apparentImpedance(relay DistanceRelay, pt, pt0 faultPoint, k0 complex128) (complex128, error) {
+	_, Zff1, err := nets.p1.pointImpedances(pt)
+	if err != nil {
+		return 0, err
+	}
+	var Zff2, Zff0 complex128
+	if nets.p2 != nil {
+		if _, Zff2, err = nets.p2.pointImpedances(pt); err != nil {
+			return 0, err
+		}
+		if _, Zff0, err = nets.p0.pointImpedances(pt0); err != nil {
+			return 0, err
+		}
+	}
+	If1, If2, If0, err := ComputeSequenceFault(nets.faultType, Zff1, Zff2, Zff0, 0)
+	if err != nil {
+		return 0, err
+	}
+	V1, I1, err := nets.p1.relayCurrent(relay.Node1, relay.Node2, pt, 1, If1)
+	if err != nil {
+		return 0, err
+	}
+	var V2, I2, V0, I0 complex128
+	if nets.p2 != nil {
+		if V2, I2, err = nets.p2.relayCurrent(relay.Node1, relay.Node2, pt, 0, If2); err != nil {
+			return 0, err
+		}
+		n01, n02 := relay.zeroNodes()
+		if V0, I0, err = nets.p0.relayCurrent(n01, n02, pt0, 0, If0); err != nil {
+			return 0, err
+		}
+	}
+	Va, Vb, Vc := PhaseComponents(V1, V2, V0)
+	Ia, Ib, Ic := PhaseComponents(I1, I2, I0)
+	var V, I complex128
+	switch nets.faultType {
+	case Fault3Ph:
+		V, I = V1, I1
+	case Fault1Ph:
+		// 接地阻抗元件: Va / (Ia + k0 3I0)
+		V, I = Va, Ia+k0*3*I0
+	default:
+		// 相间阻抗元件: (Vb - Vc) / (Ib - Ic)
+		V, I = Vb-Vc, Ib-Ic
+	}
+	if I == 0 {
+		return cmplx.Inf(), nil
+	}
+	return V / I, nil
+}
+
+// 零序补偿系数, 没有给出时由线路的零序和正序阻抗计算
+func (nets distanceNetworks) k0(relay DistanceRelay, Z1L complex128) (complex128, error) {
+	if relay.K0 != 0 || relay.K0Angle != 0 {
+		return cmplx.Rect(relay.K0, relay.K0Angle*math.Pi/180), nil
+	}
+	if nets.p0 == nil {
+		return 0, nil
+	}
+	Z0L, err := nets.p0.lineImpedance(relay.zeroNodes())
+	if err != nil {
+		return 0, fmt.Errorf("零序网络: %w", err)
+	}
+	return (Z0L - Z1L) / (3 * Z1L), nil
+}
+
+// 测量阻抗Z是否在整定阻抗Zr为直径、过原点的圆内
+func mhoOperate(Z, Zr complex128) bool {
+	if cmplx.IsInf(Z) {
+		return false
+	}
+	return cmplx.Abs(Z-Zr/2) <= cmplx.Abs(Zr)/2*(1+1e-9)
+}
+
+// 校验各距离继电器, buses为需要计算的母线短路点及其在零序网络中的节点, points为线路上短路点的位置
+func (nets distanceNetworks) check(relays []DistanceRelay, buses [][2]int, points []float64) ([]DistanceCheck, error) {
+	if len(relays) == 0 {
+		return nil, fmt.Errorf("网络中没有距离继电器")
+	}
+	p1 := nets.p1
+	if p1.resultZ == nil {
+		return nil, ErrNotComputed
+	}
+	var checks []DistanceCheck
+	for i, relay := range relays {
+		name := relay.name(i)
+		Z1L, err := p1.lineImpedance(relay.Node1, relay.Node2)
+		if err != nil {
+			return nil, fmt.Errorf("距离继电器%s: %w", name, err)
+		}
+		k0, err := nets.k0(relay, Z1L)
+		if err != nil {
+			return nil, fmt.Errorf("距离继电器%s: %w", name, err)
+		}
+		zones := relay.zones()
+		n01, n02 := relay.zeroNodes()
+		evaluate := func(location string, node int, x float64, pt, pt0 faultPoint) error {
+			Z, err := nets.apparentImpedance(relay, pt, pt0, k0)
+			if err != nil {
+				return fmt.Errorf("距离继电器%s: %w", name, err)
+			}
+			c := DistanceCheck{Relay: name, Location: location, Node: node, X: x, Z: Z, Percent: math.Inf(1)}
+			if !cmplx.IsInf(Z) {
+				c.Percent = cmplx.Abs(Z) / cmplx.Abs(Z1L) * 100
+			}
+			c.Expected = expectedZones(location, x, zones)
+			for k := range zones {
+				c.Operate[k] = mhoOperate(Z, Z1L*complex(zones[k]/100, 0))
+				switch {
+				case c.Expected[k] == 1 && !c.Operate[k]:
+					c.Violations = append(c.Violations, fmt.Sprintf("%s段拒动", zoneNames[k]))
+				case c.Expected[k] == -1 && c.Operate[k]:
+					c.Violations = append(c.Violations, fmt.Sprintf("%s段超越", zoneNames[k]))
+				}
+			}
+			checks = append(checks, c)
+			return nil
+		}
+		for _, x := range points {
+			pt := faultPoint{node: relay.Node1, node2: relay.Node2, x: x}
+			pt0 := faultPoint{node: n01, node2: n02, x: x}
+			if err := evaluate(FaultInternal, 0, x, pt, pt0); err != nil {
+				return nil, err
+			}
+		}
+		if err := evaluate(FaultRemote, relay.Node2, 1, faultPoint{node: relay.Node2}, faultPoint{node: n02}); err != nil {
+			return nil, err
+		}
+		// 继电器所在母线上短路时电压为0, 测量阻抗在动作圆的边界上, 不作校验
+		for _, bus := range buses {
+			f, f0 := bus[0], bus[1]
+			if f == relay.Node1 || f == relay.Node2 {
+				continue
+			}
+			location := FaultOther
+			switch {
+			case p1.adjacent(f, relay.Node2):
+				location = FaultExternal
+			case p1.adjacent(f, relay.Node1):
+				location = FaultReverse
+			}
+			if err := evaluate(location, f, 0, faultPoint{node: f}, faultPoint{node: f0}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return checks, nil
+}
+
+var zoneNames = [3]string{"I", "II", "III"}
+
+// 两个节点之间是否有支路
+func (p *Parser) adjacent(node1, node2 int) bool {
+	return node1 != node2 && p.resultY.rcAt(node1, node2) != 0
+}
+
+// 默认的线路上短路点的位置: 线路全长的10%到90%
+func defaultDistancePoints() []float64 {
+	var points []float64
+	for i := 1; i <= 9; i++ {
+		points = append(points, float64(i)/10)
+	}
+	return points
+}
+
+func checkDistancePoints(points []float64) ([]float64, error) {
+	if len(points) == 0 {
+		return defaultDistancePoints(), nil
+	}
+	for _, x := range points {
+		if x <= 0 || x >= 1 {
+			return nil, fmt.Errorf("线路上短路点的位置应在0和1之间: %v", x)
+		}
+	}
+	return points, nil
+}
+
+// 在各母线和被保护线路上的各点三相短路, 校验网络中距离继电器各段的保护范围,
+// 测量阻抗由短路后的节点电压和线路电流计算, 已经计入了末端母线上其他电源的助增作用
+func (p *Parser) CheckDistanceRelays(points []float64) ([]DistanceCheck, error) {
+	points, err := checkDistancePoints(points)
+	if err != nil {
+		return nil, err
+	}
+	var buses [][2]int
+	for f := 1; f <= p.nodeNum; f++ {
+		buses = append(buses, [2]int{f, f})
+	}
+	return distanceNetworks{p1: p, faultType: Fault3Ph}.check(p.network.DistanceRelays, buses, points)
+}
+
+// 用各序网络校验距离继电器, p1、p2、p0为已经计算了阻抗矩阵的正序、负序和零序网络,
+// 短路点为被保护线路上的各点、线路末端母线, 以及网络数据中给出的短路点f1(零序网络中为f0)
+func (network *SequenceNetwork) CheckDistanceRelays(p1, p2, p0 *Parser, faultType string, points []float64) ([]DistanceCheck, error) {
+	if err := validateDistanceRelays(network.DistanceRelays); err != nil {
+		return nil, err
+	}
+	points, err := checkDistancePoints(points)
+	if err != nil {
+		return nil, err
+	}
+	nets := distanceNetworks{p1: p1, p2: p2, p0: p0, faultType: faultType}
+	if p2 == nil || p0 == nil {
+		return nil, fmt.Errorf("缺少负序或零序网络")
+	}
+	for _, q := range []*Parser{p2, p0} {
+		if q.resultZ == nil {
+			return nil, ErrNotComputed
+		}
+	}
+	return nets.check(network.DistanceRelays, [][2]int{{network.F1, network.F0}}, points)
+}
@@ -0,0 +1,97 @@
+package psa
+
+import (
+	"testing"
+)
+
+func TestCheckDistanceRelays(t *testing.T) {
+	// 辐射形网络没有助增, 测量阻抗就是继电器到短路点的线路阻抗
+	network := chainNetwork()
+	network.DistanceRelays = []DistanceRelay{
+		{Name: "D12", Node1: 1, Node2: 2},
+		{Name: "D23", Node1: 2, Node2: 3},
+	}
+	p, err := NewParser(network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ComputeResult(); err != nil {
+		t.Fatal(err)
+	}
+	checks, err := p.CheckDistanceRelays([]float64{0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checks) != 6 {
+		t.Fatalf("%d checks, want 6", len(checks))
+	}
+	// D12: 线路中点 j0.1, 末端母线 j0.2, 下一级线路末端 j0.5
+	for i, want := range []struct {
+		location string
+		z        complex128
+		operate  [3]bool
+	}{
+		{FaultInternal, 0.1i, [3]bool{true, true, true}},
+		{FaultRemote, 0.2i, [3]bool{false, true, true}},
+		// 250%超出III段的200%, 远后备拒动
+		{FaultExternal, 0.5i, [3]bool{false, false, false}},
+	} {
+		c := checks[i]
+		if c.Relay != "D12" || c.Location != want.location {
+			t.Fatalf("check %d = %s %s", i, c.Relay, c.Location)
+		}
+		assertComplex(t, "D12 "+c.Location, c.Z, want.z)
+		if c.Operate != want.operate {
+			t.Errorf("D12 %s operate = %v, want %v", c.Location, c.Operate, want.operate)
+		}
+	}
+	assertFloat(t, "D12 remote %", checks[1].Percent, 100, 1e-9)
+	if v := checks[2].Violations; len(v) != 1 || v[0] != "III段拒动" {
+		t.Errorf("external violations = %v", v)
+	}
+	// D23: 节点1短路是反方向, 线路2-3上没有电流, 各段都不动作
+	reverse := checks[5]
+	if reverse.Location != FaultReverse || reverse.Node != 1 || reverse.Operate != [3]bool{} || len(reverse.Violations) != 0 {
+		t.Errorf("reverse check = %+v", reverse)
+	}
+	assertComplex(t, "D23 internal", checks[3].Z, 0.15i)
+}
+
+func TestCheckDistanceRelaysSinglePhase(t *testing.T) {
+	// 线路零序阻抗是正序的3倍, k0 = (j0.6 - j0.2)/(3*j0.2) = 2/3,
+	// 补偿后的接地阻抗元件同样测得继电器到短路点的正序阻抗
+	grid := []Branch{{Node1: 1, Reactance: 0.1}, {Node1: 1, Node2: 2, Reactance: 0.2}}
+	network := SequenceNetwork{
+		Grid1: grid, Grid2: grid,
+		Grid0: []Branch{{Node1: 1, Reactance: 0.1}, {Node1: 1, Node2: 2, Reactance: 0.6}},
+		F1:    2, F2: 2, F0: 2,
+		DistanceRelays: []DistanceRelay{{Node1: 1, Node2: 2}},
+	}
+	var parsers [3]*Parser
+	for i, branches := range [][]Branch{network.Grid1, network.Grid2, network.Grid0} {
+		p, err := NewBranchParser(branches)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.ComputeResult(); err != nil {
+			t.Fatal(err)
+		}
+		parsers[i] = p
+	}
+	checks, err := network.CheckDistanceRelays(parsers[0], parsers[1], parsers[2], Fault1Ph, []float64{0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checks) != 2 {
+		t.Fatalf("%d checks, want 2", len(checks))
+	}
+	assertComplex(t, "internal", checks[0].Z, 0.1i)
+	assertComplex(t, "remote", checks[1].Z, 0.2i)
+	if len(checks[0].Violations)+len(checks[1].Violations) != 0 {
+		t.Errorf("violations = %v, %v", checks[0].Violations, checks[1].Violations)
+	}
+	network.DistanceRelays[0].Zone2 = 50
+	if _, err := network.CheckDistanceRelays(parsers[0], parsers[1], parsers[2], Fault1Ph, nil); err == nil {
+		t.Error("zone 2 shorter than zone 1 was accepted")
+	}
+}
@@ -0,0 +1,253 @@
+package psa
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+const (
+	// 功角特性曲线的采样间隔(度), 从0到180度
+	powerAngleStep = 1
+	// 求短路中功角达到临界切除角的时间时的积分步长(s)和最长时间(s)
+	eacStep    = 1e-4
+	eacMaxTime = 10
+)
+
+// 单机无穷大系统的等面积定则参数
+type EqualAreaOptions struct {
+	// 发电机的序号(从1开始), 为0时网络中只能有一台发电机
+	Generator int
+	// 三相短路的母线
+	Fault int
+	// 切除短路时断开的线路两端节点, 都为0时网络恢复到短路前
+	TripNode1, TripNode2 int
+	// 系统频率(Hz), 为0时取50Hz
+	Frequency float64
+	// 短路切除时间(s), 大于0时判断该切除时间下是否稳定
+	ClearTime float64
+}
+
+// 功角特性 Pe(δ) = Pc + Pmax sin(δ - γ), δ为发电机内电势相对无穷大母线的相角
+type PowerAngleCurve struct {
+	// 发电机内电势与无穷大母线之间的转移阻抗(标幺值)
+	Z    complex128
+	Pc   float64
+	Pmax float64
+	// 弧度
+	Gamma float64
+}
+
+// 收缩后两节点导纳矩阵中, 第2个节点(发电机)的电磁功率
+func newPowerAngleCurve(Y *ComplexMatrix, E, V float64) PowerAngleCurve {
+	Y21 := Y.rcAt(2, 1)
+	c := PowerAngleCurve{
+		Pc:   E * E * real(Y.rcAt(2, 2)),
+		Pmax: E * V * cmplx.Abs(Y21),
+		// Pe = E²G22 + EV|Y21|cos(δ - θ21)
+		Gamma: cmplx.Phase(Y21) - math.Pi/2,
+	}
+	if Y21 != 0 {
+		c.Z = -1 / Y21
+	}
+	return c
+}
+
+// δ为弧度
+func (c PowerAngleCurve) P(delta float64) float64 {
+	return c.Pc + c.Pmax*math.Sin(delta-c.Gamma)
+}
+
+// Pe在a到b上的积分
+func (c PowerAngleCurve) area(a, b float64) float64 {
+	return c.Pc*(b-a) - c.Pmax*(math.Cos(b-c.Gamma)-math.Cos(a-c.Gamma))
+}
+
+// 等面积定则的结果, 角度为度, 功率为标幺值
+type EqualArea struct {
+	Machine string
+	// 发电机的暂态电势和无穷大母线电压的模
+	E, V float64
+	// 换算到系统基准容量的惯性时间常数(s)
+	H  float64
+	Pm float64
+	// 短路前、短路中和短路后的功角特性
+	Pre, During, Post PowerAngleCurve
+	// 初始功角和短路后功角特性上的最大摆开角
+	Delta0, DeltaMax float64
+	// 加速面积等于减速面积时的临界切除角
+	CriticalAngle float64
+	// 短路中功角达到临界切除角的时间(s), 持续短路也不失稳时为+Inf
+	CriticalTime float64
+	// 短路后功角特性上可以提供的最大减速面积
+	MaxDecelerating float64
+	// 给出切除时间时, 切除时的功角和是否稳定
+	ClearingAngle float64
+	Stable        bool
+	// 绘图用的功角特性曲线
+	Angles                  []float64
+	PePre, PeDuring, PePost []float64
+}
+
+// 单机无穷大系统的等面积定则: 系统电源SG作为无穷大母线, 其余发电机的暂态电抗和负荷作为对地支路,
+// 把网络收缩到发电机内电势和无穷大母线两个节点, 得到短路前、短路中和短路后的转移阻抗和功角特性
+func (p *Parser) ComputeEqualArea(opts EqualAreaOptions) (*EqualArea, error) {
+	if opts.Frequency == 0 {
+		opts.Frequency = 50
+	}
+	if !positive(opts.Frequency) || !(opts.ClearTime >= 0) || opts.ClearTime > eacMaxTime {
+		return nil, fmt.Errorf("频率必须是大于0的有限值, 切除时间应在0到%gs之间", float64(eacMaxTime))
+	}
+	if err := p.checkNode(opts.Fault); err != nil {
+		return nil, err
+	}
+	if p.SB == 0 {
+		return nil, fmt.Errorf("没有基准容量SB")
+	}
+	if p.network.SG == nil || p.network.SG.Node == 0 {
+		return nil, fmt.Errorf("单机无穷大系统需要系统电源SG作为无穷大母线")
+	}
+	k := opts.Generator
+	if k == 0 && len(p.network.PowerGenerators) == 1 {
+		k = 1
+	}
+	if k <= 0 || k > len(p.network.PowerGenerators) {
+		return nil, fmt.Errorf("需要给出发电机的序号(1到%d)", len(p.network.PowerGenerators))
+	}
+	if p.network.PowerGenerators[k-1].H <= 0 {
+		return nil, fmt.Errorf("第%d台发电机没有给出惯性时间常数H", k)
+	}
+	machines, err := p.stabilityMachines()
+	if err != nil {
+		return nil, err
+	}
+	// machines[0]为无穷大母线, 第k台发电机为machines[k]
+	infinite, generator := machines[0], machines[k]
+	pair := []machine{infinite, generator}
+	shunt := func(Y *ComplexMatrix) {
+		for i, m := range machines[1:] {
+			if i+1 != k {
+				Y.rcSet(m.node, m.node, Y.rcAt(m.node, m.node)+m.y)
+			}
+		}
+	}
+	Y, err := p.stabilityY(machines, [2]int{})
+	if err != nil {
+		return nil, err
+	}
+	shunt(Y)
+	Ypost := Y
+	if opts.TripNode1 != 0 || opts.TripNode2 != 0 {
+		if Ypost, err = p.stabilityY(machines, [2]int{opts.TripNode1, opts.TripNode2}); err != nil {
+			return nil, err
+		}
+		shunt(Ypost)
+	}
+	pre, err := reduceToMachines(Y, pair, 0)
+	if err != nil {
+		return nil, fmt.Errorf("短路前网络: %w", err)
+	}
+	during, err := reduceToMachines(Y, pair, opts.Fault)
+	if err != nil {
+		return nil, fmt.Errorf("短路中网络: %w", err)
+	}
+	post, err := reduceToMachines(Ypost, pair, 0)
+	if err != nil {
+		return nil, fmt.Errorf("短路后网络: %w", err)
+	}
+	r := &EqualArea{
+		Machine: generator.name,
+		E:       cmplx.Abs(generator.e),
+		V:       cmplx.Abs(infinite.e),
+		H:       generator.h,
+	}
+	r.Pre = newPowerAngleCurve(pre, r.E, r.V)
+	r.During = newPowerAngleCurve(during, r.E, r.V)
+	r.Post = newPowerAngleCurve(post, r.E, r.V)
+	delta0 := cmplx.Phase(generator.e) - cmplx.Phase(infinite.e)
+	r.Pm = r.Pre.P(delta0)
+	if r.Post.Pmax == 0 || r.Pm-r.Post.Pc > r.Post.Pmax {
+		return nil, fmt.Errorf("短路后的功角特性最大值小于机械功率%.4f, 切除短路后不能保持同步", r.Pm)
+	}
+	deltaMax := r.Post.Gamma + math.Pi - math.Asin((r.Pm-r.Post.Pc)/r.Post.Pmax)
+	if delta0 >= deltaMax {
+		return nil, fmt.Errorf("初始功角已经超过短路后的最大摆开角")
+	}
+	// 临界切除角δc: 加速面积∫(Pm-Pe短路中)等于减速面积∫(Pe短路后-Pm)
+	excess := func(dc float64) float64 {
+		accelerating := r.Pm*(dc-delta0) - r.During.area(delta0, dc)
+		decelerating := r.Post.area(dc, deltaMax) - r.Pm*(deltaMax-dc)
+		return accelerating - decelerating
+	}
+	r.MaxDecelerating = r.Post.area(delta0, deltaMax) - r.Pm*(deltaMax-delta0)
+	dc := deltaMax
+	if excess(deltaMax) > 0 {
+		lo, hi := delta0, deltaMax
+		for hi-lo > 1e-9 {
+			mid := (lo + hi) / 2
+			if excess(mid) > 0 {
+				hi = mid
+			} else {
+				lo = mid
+			}
+		}
+		dc = lo
+	}
+	r.Delta0 = delta0 * 180 / math.Pi
+	r.DeltaMax = deltaMax * 180 / math.Pi
+	r.CriticalAngle = dc * 180 / math.Pi
+	omegaS := 2 * math.Pi * opts.Frequency
+	r.CriticalTime = math.Inf(1)
+	if excess(deltaMax) > 0 {
+		r.CriticalTime = r.faultOnTime(delta0, dc, omegaS)
+	}
+	if opts.ClearTime > 0 {
+		r.ClearingAngle = r.faultOnAngle(delta0, opts.ClearTime, omegaS) * 180 / math.Pi
+		r.Stable = opts.ClearTime < r.CriticalTime
+	}
+	for d := 0.0; d <= 180; d += powerAngleStep {
+		rad := d * math.Pi / 180
+		r.Angles = append(r.Angles, d)
+		r.PePre = append(r.PePre, r.Pre.P(rad))
+		r.PeDuring = append(r.PeDuring, r.During.P(rad))
+		r.PePost = append(r.PePost, r.Post.P(rad))
+	}
+	return r, nil
+}
+
+// 按短路中的功角特性积分转子运动方程 2H dω/dt = Pm - Pe, 返回功角达到dc的时间;
+// 功角先开始减小或超过eacMaxTime时认为达不到, 返回+Inf
+func (r *EqualArea) faultOnTime(delta0, dc, omegaS float64) float64 {
+	state := []float64{delta0, 1}
+	f := r.swing(omegaS)
+	for t := 0.0; t < eacMaxTime; t += eacStep {
+		next := rk4Step(state, eacStep, f)
+		if next[0] >= dc {
+			// 在一步之内线性插值
+			return t + eacStep*(dc-state[0])/(next[0]-state[0])
+		}
+		if next[1] < 1 {
+			break
+		}
+		state = next
+	}
+	return math.Inf(1)
+}
+
+// 短路持续t秒时的功角(弧度)
+func (r *EqualArea) faultOnAngle(delta0, t, omegaS float64) float64 {
+	state := []float64{delta0, 1}
+	f := r.swing(omegaS)
+	n := int(math.Ceil(t / eacStep))
+	h := t / float64(n)
+	for i := 0; i < n; i++ {
+		state = rk4Step(state, h, f)
+	}
+	return state[0]
+}
+
+func (r *EqualArea) swing(omegaS float64) func([]float64) []float64 {
+	return func(x []float64) []float64 {
+		return []float64{omegaS * (x[1] - 1), (r.Pm - r.During.P(x[0])) / (2 * r.H)}
+	}
+}
@@ -0,0 +1,105 @@
+package psa
+
+import (
+	"math"
+	"testing"
+)
+
+// 发电机端短路: 短路中Pe = 0, 与暂态稳定仿真的smibNetwork相同,
+// δ0 = atan(0.24), Pmax = |E'|/0.7, δmax = 180° - δ0, cosδc = sinδ0(δmax-δ0)+cosδmax,
+// 匀加速到δc的时间 tc = √(4H(δc-δ0)/(ωs Pm))
+func TestEqualAreaTerminalFault(t *testing.T) {
+	p, err := NewParser(smibNetwork())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := p.ComputeEqualArea(EqualAreaOptions{Fault: 1, ClearTime: 0.1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := math.Hypot(1, 0.24)
+	pm := 0.24 / 0.7
+	delta0 := math.Atan(0.24)
+	deltaMax := math.Pi - delta0
+	dc := math.Acos(math.Sin(delta0)*(deltaMax-delta0) + math.Cos(deltaMax))
+	assertFloat(t, "E", r.E, e, 1e-9)
+	assertFloat(t, "V", r.V, 1, 1e-9)
+	assertFloat(t, "H", r.H, 5, 1e-12)
+	assertFloat(t, "Pm", r.Pm, pm, 1e-9)
+	assertComplex(t, "Zpre", r.Pre.Z, complex(0, 0.7))
+	assertFloat(t, "Pmax pre", r.Pre.Pmax, e/0.7, 1e-9)
+	assertFloat(t, "γ", r.Pre.Gamma, 0, 1e-12)
+	assertFloat(t, "Pmax during", r.During.Pmax, 0, 1e-12)
+	assertFloat(t, "δ0", r.Delta0, delta0*180/math.Pi, 1e-9)
+	assertFloat(t, "δmax", r.DeltaMax, deltaMax*180/math.Pi, 1e-9)
+	assertFloat(t, "δc", r.CriticalAngle, dc*180/math.Pi, 1e-6)
+	assertFloat(t, "δc(deg)", r.CriticalAngle, 110.436203, 1e-6)
+	assertFloat(t, "tc", r.CriticalTime, math.Sqrt(4*5*(dc-delta0)/(100*math.Pi*pm)), 1e-6)
+	// 0.1s时 Δδ = ωs·Pm/(4H)·t² = 3.0857°
+	assertFloat(t, "δ(0.1)", r.ClearingAngle, 16.581447567, 1e-6)
+	if !r.Stable {
+		t.Error("Stable = false, want true")
+	}
+	if len(r.Angles) != 181 || r.PePre[90] != r.Pre.P(math.Pi/2) || r.PeDuring[90] != 0 {
+		t.Errorf("power-angle curve: %d points, Pe(90°) = %v, %v", len(r.Angles), r.PePre[90], r.PeDuring[90])
+	}
+}
+
+// 节点1、2之间两回x=0.4的线路, 其中一回经节点3分为两段0.2, 节点3短路后断开线路1-3:
+// 短路前 0.3+0.2+0.2 = 0.7; 短路后 0.3+0.4+0.2 = 0.9;
+// 短路中经两次星网变换, 转移电抗为3.1
+func TestEqualAreaTripLine(t *testing.T) {
+	network := smibNetwork()
+	network.Branches = []Branch{
+		{Node1: 1, Node2: 2, Reactance: 0.4},
+		{Node1: 1, Node2: 3, Reactance: 0.2},
+		{Node1: 3, Node2: 2, Reactance: 0.2},
+	}
+	p, err := NewParser(network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := p.ComputeEqualArea(EqualAreaOptions{Fault: 3, TripNode1: 1, TripNode2: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertComplex(t, "Zpre", r.Pre.Z, complex(0, 0.7))
+	assertComplex(t, "Zduring", r.During.Z, complex(0, 3.1))
+	assertComplex(t, "Zpost", r.Post.Z, complex(0, 0.9))
+	assertFloat(t, "Pmax during", r.During.Pmax, 0.331740907, 1e-9)
+	assertFloat(t, "Pmax post", r.Post.Pmax, 1.142663123, 1e-9)
+	assertFloat(t, "δmax", r.DeltaMax, 162.539337343, 1e-6)
+	assertFloat(t, "δc", r.CriticalAngle, 129.950939283, 1e-6)
+	// 短路中Pmax略小于Pm, 加速比发电机端短路慢得多
+	if math.IsInf(r.CriticalTime, 1) || r.CriticalTime < 0.5605 {
+		t.Errorf("tc = %v, want finite and longer than the terminal fault", r.CriticalTime)
+	}
+}
+
+func TestEqualAreaErrors(t *testing.T) {
+	p, err := NewParser(smibNetwork())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.ComputeEqualArea(EqualAreaOptions{Fault: 1, Generator: 2}); err == nil {
+		t.Error("generator 2: want error")
+	}
+	if _, err := p.ComputeEqualArea(EqualAreaOptions{Fault: 1, ClearTime: eacMaxTime + 1}); err == nil {
+		t.Error("clear time beyond eacMaxTime: want error")
+	}
+	if _, err := p.ComputeEqualArea(EqualAreaOptions{Fault: 1, ClearTime: math.NaN()}); err == nil {
+		t.Error("NaN clear time: want error")
+	}
+	if _, err := p.ComputeEqualArea(EqualAreaOptions{Fault: 1, TripNode1: 1, TripNode2: 2}); err == nil {
+		t.Error("tripping the only line: want error")
+	}
+	network := smibNetwork()
+	network.SG = nil
+	network.Branches = append(network.Branches, Branch{Node1: 2, Reactance: 0.2, E: 1})
+	if p, err = NewParser(network); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.ComputeEqualArea(EqualAreaOptions{Fault: 1}); err == nil {
+		t.Error("no SG: want error")
+	}
+}
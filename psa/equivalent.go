@@ -0,0 +1,191 @@
+package psa
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sort"
+)
+
+// 等值导纳矩阵中模不大于这个值的互导纳不生成等值支路
+const equivalentBranchTolerance = 1e-9
+
+// 消去内部节点后, 保留节点上的等值网络
+type Equivalent struct {
+	// 保留的节点, 等值导纳矩阵的第i行对应节点Retained[i-1]
+	Retained []int
+	// 等值导纳矩阵 Y' = Ykk - Yke Yee^-1 Yek
+	Y *ComplexMatrix
+	// 各保留节点的等值对地导纳, 即等值导纳矩阵各行之和
+	Shunts []complex128
+	// 各保留节点的等值注入电流 I' = Ik - Yke Yee^-1 Ie
+	Injections []complex128
+	// Ward等值时外部节点的注入功率折算到各保留节点的等值注入功率(标幺值), Kron等值时为nil
+	Powers []complex128
+}
+
+// 检查并排序保留节点, 返回被消去的节点
+func (p *Parser) splitNodes(retained []int) (keep, eliminate []int, err error) {
+	if len(retained) == 0 {
+		return nil, nil, fmt.Errorf("没有给出保留的节点")
+	}
+	keep = append([]int(nil), retained...)
+	sort.Ints(keep)
+	for i, node := range keep {
+		if err := p.checkNode(node); err != nil {
+			return nil, nil, err
+		}
+		if i > 0 && keep[i-1] == node {
+			return nil, nil, fmt.Errorf("保留的节点%d重复", node)
+		}
+	}
+	eliminate, _ = remainingNodes(p.nodeNum, keep)
+	return keep, eliminate, nil
+}
+
+// 把内部节点的注入电流折算到保留节点 I' = Ik - Yke Yee^-1 Ie
+func reduceInjections(Y *ComplexMatrix, keep, eliminate []int, I []complex128) ([]complex128, error) {
+	result := make([]complex128, len(keep))
+	for i, node := range keep {
+		result[i] = I[node-1]
+	}
+	if len(eliminate) == 0 {
+		return result, nil
+	}
+	Ie := make([]complex128, len(eliminate))
+	for i, node := range eliminate {
+		Ie[i] = I[node-1]
+	}
+	Yee, _ := Y.Submatrix(eliminate, eliminate)
+	Yke, _ := Y.Submatrix(keep, eliminate)
+	YeeInv, err := Yee.Inverse()
+	if err != nil {
+		return nil, err
+	}
+	x, _ := YeeInv.MulVec(Ie)
+	moved, _ := Yke.MulVec(x)
+	for i := range result {
+		result[i] -= moved[i]
+	}
+	return result, nil
+}
+
+func newEquivalent(Y *ComplexMatrix, keep, eliminate []int, I []complex128) (*Equivalent, error) {
+	Yeq, err := Y.Kron(eliminate...)
+	if err != nil {
+		return nil, err
+	}
+	injections, err := reduceInjections(Y, keep, eliminate, I)
+	if err != nil {
+		return nil, err
+	}
+	shunts := make([]complex128, len(keep))
+	for i, row := range Yeq.m {
+		for _, y := range row {
+			shunts[i] += y
+		}
+	}
+	return &Equivalent{Retained: keep, Y: Yeq, Shunts: shunts, Injections: injections}, nil
+}
+
+// Kron等值: 在导纳矩阵上消去保留节点以外的节点,
+// 电源支路(E不为0)的诺顿注入电流 E/z 一并折算为保留节点上的等值电源
+func (p *Parser) KronEquivalent(retained []int) (*Equivalent, error) {
+	keep, eliminate, err := p.splitNodes(retained)
+	if err != nil {
+		return nil, err
+	}
+	p.ComputeResultY()
+	I, _ := p.sourceInjections()
+	return newEquivalent(p.resultY, keep, eliminate, I)
+}
+
+// 电源支路的诺顿注入电流 E/z, 第二个返回值表示网络中是否有电源
+func (p *Parser) sourceInjections() ([]complex128, bool) {
+	I := make([]complex128, p.nodeNum)
+	hasSource := false
+	for _, branch := range p.branches {
+		if node, isGroundBranch := p.isGroundBranch(branch); isGroundBranch && branch.E != 0 {
+			I[node-1] += complex(branch.E, 0) / complex(branch.Resistance, branch.Reactance)
+			hasSource = true
+		}
+	}
+	return I, hasSource
+}
+
+// Ward等值: 用于潮流计算, 导纳矩阵不含电源支路,
+// 外部节点的注入功率按母线数据中的电压(没有时取1∠0)换算为注入电流后折算到保留节点
+func (p *Parser) WardEquivalent(retained []int) (*Equivalent, error) {
+	keep, eliminate, err := p.splitNodes(retained)
+	if err != nil {
+		return nil, err
+	}
+	if p.SB == 0 {
+		return nil, fmt.Errorf("没有基准容量SB, 无法换算注入功率")
+	}
+	network := &Parser{nodeNum: p.nodeNum}
+	for _, branch := range p.branches {
+		if branch.E == 0 {
+			network.branches = append(network.branches, branch)
+		}
+	}
+	network.ComputeResultY()
+	V := make([]complex128, p.nodeNum)
+	S := make([]complex128, p.nodeNum)
+	for i := range V {
+		V[i] = 1
+	}
+	for _, bus := range p.network.Buses {
+		if bus.Node <= 0 || bus.Node > p.nodeNum {
+			continue
+		}
+		if bus.V != 0 {
+			V[bus.Node-1] = cmplx.Rect(bus.V, bus.Angle*math.Pi/180)
+		}
+		S[bus.Node-1] += complex(bus.Pg-bus.Pd, bus.Qg-bus.Qd) / complex(p.SB, 0)
+	}
+	// 只折算外部节点的注入, 保留节点原有的注入不变
+	I := make([]complex128, p.nodeNum)
+	for _, node := range eliminate {
+		I[node-1] = cmplx.Conj(S[node-1] / V[node-1])
+	}
+	eq, err := newEquivalent(network.resultY, keep, eliminate, I)
+	if err != nil {
+		return nil, err
+	}
+	eq.Powers = make([]complex128, len(keep))
+	for i, node := range keep {
+		eq.Powers[i] = V[node-1] * cmplx.Conj(eq.Injections[i])
+	}
+	return eq, nil
+}
+
+// 等值网络的标幺值支路, 保留节点重新编号为1到len(Retained),
+// 等值电源的电势为注入电流除以等值对地导纳, E只保存其模
+func (e *Equivalent) Branches() []Branch {
+	var branches []Branch
+	n := len(e.Retained)
+	for i := 1; i <= n; i++ {
+		for j := i + 1; j <= n; j++ {
+			y := -e.Y.rcAt(i, j)
+			if cmplx.Abs(y) <= equivalentBranchTolerance {
+				continue
+			}
+			z := 1 / y
+			branches = append(branches, Branch{Node1: i, Node2: j, Resistance: real(z), Reactance: imag(z)})
+		}
+	}
+	for i := 1; i <= n; i++ {
+		y := e.Shunts[i-1]
+		if cmplx.Abs(y) <= equivalentBranchTolerance {
+			continue
+		}
+		z := 1 / y
+		branch := Branch{Node1: i, Node2: 0, Resistance: real(z), Reactance: imag(z)}
+		if e.Powers == nil {
+			branch.E = cmplx.Abs(e.Injections[i-1] / y)
+		}
+		branches = append(branches, branch)
+	}
+	return branches
+}
@@ -0,0 +1,93 @@
+package psa
+
+import (
+	"testing"
+)
+
+// 电源x=0.1接在节点1, 1-2的线路x=0.2, 2-3的线路x=0.3
+func chainNetwork() PowerNetwork {
+	return PowerNetwork{SB: 100, Branches: []Branch{
+		{Node1: 1, Reactance: 0.1, E: 1},
+		{Node1: 1, Node2: 2, Reactance: 0.2},
+		{Node1: 2, Node2: 3, Reactance: 0.3},
+	}}
+}
+
+func TestKronEquivalent(t *testing.T) {
+	p, err := NewParser(chainNetwork())
+	if err != nil {
+		t.Fatal(err)
+	}
+	eq, err := p.KronEquivalent([]int{3, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(eq.Retained) != 2 || eq.Retained[0] != 1 || eq.Retained[1] != 3 {
+		t.Fatalf("Retained = %v, want [1 3]", eq.Retained)
+	}
+	// 消去节点2: Y13' = -(j5)(j3.333)/(-j8.333) = j2, 即x = 0.2 + 0.3的串联支路
+	assertComplex(t, "Y13", eq.Y.At(1, 2), 2i)
+	assertComplex(t, "Y11", eq.Y.At(1, 1), -12i)
+	// 电源的注入电流 1/j0.1 留在节点1上
+	assertComplex(t, "I1", eq.Injections[0], -10i)
+	assertComplex(t, "I3", eq.Injections[1], 0)
+
+	branches := eq.Branches()
+	if len(branches) != 2 {
+		t.Fatalf("branches = %+v", branches)
+	}
+	assertFloat(t, "x13", branches[0].Reactance, 0.5, 1e-12)
+	assertFloat(t, "x1", branches[1].Reactance, 0.1, 1e-12)
+	assertFloat(t, "E1", branches[1].E, 1, 1e-12)
+}
+
+func TestKronEquivalentSingleNode(t *testing.T) {
+	p, err := NewParser(chainNetwork())
+	if err != nil {
+		t.Fatal(err)
+	}
+	eq, err := p.KronEquivalent([]int{3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 只保留节点3时就是x = 0.1 + 0.2 + 0.3、E = 1的戴维南支路
+	branches := eq.Branches()
+	if len(branches) != 1 || branches[0].Node1 != 1 || branches[0].Node2 != 0 {
+		t.Fatalf("branches = %+v", branches)
+	}
+	assertFloat(t, "x", branches[0].Reactance, 0.6, 1e-12)
+	assertFloat(t, "E", branches[0].E, 1, 1e-12)
+}
+
+func TestWardEquivalent(t *testing.T) {
+	network := chainNetwork()
+	// 节点1的负荷50MW在1∠0时的注入电流为-0.5, 经Yke Yee^-1折算到节点2
+	network.Buses = []Bus{{Node: 1, Type: BusPQ, Pd: 50}}
+	p, err := NewParser(network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eq, err := p.WardEquivalent([]int{2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 不含电源支路时节点1只通过1-2相连, 注入电流全部转移到节点2
+	assertComplex(t, "S2", eq.Powers[0], -0.5)
+	assertComplex(t, "S3", eq.Powers[1], 0)
+}
+
+func TestEquivalentErrors(t *testing.T) {
+	p, err := NewParser(chainNetwork())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, retained := range map[string][]int{
+		"none":      nil,
+		"duplicate": {2, 2},
+		"unknown":   {4},
+	} {
+		if _, err := p.KronEquivalent(retained); err == nil {
+			t.Errorf("%s retained nodes were accepted", name)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package psa
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 在计算阻抗矩阵之前调用了短路计算
+var ErrNotComputed = errors.New("尚未计算阻抗矩阵")
+
+// 导纳矩阵奇异, LDU分解时第Pivot个主元(对应节点Node)为0, 通常是网络没有接地支路或存在孤立节点
+type SingularMatrixError struct {
+	Pivot int
+	Node  int
+}
+
+func (e *SingularMatrixError) Error() string {
+	// gonum的分解不给出主元的位置
+	if e.Pivot == 0 {
+		return "导纳矩阵奇异"
+	}
+	return fmt.Sprintf("导纳矩阵奇异: 第%d个主元(节点%d)为0", e.Pivot, e.Node)
+}
+
+// 节点不在网络中
+type UnknownNodeError struct {
+	Node int
+}
+
+func (e *UnknownNodeError) Error() string {
+	return fmt.Sprintf("节点%d不在网络中", e.Node)
+}
+
+// 节点上没有电源支路, 无法得到电源阻抗
+type MissingSourceError struct {
+	Node int
+}
+
+func (e *MissingSourceError) Error() string {
+	return fmt.Sprintf("节点%d上没有电源支路", e.Node)
+}
+
+// 短路位置无效, 线路中点短路时Node2为线路的另一端, 否则为0
+type InvalidFaultError struct {
+	Node1  int
+	Node2  int
+	Reason string
+}
+
+func (e *InvalidFaultError) Error() string {
+	if e.Node2 != 0 {
+		return fmt.Sprintf("线路%d-%d不能作为短路位置: %s", e.Node1, e.Node2, e.Reason)
+	}
+	return fmt.Sprintf("节点%d不能作为短路点: %s", e.Node1, e.Reason)
+}
+
+// 各电源提供的短路电流之和与短路电流不一致
+type ContributionError struct {
+	Node       int
+	Sum, Total complex128
+}
+
+func (e *ContributionError) Error() string {
+	return fmt.Sprintf("节点%d短路时各电源的电流之和%v与短路电流%v不一致", e.Node, e.Sum, e.Total)
+}
+
+// 矩阵运算时两个矩阵(或向量)的维数不匹配
+type DimensionError struct {
+	Op         string
+	Row1, Col1 int
+	Row2, Col2 int
+}
+
+func (e *DimensionError) Error() string {
+	return fmt.Sprintf("%d×%d矩阵与%d×%d矩阵不能%s", e.Row1, e.Col1, e.Row2, e.Col2, e.Op)
+}
+
+// 检查节点号是否在1到nodeNum之间
+func (p *Parser) checkNode(node int) error {
+	if node <= 0 || node > p.nodeNum {
+		return &UnknownNodeError{Node: node}
+	}
+	return nil
+}
+
+// 检查短路点, 并要求已经计算了阻抗矩阵
+func (p *Parser) checkFault(f int) error {
+	if err := p.checkNode(f); err != nil {
+		return err
+	}
+	if p.resultZ == nil {
+		return ErrNotComputed
+	}
+	return nil
+}
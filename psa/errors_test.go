@@ -0,0 +1,76 @@
+package psa
+
+import (
+	"errors"
+	"testing"
+)
+
+// 电源x=0.1接在节点1, 1-2的线路x=0.2
+func twoNodeNetwork() PowerNetwork {
+	return PowerNetwork{Branches: []Branch{
+		{Node1: 1, Reactance: 0.1, E: 1},
+		{Node1: 1, Node2: 2, Reactance: 0.2},
+	}}
+}
+
+func TestFaultErrors(t *testing.T) {
+	p, err := NewParser(twoNodeNetwork())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.ComputeShortIf(2); err != ErrNotComputed {
+		t.Errorf("before ComputeResult: %v, want ErrNotComputed", err)
+	}
+	if err := p.ComputeResult(); err != nil {
+		t.Fatal(err)
+	}
+	var unknown *UnknownNodeError
+	if _, err := p.ComputeShortIf(3); !errors.As(err, &unknown) || unknown.Node != 3 {
+		t.Errorf("fault at node 3: %v", err)
+	}
+	var missing *MissingSourceError
+	if _, err := p.Getzi(1, 2); !errors.As(err, &missing) || missing.Node != 2 {
+		t.Errorf("source at node 2: %v", err)
+	}
+	var invalid *InvalidFaultError
+	if _, err := p.HalfShortCircuitMatrix(1, 1); !errors.As(err, &invalid) {
+		t.Errorf("midline fault without a line: %v", err)
+	}
+	// 没有出错时结果是有限的: If = 1/j0.3, zf1 = Zff*z1/Zf1 = j0.3
+	If, err := p.ComputeShortIf(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertComplex(t, "If", If, 1/0.3i)
+	zf, err := p.Computezfi(2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertComplex(t, "zf1", zf, 0.3i)
+}
+
+func TestUngroundedNetworkIsSingular(t *testing.T) {
+	// 没有接地支路时导纳矩阵奇异, 不能得到NaN的阻抗矩阵
+	p, err := NewParser(PowerNetwork{Branches: []Branch{{Node1: 1, Node2: 2, Reactance: 0.2}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var singular *SingularMatrixError
+	if err := p.ComputeResult(); !errors.As(err, &singular) {
+		t.Errorf("ComputeResult = %v, want SingularMatrixError", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if _, err := NewParser(PowerNetwork{}); err == nil {
+		t.Error("empty network was accepted")
+	}
+	network := PowerNetwork{SB: 100, Transformers: []Transformer{{Node1: 1, Node2: 2, Vs: 10.5}}}
+	if _, err := NewParser(network); err == nil {
+		t.Error("transformer without Sn was accepted")
+	}
+	network = PowerNetwork{SB: 100, Circuits: []Circuit{{Node1: 1, Node2: 2, X: 0.4, L: 10}}}
+	if _, err := NewParser(network); err == nil {
+		t.Error("circuit without a base voltage was accepted")
+	}
+}
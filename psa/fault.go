@@ -0,0 +1,193 @@
+package psa
+
+import (
+	"fmt"
+	"math"
+)
+
+// 节点发生三相短路后的节点导纳矩阵, 即删去短路节点的行和列,
+// 短路节点电压为0, 不需要像KronEquivalent那样把它的影响折算到其余节点
+func (p *Parser) ShortCircuitMatrix(node int) (*ComplexMatrix, error) {
+	if err := p.checkNode(node); err != nil {
+		return nil, err
+	}
+	return p.resultY.Without(node)
+}
+
+// 线路中点发生三相短路后的节点导纳矩阵
+func (p *Parser) HalfShortCircuitMatrix(node1 int, node2 int) (*ComplexMatrix, error) {
+	if err := p.checkNode(node1); err != nil {
+		return nil, err
+	}
+	if err := p.checkNode(node2); err != nil {
+		return nil, err
+	}
+	copyResult := p.resultY.Copy()
+	// 找到发生短路的branch
+	var shortCircuit *Branch
+	for i := 0; i < len(p.branches); i++ {
+		branch := p.branches[i]
+		if (branch.Node1 == node1 && branch.Node2 == node2) || (branch.Node2 == node1 && branch.Node1 == node2) {
+			shortCircuit = &branch
+			break
+		}
+	}
+	if shortCircuit == nil {
+		return nil, &InvalidFaultError{Node1: node1, Node2: node2, Reason: "两个节点之间没有线路"}
+	}
+	// 线路的充电电纳B为支路导纳的两倍
+	B := 2 * shortCircuit.Admittance
+	// Yii' = Yii - Yij - j0.25B
+	Yij := copyResult.rcAt(node1, node2)
+	copyResult.rcSet(node1, node1, copyResult.rcAt(node1, node1)-Yij-complex(0, 0.25*B))
+	copyResult.rcSet(node2, node2, copyResult.rcAt(node2, node2)-Yij-complex(0, 0.25*B))
+	// Yij' = 0
+	copyResult.rcSet(node1, node2, 0)
+	copyResult.rcSet(node2, node1, 0)
+	return copyResult, nil
+}
+
+// 短路点的输入阻抗, 为0时短路电流无穷大
+func (p *Parser) zff(f int) (complex128, error) {
+	if err := p.checkFault(f); err != nil {
+		return 0, err
+	}
+	Zff := p.resultZ.rcAt(f, f)
+	if Zff == 0 {
+		return 0, &InvalidFaultError{Node1: f, Reason: "输入阻抗为0"}
+	}
+	return Zff, nil
+}
+
+func (p *Parser) ComputeShortIf(f int) (complex128, error) {
+	zf := complex(0, 0)
+	Zff, err := p.zff(f)
+	if err != nil {
+		return 0, err
+	}
+	return 1 / (Zff + zf), nil
+}
+
+func (p *Parser) ComputeAllNodeShortU(f int) ([]complex128, error) {
+	zf := complex(0, 0)
+	Zff, err := p.zff(f)
+	if err != nil {
+		return nil, err
+	}
+	U := make([]complex128, p.nodeNum)
+	for i := 1; i <= len(U); i++ {
+		U[i-1] = 1 - (p.resultZ.rcAt(i, f) / (Zff + zf))
+	}
+	return U, nil
+}
+
+// 各支路的电流, (i, j)元素为母线i流向母线j的电流 (Ui - Uj) yij = (Uj - Ui) Yij, 没有支路相连时为0
+func (p *Parser) ComputeIij(U []complex128) (*ComplexMatrix, error) {
+	if len(U) != p.nodeNum {
+		return nil, fmt.Errorf("节点电压的个数%d与节点数%d不同", len(U), p.nodeNum)
+	}
+	Iij := NewComplexMatrix(p.nodeNum, p.nodeNum)
+	for i := 1; i <= p.nodeNum; i++ {
+		for j := 1; j <= p.nodeNum; j++ {
+			if i != j {
+				Iij.rcSet(i, j, (U[j-1]-U[i-1])*p.resultY.rcAt(i, j))
+			}
+		}
+	}
+	return Iij, nil
+}
+
+func (p *Parser) ComputeUBeforeShort(allI []complex128) ([]complex128, error) {
+	if p.resultZ == nil {
+		return nil, ErrNotComputed
+	}
+	// U = ZI
+	return p.resultZ.MulVec(allI)
+}
+
+func (p *Parser) ComputeUAfterShort(f int, UBeforeShort []complex128) ([]complex128, error) {
+	if _, err := p.zff(f); err != nil {
+		return nil, err
+	}
+	if len(UBeforeShort) != p.nodeNum {
+		return nil, fmt.Errorf("短路前电压的个数%d与节点数%d不同", len(UBeforeShort), p.nodeNum)
+	}
+	UAfterShort := make([]complex128, p.nodeNum)
+	for i := 1; i <= p.nodeNum; i++ {
+		UAfterShort[i-1] = UBeforeShort[i-1] - (p.resultZ.rcAt(i, f) * UBeforeShort[f-1] / p.resultZ.rcAt(f, f))
+	}
+	return UAfterShort, nil
+}
+
+// 各电源对短路点的转移阻抗和提供的短路电流, 下标为电源所在节点-1,
+// 同一节点上有多个电源时转移阻抗按并联合并, 电流相加
+func (p *Parser) ComputeAllzfiAndI(f int) (zf []complex128, i []complex128, err error) {
+	zf = make([]complex128, p.nodeNum)
+	I := make([]complex128, p.nodeNum)
+	// 没有电源时转移阻抗和电流都为0
+	if len(p.Sources()) == 0 {
+		return zf, I, nil
+	}
+	c, err := p.ComputeContributions(f, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, s := range c.Sources {
+		n := s.Node - 1
+		if zf[n] == 0 {
+			zf[n] = s.Zf
+		} else {
+			zf[n] = zf[n] * s.Zf / (zf[n] + s.Zf)
+		}
+		I[n] += s.I
+	}
+	return zf, I, nil
+}
+
+// 电源i对短路点f的转移阻抗 zfi = Zff * zi / Zfi
+func (p *Parser) Computezfi(f, i int) (complex128, error) {
+	Zff, err := p.zff(f)
+	if err != nil {
+		return 0, err
+	}
+	zi, err := p.Getzi(f, i)
+	if err != nil {
+		return 0, err
+	}
+	Zfi := p.resultZ.rcAt(f, i)
+	if Zfi == 0 {
+		return 0, &InvalidFaultError{Node1: f, Reason: fmt.Sprintf("与节点%d上的电源不连通", i)}
+	}
+	return (Zff * zi) / Zfi, nil
+}
+
+// 节点i上电源支路的阻抗
+func (p *Parser) Getzi(f, i int) (complex128, error) {
+	if err := p.checkNode(i); err != nil {
+		return 0, err
+	}
+	for n := 0; n < len(p.branches); n++ {
+		branch := p.branches[n]
+		if branch.Node1 == i && branch.E != 0 {
+			return complex(branch.Resistance, branch.Reactance), nil
+		}
+	}
+	return 0, &MissingSourceError{Node: i}
+}
+
+// 由各电源的转移阻抗计算短路电流
+func (p *Parser) ComputeI(zf []complex128) complex128 {
+	I := complex(0, 0)
+	for i := 0; i < len(zf); i++ {
+		zfi := zf[i]
+		if zfi != 0 {
+			I += complex(0, 1) / zfi
+		}
+	}
+	return I
+}
+
+// 短路功率 S = √3 U I
+func (p *Parser) ComputeP(u float64, I complex128) complex128 {
+	return complex(u*math.Sqrt(3), 0) * I
+}
@@ -0,0 +1,51 @@
+package psa
+
+import (
+	"fmt"
+	"testing"
+)
+
+// 电源x=0.1接在节点1, 节点1到112串联111段x=0.01的线路
+func longChainNetwork() PowerNetwork {
+	network := PowerNetwork{SB: 100, Branches: []Branch{{Node1: 1, Reactance: 0.1, E: 1}}}
+	for i := 1; i < 112; i++ {
+		network.Branches = append(network.Branches, Branch{Node1: i, Node2: i + 1, Reactance: 0.01})
+	}
+	return network
+}
+
+// 节点112短路: Zff = j(0.1 + 111×0.01) = j1.21, 各段线路都流过If, 方向指向短路点
+func TestComputeIijThreeDigitNodes(t *testing.T) {
+	p, err := NewParser(longChainNetwork())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ComputeResult(); err != nil {
+		t.Fatal(err)
+	}
+	If, err := p.ComputeShortIf(112)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertComplex(t, "If", If, 1/complex(0, 1.21))
+	U, err := p.ComputeAllNodeShortU(112)
+	if err != nil {
+		t.Fatal(err)
+	}
+	I, err := p.ComputeIij(U)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pair := range [][2]int{{1, 2}, {11, 12}, {99, 100}, {111, 112}} {
+		i, j := pair[0], pair[1]
+		assertComplex(t, fmt.Sprintf("I(%d,%d)", i, j), I.At(i, j), If)
+		assertComplex(t, fmt.Sprintf("I(%d,%d)", j, i), I.At(j, i), -If)
+	}
+	// "I1112"既可以是11-12也可以是1-112, 后者之间没有支路
+	if I.At(1, 112) != 0 || I.At(11, 13) != 0 {
+		t.Errorf("I(1,112) = %v, I(11,13) = %v, want 0", I.At(1, 112), I.At(11, 13))
+	}
+	if _, err := p.ComputeIij(U[:3]); err == nil {
+		t.Error("short voltage vector: want error")
+	}
+}
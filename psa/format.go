@@ -0,0 +1,156 @@
+package psa
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 支持的数据文件格式
+const (
+	FormatJSON     = "json"
+	FormatCDF      = "cdf"
+	FormatMatpower = "matpower"
+	FormatRaw      = "raw"
+	FormatCSV      = "csv"
+)
+
+// 根据扩展名判断文件格式, 目录按每种元件一个的csv文件处理, 无法识别时按json处理
+func FormatOf(path string) string {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return FormatCSV
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".cdf", ".cf":
+		return FormatCDF
+	case ".m":
+		return FormatMatpower
+	case ".raw":
+		return FormatRaw
+	case ".csv":
+		return FormatCSV
+	}
+	return FormatJSON
+}
+
+// 按格式读取网络数据并解码到v中, v一般为*PowerNetwork
+func Decode(r io.Reader, format string, v interface{}) error {
+	var network *PowerNetwork
+	var err error
+	switch format {
+	case FormatJSON, "":
+		return json.NewDecoder(r).Decode(v)
+	case FormatCDF:
+		network, err = ReadCDF(r)
+	case FormatMatpower:
+		network, err = ReadMatpower(r)
+	case FormatRaw:
+		network, err = ReadRaw(r)
+	case FormatCSV:
+		network, err = readCSVFrom(r)
+	default:
+		return fmt.Errorf("不支持的文件格式: %s", format)
+	}
+	if err != nil {
+		return err
+	}
+	if v, ok := v.(*PowerNetwork); ok {
+		*v = *network
+		return nil
+	}
+	// 其他结构体与PowerNetwork使用相同的json字段名
+	data, err := json.Marshal(network)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// 将网络按格式写出, v中只有SB和标幺值支路(branches)会被写出
+func Encode(w io.Writer, format string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var network PowerNetwork
+	if err := json.Unmarshal(data, &network); err != nil {
+		return err
+	}
+	switch format {
+	case FormatJSON, "":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(network)
+	case FormatMatpower:
+		return WriteMatpower(w, &network)
+	}
+	return fmt.Errorf("不支持导出的文件格式: %s", format)
+}
+
+// 从文件读取网络, format为空时按扩展名判断
+func ImportPowerNetworkFromFile(path string, format string) (PowerNetwork, error) {
+	var network PowerNetwork
+	file, err := os.Open(path)
+	if err != nil {
+		return network, fmt.Errorf("打开文件失败: %v", err)
+	}
+	defer file.Close()
+	if format == "" {
+		format = FormatOf(path)
+	}
+	if err := Decode(file, format, &network); err != nil {
+		return network, fmt.Errorf("解析失败: %v", err)
+	}
+	return network, nil
+}
+
+// 从文件读取各序网络, 只给出元件参数时正序和负序网络相同, 零序网络必须在文件中给出
+func ImportSequenceNetworkFromFile(path string, format string) (SequenceNetwork, error) {
+	var network SequenceNetwork
+	if format == "" {
+		format = FormatOf(path)
+	}
+	if format == FormatJSON {
+		file, err := os.Open(path)
+		if err != nil {
+			return network, fmt.Errorf("打开文件失败: %v", err)
+		}
+		defer file.Close()
+		if err := json.NewDecoder(file).Decode(&network); err != nil {
+			return network, fmt.Errorf("解析失败: %v", err)
+		}
+	}
+	if len(network.Grid1) == 0 {
+		components, err := ImportPowerNetworkFromFile(path, format)
+		if err != nil {
+			return network, err
+		}
+		parser, err := NewParser(components)
+		if err != nil {
+			return network, err
+		}
+		// 静止元件的负序参数与正序相同
+		network.Grid1 = parser.Branches()
+		network.Grid2 = network.Grid1
+	}
+	if len(network.Grid0) == 0 {
+		return network, fmt.Errorf("缺少零序网络数据")
+	}
+	return network, nil
+}
+
+// 将网络按扩展名对应的格式写到文件
+func ExportPowerNetwork(path string, network interface{}) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %v", err)
+	}
+	defer file.Close()
+	if err := Encode(file, FormatOf(path), network); err != nil {
+		return fmt.Errorf("导出失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,343 @@
+package psa
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// IEC 60909中冲击系数κ的计算方法
+const (
+	// 取网络各支路中最小的R/X
+	IECMethodA = "A"
+	// 取短路点阻抗的R/X, κ再乘以1.15
+	IECMethodB = "B"
+	// 等效频率法, 按20Hz(50Hz系统)或24Hz(60Hz系统)计算短路点阻抗
+	IECMethodC = "C"
+)
+
+// 按IEC 60909计算短路电流的参数
+type IEC60909 struct {
+	// 计算最大短路电流, 否则计算最小短路电流
+	Max bool
+	// 电压系数, 为0时按标称电压取默认值
+	C float64
+	// 冲击系数的计算方法, 为空时使用方法B
+	Method string
+	// 标称电压(kV), 为0时使用网络的Vav, 用于选择电压系数和发电机校正系数
+	Un float64
+	// 最小开断时间(s), 为0时取0.1s
+	Tmin float64
+	// 短路持续时间(s), 为0时取1s
+	Tk float64
+	// 系统频率(Hz), 为0时取50Hz
+	Frequency float64
+}
+
+// 按IEC 60909计算的短路电流, 电流为标幺值
+type IEC60909Result struct {
+	// 使用的电压系数
+	C float64
+	// 短路点的短路阻抗, 已经计入校正系数
+	Zk complex128
+	// 计算冲击系数使用的R/X
+	RX    float64
+	Kappa float64
+	// 初始对称短路电流Ik''
+	Ik float64
+	// 冲击电流ip
+	Ip float64
+	// 对称开断电流Ib
+	Ib float64
+	// 热等效短路电流Ith
+	Ith float64
+}
+
+func (s IEC60909) withDefaults(vav float64) IEC60909 {
+	if s.Un == 0 {
+		s.Un = vav
+	}
+	if s.Method == "" {
+		s.Method = IECMethodB
+	}
+	if s.Tmin == 0 {
+		s.Tmin = 0.1
+	}
+	if s.Tk == 0 {
+		s.Tk = 1
+	}
+	if s.Frequency == 0 {
+		s.Frequency = 50
+	}
+	if s.C == 0 {
+		s.C = s.defaultC()
+	}
+	return s
+}
+
+// 最大短路电流取cmax = 1.10(低压系统按电压偏差+10%), 最小短路电流高压系统取1.00, 低压系统取0.95,
+// 没有标称电压时按高压系统
+func (s IEC60909) defaultC() float64 {
+	if s.Max {
+		return 1.10
+	}
+	if s.Un != 0 && s.Un <= 1 {
+		return 0.95
+	}
+	return 1.00
+}
+
+// 校正系数公式中的cmax
+func (s IEC60909) cmax() float64 {
+	if s.Max {
+		return s.C
+	}
+	return 1.10
+}
+
+func (s IEC60909) validate() error {
+	switch s.Method {
+	case IECMethodA, IECMethodB, IECMethodC:
+	default:
+		return fmt.Errorf("未知的冲击系数计算方法: %s", s.Method)
+	}
+	if s.C < 0 || s.Tmin < 0 || s.Tk < 0 || s.Frequency < 0 {
+		return fmt.Errorf("IEC 60909的参数不能为负数")
+	}
+	return nil
+}
+
+// 发电机的校正系数 KG = Un/UrG * cmax/(1 + xd” sinφrG), 没有功率因数时sinφrG取0
+func (s IEC60909) generatorFactor(generator PowerGenerator) float64 {
+	un := generator.VB
+	if un == 0 {
+		un = s.Un
+	}
+	ur := generator.Vn
+	if ur == 0 {
+		ur = un
+	}
+	ratio := 1.0
+	if un != 0 && ur != 0 {
+		ratio = un / ur
+	}
+	sin := 0.0
+	if generator.Cos > 0 && generator.Cos < 1 {
+		sin = math.Sqrt(1 - generator.Cos*generator.Cos)
+	}
+	return ratio * s.cmax() / (1 + generator.Xd*sin)
+}
+
+// 双绕组变压器的校正系数 KT = 0.95 * cmax/(1 + 0.6xT), xT为短路电压的标幺值
+func (s IEC60909) transformerFactor(transformer Transformer) float64 {
+	return 0.95 * s.cmax() / (1 + 0.6*transformer.Vs/100)
+}
+
+// 按IEC 60909建立网络: 发电机电抗乘以KG, 变压器短路电压乘以KT, 计算最小短路电流时不计电动机,
+// 短路点使用等效电压源cUn/√3, 其余电源的电势不计入
+func NewIEC60909Parser(network PowerNetwork, s IEC60909) (*Parser, error) {
+	s = s.withDefaults(network.Vav)
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	network.PowerGenerators = append([]PowerGenerator(nil), network.PowerGenerators...)
+	for i := range network.PowerGenerators {
+		network.PowerGenerators[i].Xd *= s.generatorFactor(network.PowerGenerators[i])
+	}
+	if !s.Max {
+		network = network.WithoutMotors()
+	}
+	network.Transformers = append([]Transformer(nil), network.Transformers...)
+	for i := range network.Transformers {
+		kt := s.transformerFactor(network.Transformers[i])
+		network.Transformers[i].Vs *= kt
+		network.Transformers[i].Vr *= kt
+	}
+	p, err := NewParser(network)
+	if err != nil {
+		return nil, err
+	}
+	p.iec = &s
+	return p, nil
+}
+
+// 按IEC 60909计算节点f的三相短路电流, 需要先用NewIEC60909Parser建立网络并计算阻抗矩阵
+func (p *Parser) ComputeIEC60909(f int) (*IEC60909Result, error) {
+	if p.iec == nil {
+		return nil, fmt.Errorf("网络不是按IEC 60909建立的")
+	}
+	s := *p.iec
+	Zk, err := p.zff(f)
+	if err != nil {
+		return nil, err
+	}
+	// 没有电源的网络从短路点看进去可能是容性的, κ的公式不再适用
+	if imag(Zk) <= 0 {
+		return nil, &InvalidFaultError{Node1: f, Reason: "短路阻抗不是感性的, 网络中可能没有电源"}
+	}
+	r := &IEC60909Result{C: s.C, Zk: Zk}
+	r.Ik = p.equivalentSource(s) / cmplx.Abs(Zk)
+	if r.RX, err = p.kappaRX(f, s); err != nil {
+		return nil, err
+	}
+	// 纯电抗时避免输出-0
+	r.RX = math.Abs(r.RX)
+	r.Kappa = kappa(r.RX)
+	if s.Method == IECMethodB {
+		r.Kappa *= p.methodBFactor()
+	}
+	// 方法A和B的κ不超过低压系统1.8, 高压系统2.0
+	limit := 2.0
+	if s.Un != 0 && s.Un <= 1 {
+		limit = 1.8
+	}
+	if s.Method != IECMethodC && r.Kappa > limit {
+		r.Kappa = limit
+	}
+	r.Ip = r.Kappa * math.Sqrt2 * r.Ik
+	r.Ib = r.Ik - p.breakingReduction(f, s)
+	r.Ith = r.Ik * math.Sqrt(thermalM(r.Kappa, s.Frequency, s.Tk)+1)
+	return r, nil
+}
+
+// 等效电压源cUn/√3的标幺值, 网络以Vav为基准电压, 即 c·Un/Vav, 没有Vav时为c
+func (p *Parser) equivalentSource(s IEC60909) float64 {
+	if p.Vav == 0 || s.Un == 0 {
+		return s.C
+	}
+	return s.C * s.Un / p.Vav
+}
+
+// κ = 1.02 + 0.98e^(-3R/X)
+func kappa(rx float64) float64 {
+	return 1.02 + 0.98*math.Exp(-3*rx)
+}
+
+// 计算冲击系数使用的R/X
+func (p *Parser) kappaRX(f int, s IEC60909) (float64, error) {
+	switch s.Method {
+	case IECMethodA:
+		min := math.Inf(1)
+		for _, branch := range p.branches {
+			if branch.Reactance > 0 {
+				min = math.Min(min, branch.Resistance/branch.Reactance)
+			}
+		}
+		if math.IsInf(min, 1) {
+			return 0, nil
+		}
+		return min, nil
+	case IECMethodC:
+		// fc/f = 20/50 = 24/60 = 0.4, 电抗和电纳都按频率换算
+		const ratio = 0.4
+		branches := make([]Branch, len(p.branches))
+		for i, branch := range p.branches {
+			branch.Reactance *= ratio
+			branch.Admittance *= ratio
+			branches[i] = branch
+		}
+		pc, err := NewBranchParser(branches)
+		if err != nil {
+			return 0, err
+		}
+		pc.PivotTolerance, pc.Pivoting = p.PivotTolerance, p.Pivoting
+		if err := pc.ComputeResult(); err != nil {
+			return 0, err
+		}
+		Zc, err := pc.zff(f)
+		if err != nil {
+			return 0, err
+		}
+		if imag(Zc) == 0 {
+			return math.Inf(1), nil
+		}
+		return real(Zc) / imag(Zc) * ratio, nil
+	}
+	Zk := p.resultZ.rcAt(f, f)
+	if imag(Zk) == 0 {
+		return math.Inf(1), nil
+	}
+	return real(Zk) / imag(Zk), nil
+}
+
+// 方法B的κ乘以1.15, 各支路的R/X都小于0.3时不需要
+func (p *Parser) methodBFactor() float64 {
+	for _, branch := range p.branches {
+		if branch.Reactance != 0 && branch.Resistance/branch.Reactance >= 0.3 {
+			return 1.15
+		}
+	}
+	return 1
+}
+
+// 近端短路时发电机电流的衰减使开断电流减小
+// Ib = Ik” - Σ ΔU”G/(cUn/√3) * (1-μ) IkG”, ΔU”G为发电机电抗上的电压降
+func (p *Parser) breakingReduction(f int, s IEC60909) float64 {
+	reduction := 0.0
+	source := p.equivalentSource(s)
+	If := complex(source, 0) / p.resultZ.rcAt(f, f)
+	for _, generator := range p.network.PowerGenerators {
+		g := generator.Node
+		if g <= 0 || g > p.nodeNum {
+			continue
+		}
+		sn := generator.Sn
+		if sn == 0 && generator.Cos != 0 {
+			sn = generator.Pn / generator.Cos
+		}
+		if sn == 0 || generator.Xd == 0 {
+			continue
+		}
+		zG := complex(0, generator.Xd*p.SB/sn)
+		// 短路点的等效电压源在发电机端产生的电压变化就是发电机电抗上的电压降
+		dU := cmplx.Abs(p.resultZ.rcAt(g, f) * If)
+		IkG := dU / cmplx.Abs(zG)
+		IrG := sn / p.SB
+		mu := breakingMu(s.Tmin, IkG/IrG)
+		reduction += dU / source * (1 - mu) * IkG
+	}
+	return reduction
+}
+
+// 衰减系数μ, 在tmin为0.02、0.05、0.1和0.25s的曲线之间线性插值
+func breakingMu(tmin, ratio float64) float64 {
+	if ratio <= 2 {
+		return 1
+	}
+	curves := []struct {
+		t, a, b, k float64
+	}{
+		{0.02, 0.84, 0.26, 0.26},
+		{0.05, 0.71, 0.51, 0.30},
+		{0.10, 0.62, 0.72, 0.32},
+		{0.25, 0.56, 0.94, 0.38},
+	}
+	mu := func(i int) float64 {
+		c := curves[i]
+		return math.Min(c.a+c.b*math.Exp(-c.k*ratio), 1)
+	}
+	if tmin <= curves[0].t {
+		return mu(0)
+	}
+	for i := 1; i < len(curves); i++ {
+		if tmin <= curves[i].t {
+			w := (tmin - curves[i-1].t) / (curves[i].t - curves[i-1].t)
+			return mu(i-1) + w*(mu(i)-mu(i-1))
+		}
+	}
+	return mu(len(curves) - 1)
+}
+
+// 直流分量的热效应系数 m = (e^(4fTk ln(κ-1)) - 1) / (2fTk ln(κ-1)),
+// 交流分量按远端短路取 n = 1
+func thermalM(kappa, frequency, tk float64) float64 {
+	l := math.Log(kappa - 1)
+	if math.IsNaN(l) || math.IsInf(l, 0) {
+		return 0
+	}
+	// κ = 2时直流分量不衰减, m的极限为2
+	if l == 0 {
+		return 2
+	}
+	return (math.Exp(4*frequency*tk*l) - 1) / (2 * frequency * tk * l)
+}
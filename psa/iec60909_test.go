@@ -0,0 +1,106 @@
+package psa
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIEC60909MaxCurrent(t *testing.T) {
+	// 电源z=0.01+j0.1接在节点1, 没有发电机和变压器时不需要校正
+	network := PowerNetwork{Branches: []Branch{{Node1: 1, Resistance: 0.01, Reactance: 0.1, E: 1}}}
+	for _, method := range []string{IECMethodB, IECMethodC} {
+		p, err := NewIEC60909Parser(network, IEC60909{Max: true, Method: method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.ComputeResult(); err != nil {
+			t.Fatal(err)
+		}
+		r, err := p.ComputeIEC60909(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// cmax = 1.1, Ik = 1.1/|0.01+j0.1| = 10.9454
+		assertFloat(t, method+" c", r.C, 1.1, 0)
+		assertFloat(t, method+" Ik", r.Ik, 10.945409092, 1e-8)
+		// 等效频率法的R/X = (0.01/0.04)*0.4, 与短路点的R/X相同
+		assertFloat(t, method+" R/X", r.RX, 0.1, 1e-12)
+		// κ = 1.02 + 0.98e^-0.3, 各支路R/X小于0.3时方法B不乘1.15
+		assertFloat(t, method+" κ", r.Kappa, 1.746001856, 1e-8)
+		assertFloat(t, method+" ip", r.Ip, 27.026617622, 1e-8)
+		// 没有发电机时Ib = Ik, Ith = Ik√(m+1), m = 0.0341265
+		assertFloat(t, method+" Ib", r.Ib, r.Ik, 0)
+		assertFloat(t, method+" Ith", r.Ith, 11.130606703, 1e-8)
+	}
+}
+
+// 网络以Vav = 115kV为基准, 标称电压Un = 110kV时等效电压源 cUn/√3 的标幺值为 1.1×110/115
+func TestIEC60909NominalVoltage(t *testing.T) {
+	network := PowerNetwork{SB: 100, Vav: 115, Branches: []Branch{{Node1: 1, Resistance: 0.01, Reactance: 0.1, E: 1}}}
+	ik := func(un float64) *IEC60909Result {
+		p, err := NewIEC60909Parser(network, IEC60909{Max: true, Un: un})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.ComputeResult(); err != nil {
+			t.Fatal(err)
+		}
+		r, err := p.ComputeIEC60909(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+	// Un为0时取Vav, 与Un = 115kV相同
+	assertFloat(t, "Ik(Vav)", ik(0).Ik, 10.945409092, 1e-8)
+	assertFloat(t, "Ik(115)", ik(115).Ik, 10.945409092, 1e-8)
+	r := ik(110)
+	assertFloat(t, "Ik(110)", r.Ik, 10.945409092*110/115, 1e-8)
+	assertFloat(t, "ip(110)", r.Ip, 27.026617622*110/115, 1e-8)
+	assertFloat(t, "Ib(110)", r.Ib, r.Ik, 0)
+	assertFloat(t, "Ith(110)", r.Ith, 11.130606703*110/115, 1e-8)
+}
+
+func TestIEC60909MinCurrent(t *testing.T) {
+	network := PowerNetwork{SB: 100, Branches: []Branch{{Node1: 1, Resistance: 0.01, Reactance: 0.1, E: 1}}}
+	p, err := NewIEC60909Parser(network, IEC60909{Un: 0.4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ComputeResult(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := p.ComputeIEC60909(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 低压系统的cmin = 0.95
+	assertFloat(t, "c", r.C, 0.95, 0)
+	assertFloat(t, "Ik", r.Ik, 0.95/math.Hypot(0.01, 0.1), 1e-9)
+	if _, err := NewIEC60909Parser(network, IEC60909{Method: "D"}); err == nil {
+		t.Error("unknown method was accepted")
+	}
+}
+
+func TestIEC60909CorrectionFactors(t *testing.T) {
+	s := IEC60909{Max: true}.withDefaults(10.5)
+	// KG = 1.1/(1 + 0.2*0.6)
+	kg := s.generatorFactor(PowerGenerator{Xd: 0.2, Cos: 0.8, VB: 10.5, Vn: 10.5})
+	assertFloat(t, "KG", kg, 1.1/1.12, 1e-12)
+	// 额定电压10kV的发电机接在10.5kV母线上, 再乘以Un/UrG
+	kg = s.generatorFactor(PowerGenerator{Xd: 0.2, Cos: 0.8, VB: 10.5, Vn: 10})
+	assertFloat(t, "KG 10kV", kg, 1.05*1.1/1.12, 1e-12)
+	// KT = 0.95*1.1/(1 + 0.6*0.105)
+	kt := s.transformerFactor(Transformer{Vs: 10.5})
+	assertFloat(t, "KT", kt, 0.95*1.1/1.063, 1e-12)
+}
+
+func TestBreakingMu(t *testing.T) {
+	// 发电机电流不超过2倍额定电流时不衰减
+	assertFloat(t, "μ(2)", breakingMu(0.1, 2), 1, 0)
+	// tmin = 0.1s: μ = 0.62 + 0.72e^(-0.32*5)
+	assertFloat(t, "μ(0.1, 5)", breakingMu(0.1, 5), 0.62+0.72*math.Exp(-1.6), 1e-12)
+	// 0.1s和0.25s的中点取两条曲线的平均值
+	mid := (0.62 + 0.72*math.Exp(-1.6) + 0.56 + 0.94*math.Exp(-1.9)) / 2
+	assertFloat(t, "μ(0.175, 5)", breakingMu(0.175, 5), mid, 1e-12)
+}
@@ -0,0 +1,76 @@
+package psa
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// 节点1经x=1接地, 节点2经x=0.1接地, 1-2的线路x=0.1
+// Y = [-j11 j10; j10 -j20], det = -120
+func pivotNetwork() PowerNetwork {
+	return PowerNetwork{Branches: []Branch{
+		{Node1: 1, Reactance: 1, E: 1},
+		{Node1: 2, Reactance: 0.1, E: 1},
+		{Node1: 1, Node2: 2, Reactance: 0.1},
+	}}
+}
+
+func TestLDUPivoting(t *testing.T) {
+	for _, pivoting := range []bool{false, true} {
+		p, err := NewParser(pivotNetwork())
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Pivoting = pivoting
+		if err := p.ComputeResult(); err != nil {
+			t.Fatal(err)
+		}
+		// 主元选择不改变阻抗矩阵: Z11 = -j20/-120, Z22 = -j11/-120, Z12 = -j10/-120
+		Z := p.ResultZ()
+		assertComplex(t, "Z11", Z.At(1, 1), 1i/6)
+		assertComplex(t, "Z22", Z.At(2, 2), 11i/120)
+		assertComplex(t, "Z12", Z.At(1, 2), 1i/12)
+		assertComplex(t, "Z21", Z.At(2, 1), 1i/12)
+	}
+
+	p, err := NewParser(pivotNetwork())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Pivoting = true
+	p.ComputeResultY()
+	_, d, u, err := p.LDU()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// |Y22| > |Y11|, 节点2作为第一个主元: d1 = -j20, u12 = j10/-j20, d2 = -j11 - 0.25*(-j20)
+	if !reflect.DeepEqual(p.Permutation(), []int{1, 0}) {
+		t.Errorf("Permutation = %v, want [1 0]", p.Permutation())
+	}
+	assertComplex(t, "d1", d.At(1, 1), -20i)
+	assertComplex(t, "u12", u.At(1, 2), -0.5)
+	assertComplex(t, "d2", d.At(2, 2), -6i)
+}
+
+func TestLDUSingular(t *testing.T) {
+	// 2-3是不接地的孤岛, 消去节点2后节点3的主元 -j5 - (-1)²(-j5) = 0
+	network := PowerNetwork{Branches: []Branch{
+		{Node1: 1, Reactance: 0.1, E: 1},
+		{Node1: 2, Node2: 3, Reactance: 0.2},
+	}}
+	for _, pivoting := range []bool{false, true} {
+		p, err := NewParser(network)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Pivoting = pivoting
+		var singular *SingularMatrixError
+		if err := p.ComputeResult(); !errors.As(err, &singular) {
+			t.Fatalf("pivoting %v: ComputeResult = %v, want SingularMatrixError", pivoting, err)
+		}
+		if singular.Pivot != 3 || singular.Node != 3 {
+			t.Errorf("pivoting %v: pivot %d at node %d, want 3 at node 3", pivoting, singular.Pivot, singular.Node)
+		}
+	}
+}
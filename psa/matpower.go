@@ -0,0 +1,302 @@
+package psa
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	matpowerBaseMVA = regexp.MustCompile(`mpc\.baseMVA\s*=\s*([-+0-9.eE]+)`)
+	matpowerMatrix  = regexp.MustCompile(`(?s)mpc\.(bus|gen|branch)\s*=\s*\[(.*?)\]`)
+)
+
+// 读取MATPOWER的算例文件(mpc结构体的文本格式)
+//
+// 母线按出现顺序重新编号为1..n, 同一母线上投运的发电机出力合并到Bus.Pg和Bus.Qg;
+// 母线并联导纳由MW/Mvar换算为标幺值.
+func ReadMatpower(r io.Reader) (*PowerNetwork, error) {
+	text, err := stripMatpowerComments(r)
+	if err != nil {
+		return nil, err
+	}
+	network := &PowerNetwork{}
+	match := matpowerBaseMVA.FindStringSubmatch(text)
+	if match == nil {
+		return nil, fmt.Errorf("matpower: 缺少mpc.baseMVA")
+	}
+	if network.SB, err = strconv.ParseFloat(match[1], 64); err != nil {
+		return nil, fmt.Errorf("matpower: baseMVA: %v", err)
+	}
+	matrices := map[string][][]float64{}
+	for _, match := range matpowerMatrix.FindAllStringSubmatch(text, -1) {
+		rows, err := parseMatpowerRows(match[2])
+		if err != nil {
+			return nil, fmt.Errorf("matpower: mpc.%s: %v", match[1], err)
+		}
+		matrices[match[1]] = rows
+	}
+	if len(matrices["bus"]) == 0 {
+		return nil, fmt.Errorf("matpower: 缺少mpc.bus")
+	}
+
+	// 原始母线号 -> 节点号
+	nodes := map[int]int{}
+	for i, row := range matrices["bus"] {
+		if len(row) < 13 {
+			return nil, fmt.Errorf("matpower: mpc.bus第%d行的列数不足", i+1)
+		}
+		bus := Bus{
+			Node:   len(network.Buses) + 1,
+			Number: int(row[0]),
+			Type:   int(row[1]),
+			Pd:     row[2],
+			Qd:     row[3],
+			Gs:     row[4] / network.SB,
+			Bs:     row[5] / network.SB,
+			V:      row[7],
+			Angle:  row[8],
+			BaseKV: row[9],
+		}
+		if _, exist := nodes[bus.Number]; exist {
+			return nil, fmt.Errorf("matpower: 母线%d重复", bus.Number)
+		}
+		nodes[bus.Number] = bus.Node
+		network.Buses = append(network.Buses, bus)
+	}
+	for i, row := range matrices["gen"] {
+		if len(row) < 8 {
+			return nil, fmt.Errorf("matpower: mpc.gen第%d行的列数不足", i+1)
+		}
+		node, exist := nodes[int(row[0])]
+		if !exist {
+			return nil, fmt.Errorf("matpower: 发电机的母线%d不存在", int(row[0]))
+		}
+		if row[7] <= 0 {
+			continue
+		}
+		network.Buses[node-1].Pg += row[1]
+		network.Buses[node-1].Qg += row[2]
+	}
+	for i, row := range matrices["branch"] {
+		if len(row) < 11 {
+			return nil, fmt.Errorf("matpower: mpc.branch第%d行的列数不足", i+1)
+		}
+		node1, exist := nodes[int(row[0])]
+		if !exist {
+			return nil, fmt.Errorf("matpower: 支路的母线%d不存在", int(row[0]))
+		}
+		node2, exist := nodes[int(row[1])]
+		if !exist {
+			return nil, fmt.Errorf("matpower: 支路的母线%d不存在", int(row[1]))
+		}
+		network.Branches = append(network.Branches, Branch{
+			Node1:        node1,
+			Node2:        node2,
+			Resistance:   row[2],
+			Reactance:    row[3],
+			Admittance:   0.5 * row[4],
+			RateA:        row[5],
+			RateB:        row[6],
+			RateC:        row[7],
+			Ratio:        row[8],
+			Angle:        row[9],
+			OutOfService: row[10] <= 0,
+		})
+	}
+	return network, nil
+}
+
+// 去掉%开始的注释
+func stripMatpowerComments(r io.Reader) (string, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	var builder strings.Builder
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "%"); i >= 0 {
+			line = line[:i]
+		}
+		builder.WriteString(line)
+		builder.WriteString("\n")
+	}
+	return builder.String(), scanner.Err()
+}
+
+// 矩阵的行以分号或换行分隔, 列以空白或逗号分隔
+func parseMatpowerRows(body string) ([][]float64, error) {
+	var rows [][]float64
+	lines := strings.FieldsFunc(body, func(r rune) bool {
+		return r == ';' || r == '\n'
+	})
+	for _, line := range lines {
+		fields := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t' || r == '\r'
+		})
+		if len(fields) == 0 {
+			continue
+		}
+		row := make([]float64, len(fields))
+		for i, field := range fields {
+			v, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, err
+			}
+			row[i] = v
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// 写出MATPOWER算例文件
+//
+// 接地支路(发电机、负荷等效电抗和并联导纳)合并为所在母线的Gs和Bs;
+// MATPOWER中变压器节点1侧的充电电纳要除以变比的平方, 而各实验不随变比折算,
+// 因此非标准变比支路的充电电纳也作为两端母线的Bs, 这样由makeYbus得到的导纳矩阵
+// 与各实验的导纳矩阵相同(移相角只在MATPOWER中计入).
+// 没有母线数据时按支路的节点生成, 节点1作为平衡节点.
+func WriteMatpower(w io.Writer, network *PowerNetwork) error {
+	sb := network.SB
+	if sb == 0 {
+		sb = 100
+	}
+	buses := network.Buses
+	if len(buses) == 0 {
+		buses = busesOfBranches(network.Branches)
+	}
+	// 节点号 -> 母线在buses中的下标
+	index := map[int]int{}
+	shunts := make([]complex128, len(buses))
+	for i, bus := range buses {
+		index[bus.Node] = i
+		shunts[i] = complex(bus.Gs, bus.Bs)
+	}
+	var branches []Branch
+	for _, branch := range network.Branches {
+		node := branch.Node1
+		if branch.Node1 == 0 {
+			node = branch.Node2
+		} else if branch.Node2 != 0 {
+			if branch.Ratio != 0 && branch.Ratio != 1 && branch.Admittance != 0 {
+				for _, node := range []int{branch.Node1, branch.Node2} {
+					i, exist := index[node]
+					if !exist {
+						return fmt.Errorf("matpower: 支路的节点%d不存在", node)
+					}
+					shunts[i] += complex(0, branch.Admittance)
+				}
+				branch.Admittance = 0
+			}
+			branches = append(branches, branch)
+			continue
+		}
+		i, exist := index[node]
+		if !exist {
+			return fmt.Errorf("matpower: 接地支路的节点%d不存在", node)
+		}
+		if branch.Resistance != 0 || branch.Reactance != 0 {
+			shunts[i] += 1 / complex(branch.Resistance, branch.Reactance)
+		}
+	}
+	number := func(node int) (int, error) {
+		i, exist := index[node]
+		if !exist {
+			return 0, fmt.Errorf("matpower: 支路的节点%d不存在", node)
+		}
+		if buses[i].Number != 0 {
+			return buses[i].Number, nil
+		}
+		return buses[i].Node, nil
+	}
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "function mpc = psa_case")
+	fmt.Fprintln(out, "% 由power-system-analysis-labs导出")
+	fmt.Fprintln(out, "mpc.version = '2';")
+	fmt.Fprintf(out, "mpc.baseMVA = %g;\n\n", sb)
+	fmt.Fprintln(out, "%% bus data")
+	fmt.Fprintln(out, "%\tbus_i\ttype\tPd\tQd\tGs\tBs\tarea\tVm\tVa\tbaseKV\tzone\tVmax\tVmin")
+	fmt.Fprintln(out, "mpc.bus = [")
+	for i, bus := range buses {
+		n, _ := number(bus.Node)
+		v := bus.V
+		if v == 0 {
+			v = 1
+		}
+		fmt.Fprintf(out, "\t%d\t%d\t%g\t%g\t%g\t%g\t1\t%g\t%g\t%g\t1\t1.1\t0.9;\n",
+			n, bus.Type, bus.Pd, bus.Qd, real(shunts[i])*sb, imag(shunts[i])*sb, v, bus.Angle, bus.BaseKV)
+	}
+	fmt.Fprintln(out, "];")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "%% generator data")
+	fmt.Fprintln(out, "%\tbus\tPg\tQg\tQmax\tQmin\tVg\tmBase\tstatus\tPmax\tPmin")
+	fmt.Fprintln(out, "mpc.gen = [")
+	for _, bus := range buses {
+		switch {
+		case bus.Type == BusPV || bus.Type == BusSlack:
+		case bus.Type == BusPQ && (bus.Pg != 0 || bus.Qg != 0):
+			// PQ母线上的出力作为恒定注入的发电机, MATPOWER不改变母线类型
+		default:
+			continue
+		}
+		n, _ := number(bus.Node)
+		v := bus.V
+		if v == 0 {
+			v = 1
+		}
+		fmt.Fprintf(out, "\t%d\t%g\t%g\t9999\t-9999\t%g\t%g\t1\t9999\t0;\n", n, bus.Pg, bus.Qg, v, sb)
+	}
+	fmt.Fprintln(out, "];")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "%% branch data")
+	fmt.Fprintln(out, "%\tfbus\ttbus\tr\tx\tb\trateA\trateB\trateC\tratio\tangle\tstatus\tangmin\tangmax")
+	fmt.Fprintln(out, "mpc.branch = [")
+	for _, branch := range branches {
+		n1, err := number(branch.Node1)
+		if err != nil {
+			return err
+		}
+		n2, err := number(branch.Node2)
+		if err != nil {
+			return err
+		}
+		status := 1
+		if branch.OutOfService {
+			status = 0
+		}
+		fmt.Fprintf(out, "\t%d\t%d\t%g\t%g\t%g\t%g\t%g\t%g\t%g\t%g\t%d\t-360\t360;\n",
+			n1, n2, branch.Resistance, branch.Reactance, 2*branch.Admittance,
+			branch.RateA, branch.RateB, branch.RateC, branch.Ratio, branch.Angle, status)
+	}
+	fmt.Fprintln(out, "];")
+	return out.Flush()
+}
+
+// 由支路的节点生成母线, 节点1作为平衡节点, 其余为PQ节点
+func busesOfBranches(branches []Branch) []Bus {
+	nodeNum := 0
+	for _, branch := range branches {
+		if branch.Node1 > nodeNum {
+			nodeNum = branch.Node1
+		}
+		if branch.Node2 > nodeNum {
+			nodeNum = branch.Node2
+		}
+	}
+	buses := make([]Bus, nodeNum)
+	for i := 0; i < nodeNum; i++ {
+		buses[i] = Bus{Node: i + 1, Type: BusPQ, V: 1}
+	}
+	if nodeNum > 0 {
+		buses[0].Type = BusSlack
+	}
+	return buses
+}
@@ -0,0 +1,115 @@
+package psa
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMatpowerRoundTrip(t *testing.T) {
+	// 电源x=0.1接在节点1, 1-2是x=0.2、B/2=0.01、变比1.05的变压器
+	network := PowerNetwork{
+		SB: 100,
+		Branches: []Branch{
+			{Node1: 1, Reactance: 0.1, E: 1},
+			{Node1: 1, Node2: 2, Reactance: 0.2, Admittance: 0.01, Ratio: 1.05},
+		},
+	}
+	var buffer bytes.Buffer
+	if err := WriteMatpower(&buffer, &network); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadMatpower(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.SB != 100 || len(read.Buses) != 2 || len(read.Branches) != 1 {
+		t.Fatalf("SB %v, %d buses, %d branches", read.SB, len(read.Buses), len(read.Branches))
+	}
+	if read.Buses[0].Type != BusSlack || read.Buses[1].Type != BusPQ {
+		t.Errorf("bus types = %d, %d", read.Buses[0].Type, read.Buses[1].Type)
+	}
+	// 电源电抗换算为母线的并联电纳 -1/0.1 * 100 MVA, 充电电纳作为两端母线的Bs
+	assertFloat(t, "Bs1", read.Buses[0].Bs, -10+0.01, 1e-12)
+	assertFloat(t, "Bs2", read.Buses[1].Bs, 0.01, 1e-12)
+	assertFloat(t, "ratio", read.Branches[0].Ratio, 1.05, 0)
+	assertFloat(t, "B/2", read.Branches[0].Admittance, 0, 0)
+
+	// 重新读入后的导纳矩阵与原网络相同: y = -j5,
+	// Y11 = -j10 + y/k² + j0.01, Y22 = y + j0.01, Y12 = -y/k
+	want := map[[2]int]complex128{
+		{1, 1}: complex(0, -10-5/(1.05*1.05)+0.01),
+		{2, 2}: complex(0, -5+0.01),
+		{1, 2}: complex(0, 5/1.05),
+	}
+	for name, n := range map[string]PowerNetwork{"original": network, "matpower": *read} {
+		p, err := NewParser(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.ComputeResultY()
+		for ij, y := range want {
+			assertComplex(t, name+" Y", p.ResultY().At(ij[0], ij[1]), y)
+		}
+	}
+}
+
+// PQ母线上的出力写为发电机, 读回后母线仍为PQ类型
+func TestWriteMatpowerPQGeneration(t *testing.T) {
+	network := PowerNetwork{
+		SB: 100,
+		Buses: []Bus{
+			{Node: 1, Type: BusSlack, V: 1},
+			{Node: 2, Type: BusPQ, Pd: 50, Qd: 10, Pg: 20, Qg: 5},
+			{Node: 3, Type: BusPQ, Pd: 30},
+		},
+		Branches: []Branch{
+			{Node1: 1, Node2: 2, Reactance: 0.1},
+			{Node1: 2, Node2: 3, Reactance: 0.1},
+		},
+	}
+	var buffer bytes.Buffer
+	if err := WriteMatpower(&buffer, &network); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadMatpower(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, bus := range network.Buses {
+		got := read.Buses[i]
+		if got.Type != bus.Type || got.Pd != bus.Pd || got.Qd != bus.Qd || got.Pg != bus.Pg || got.Qg != bus.Qg {
+			t.Errorf("bus %d = %+v, want %+v", bus.Node, got, bus)
+		}
+	}
+}
+
+func TestReadMatpowerGenerators(t *testing.T) {
+	text := `function mpc = case2
+mpc.baseMVA = 100;
+mpc.bus = [
+	7	3	0	0	0	0	1	1.02	0	110	1	1.1	0.9;
+	9	1	50	20	0	19	1	1	0	110	1	1.1	0.9;
+];
+% 第二台发电机停运, 不计入出力
+mpc.gen = [
+	7	40	10	99	-99	1.02	100	1	99	0;
+	7	30	5	99	-99	1.02	100	0	99	0;
+];
+mpc.branch = [
+	7	9	0.01	0.1	0.02	0	0	0	0	0	1	-360	360;
+];
+`
+	network, err := ReadMatpower(bytes.NewBufferString(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFloat(t, "Pg", network.Buses[0].Pg, 40, 0)
+	assertFloat(t, "Qg", network.Buses[0].Qg, 10, 0)
+	// 19 Mvar / 100 MVA
+	assertFloat(t, "Bs", network.Buses[1].Bs, 0.19, 1e-12)
+	branch := network.Branches[0]
+	if branch.Node1 != 1 || branch.Node2 != 2 {
+		t.Errorf("branch nodes = %d-%d, want 1-2", branch.Node1, branch.Node2)
+	}
+	assertFloat(t, "B/2", branch.Admittance, 0.01, 0)
+}
@@ -0,0 +1,317 @@
+package psa
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// 复数矩阵, 导纳矩阵、阻抗矩阵和LDU因子都用它保存, 对外的行和列从1开始
+type ComplexMatrix struct {
+	m [][]complex128
+}
+
+func NewComplexMatrix(row int, col int) *ComplexMatrix {
+	cm := new(ComplexMatrix)
+	cm.m = make([][]complex128, row)
+	for i := 0; i < row; i++ {
+		cm.m[i] = make([]complex128, col)
+	}
+	return cm
+}
+
+// 由按行保存的元素构造矩阵, 元素会被复制
+func NewComplexMatrixFrom(values [][]complex128) *ComplexMatrix {
+	cm := new(ComplexMatrix)
+	cm.m = make([][]complex128, len(values))
+	for i, row := range values {
+		cm.m[i] = append([]complex128(nil), row...)
+	}
+	return cm
+}
+
+// n阶单位矩阵
+func IdentityMatrix(n int) *ComplexMatrix {
+	cm := NewComplexMatrix(n, n)
+	for i := 0; i < n; i++ {
+		cm.m[i][i] = 1
+	}
+	return cm
+}
+
+// 输入参数为行和列的设值方式
+func (cm *ComplexMatrix) rcSet(row, column int, v complex128) {
+	cm.m[row-1][column-1] = v
+}
+
+// 输入参数为行和列的取值方式
+func (cm *ComplexMatrix) rcAt(row, column int) complex128 {
+	return cm.m[row-1][column-1]
+}
+
+// 输入参数为行和列的取值方式, 行和列从1开始
+func (cm *ComplexMatrix) At(row, column int) complex128 {
+	return cm.rcAt(row, column)
+}
+
+// 输入参数为行和列的设值方式, 行和列从1开始
+func (cm *ComplexMatrix) Set(row, column int, v complex128) {
+	cm.rcSet(row, column, v)
+}
+
+// 按行保存的矩阵元素
+func (cm *ComplexMatrix) Values() [][]complex128 {
+	return cm.m
+}
+
+// 行数和列数
+func (cm *ComplexMatrix) Dims() (row, col int) {
+	if len(cm.m) == 0 {
+		return 0, 0
+	}
+	return len(cm.m), len(cm.m[0])
+}
+
+func (cm *ComplexMatrix) Copy() *ComplexMatrix {
+	return NewComplexMatrixFrom(cm.m)
+}
+
+func (cm *ComplexMatrix) sameDims(op string, b *ComplexMatrix) error {
+	r1, c1 := cm.Dims()
+	r2, c2 := b.Dims()
+	if r1 != r2 || c1 != c2 {
+		return &DimensionError{Op: op, Row1: r1, Col1: c1, Row2: r2, Col2: c2}
+	}
+	return nil
+}
+
+// 矩阵相加 A + B
+func (cm *ComplexMatrix) Add(b *ComplexMatrix) (*ComplexMatrix, error) {
+	if err := cm.sameDims("相加", b); err != nil {
+		return nil, err
+	}
+	result := cm.Copy()
+	for i, row := range b.m {
+		for j, c := range row {
+			result.m[i][j] += c
+		}
+	}
+	return result, nil
+}
+
+// 矩阵相减 A - B
+func (cm *ComplexMatrix) Sub(b *ComplexMatrix) (*ComplexMatrix, error) {
+	if err := cm.sameDims("相减", b); err != nil {
+		return nil, err
+	}
+	result := cm.Copy()
+	for i, row := range b.m {
+		for j, c := range row {
+			result.m[i][j] -= c
+		}
+	}
+	return result, nil
+}
+
+// 数乘 kA
+func (cm *ComplexMatrix) Scale(k complex128) *ComplexMatrix {
+	result := cm.Copy()
+	for _, row := range result.m {
+		for j := range row {
+			row[j] *= k
+		}
+	}
+	return result
+}
+
+// 矩阵相乘 AB
+func (cm *ComplexMatrix) Mul(b *ComplexMatrix) (*ComplexMatrix, error) {
+	r1, c1 := cm.Dims()
+	r2, c2 := b.Dims()
+	if c1 != r2 {
+		return nil, &DimensionError{Op: "相乘", Row1: r1, Col1: c1, Row2: r2, Col2: c2}
+	}
+	result := NewComplexMatrix(r1, c2)
+	for i := 0; i < r1; i++ {
+		for k := 0; k < c1; k++ {
+			aik := cm.m[i][k]
+			if aik == 0 {
+				continue
+			}
+			for j := 0; j < c2; j++ {
+				result.m[i][j] += aik * b.m[k][j]
+			}
+		}
+	}
+	return result, nil
+}
+
+// 矩阵乘向量 Ax, 向量的下标从0开始
+func (cm *ComplexMatrix) MulVec(x []complex128) ([]complex128, error) {
+	row, col := cm.Dims()
+	if col != len(x) {
+		return nil, &DimensionError{Op: "乘向量", Row1: row, Col1: col, Row2: len(x), Col2: 1}
+	}
+	result := make([]complex128, row)
+	for i := 0; i < row; i++ {
+		sum := complex(0, 0)
+		for j := 0; j < col; j++ {
+			sum += cm.m[i][j] * x[j]
+		}
+		result[i] = sum
+	}
+	return result, nil
+}
+
+// 转置
+func (cm *ComplexMatrix) T() *ComplexMatrix {
+	row, col := cm.Dims()
+	result := NewComplexMatrix(col, row)
+	for i := 0; i < row; i++ {
+		for j := 0; j < col; j++ {
+			result.m[j][i] = cm.m[i][j]
+		}
+	}
+	return result
+}
+
+// 共轭转置
+func (cm *ComplexMatrix) H() *ComplexMatrix {
+	result := cm.T()
+	for _, row := range result.m {
+		for j := range row {
+			row[j] = cmplx.Conj(row[j])
+		}
+	}
+	return result
+}
+
+// 由给定的行和列组成的子矩阵, 行和列从1开始, 按给出的顺序排列
+func (cm *ComplexMatrix) Submatrix(rows, cols []int) (*ComplexMatrix, error) {
+	row, col := cm.Dims()
+	result := NewComplexMatrix(len(rows), len(cols))
+	for i, r := range rows {
+		if r < 1 || r > row {
+			return nil, &UnknownNodeError{Node: r}
+		}
+		for j, c := range cols {
+			if c < 1 || c > col {
+				return nil, &UnknownNodeError{Node: c}
+			}
+			result.m[i][j] = cm.m[r-1][c-1]
+		}
+	}
+	return result, nil
+}
+
+// 删去给定节点所在的行和列, 其余节点保持原来的顺序
+func (cm *ComplexMatrix) Without(nodes ...int) (*ComplexMatrix, error) {
+	row, _ := cm.Dims()
+	keep, err := remainingNodes(row, nodes)
+	if err != nil {
+		return nil, err
+	}
+	return cm.Submatrix(keep, keep)
+}
+
+// 1..n中除去给定节点后剩下的节点
+func remainingNodes(n int, nodes []int) ([]int, error) {
+	removed := make(map[int]bool, len(nodes))
+	for _, node := range nodes {
+		if node < 1 || node > n {
+			return nil, &UnknownNodeError{Node: node}
+		}
+		removed[node] = true
+	}
+	var keep []int
+	for i := 1; i <= n; i++ {
+		if !removed[i] {
+			keep = append(keep, i)
+		}
+	}
+	return keep, nil
+}
+
+// 逆矩阵, 用gonum的LU分解计算
+func (cm *ComplexMatrix) Inverse() (*ComplexMatrix, error) {
+	row, col := cm.Dims()
+	if row != col {
+		return nil, &DimensionError{Op: "求逆", Row1: row, Col1: col, Row2: col, Col2: row}
+	}
+	var solver GonumSolver
+	if err := solver.Factorize(cm); err != nil {
+		return nil, err
+	}
+	return solver.Inverse()
+}
+
+// Kron消去给定的节点: Y' = Ykk - Yke Yee^-1 Yek, 保留的节点保持原来的顺序
+func (cm *ComplexMatrix) Kron(eliminate ...int) (*ComplexMatrix, error) {
+	row, col := cm.Dims()
+	if row != col {
+		return nil, &DimensionError{Op: "Kron消去", Row1: row, Col1: col, Row2: col, Col2: row}
+	}
+	keep, err := remainingNodes(row, eliminate)
+	if err != nil {
+		return nil, err
+	}
+	if len(eliminate) == 0 {
+		return cm.Copy(), nil
+	}
+	Ykk, _ := cm.Submatrix(keep, keep)
+	Yke, _ := cm.Submatrix(keep, eliminate)
+	Yee, _ := cm.Submatrix(eliminate, eliminate)
+	Yek, _ := cm.Submatrix(eliminate, keep)
+	YeeInv, err := Yee.Inverse()
+	if err != nil {
+		return nil, err
+	}
+	YeeInvYek, _ := YeeInv.Mul(Yek)
+	reduced, _ := Yke.Mul(YeeInvYek)
+	return Ykk.Sub(reduced)
+}
+
+// 两个矩阵的维数相同且各元素之差的模不大于tolerance
+func (cm *ComplexMatrix) Equal(b *ComplexMatrix, tolerance float64) bool {
+	if cm.sameDims("比较", b) != nil {
+		return false
+	}
+	_, _, diff := cm.maxDiff(b)
+	return diff <= tolerance
+}
+
+// 两个同维矩阵之差的模最大的元素, 行和列从1开始
+func (cm *ComplexMatrix) maxDiff(b *ComplexMatrix) (row, col int, diff float64) {
+	for i := range cm.m {
+		for j := range cm.m[i] {
+			if d := cmplx.Abs(cm.m[i][j] - b.m[i][j]); d > diff || row == 0 {
+				row, col, diff = i+1, j+1, d
+			}
+		}
+	}
+	return row, col, diff
+}
+
+// 元素的最大模
+func (cm *ComplexMatrix) maxAbs() float64 {
+	max := 0.0
+	for _, row := range cm.m {
+		for _, c := range row {
+			max = math.Max(max, cmplx.Abs(c))
+		}
+	}
+	return max
+}
+
+// 矩阵的1-范数, 即各列元素模之和的最大值
+func (cm *ComplexMatrix) Norm1() float64 {
+	row, col := cm.Dims()
+	max := 0.0
+	for j := 0; j < col; j++ {
+		sum := 0.0
+		for i := 0; i < row; i++ {
+			sum += cmplx.Abs(cm.m[i][j])
+		}
+		max = math.Max(max, sum)
+	}
+	return max
+}
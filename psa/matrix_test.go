@@ -0,0 +1,99 @@
+package psa
+
+import (
+	"errors"
+	"testing"
+)
+
+func assertMatrix(t *testing.T, name string, got *ComplexMatrix, want [][]complex128) {
+	t.Helper()
+	if !got.Equal(NewComplexMatrixFrom(want), testTolerance) {
+		t.Errorf("%s = %v, want %v", name, got.Values(), want)
+	}
+}
+
+func TestMatrixArithmetic(t *testing.T) {
+	a := NewComplexMatrixFrom([][]complex128{{1, 2i}, {3, 4}})
+	b := NewComplexMatrixFrom([][]complex128{{1, 1}, {0, 1}})
+	sum, err := a.Add(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertMatrix(t, "A+B", sum, [][]complex128{{2, 1 + 2i}, {3, 5}})
+	difference, err := a.Sub(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertMatrix(t, "A-B", difference, [][]complex128{{0, -1 + 2i}, {3, 3}})
+	product, err := a.Mul(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertMatrix(t, "AB", product, [][]complex128{{1, 1 + 2i}, {3, 7}})
+	assertMatrix(t, "2A", a.Scale(2), [][]complex128{{2, 4i}, {6, 8}})
+	assertMatrix(t, "A^H", a.H(), [][]complex128{{1, 3}, {-2i, 4}})
+	assertMatrix(t, "A^T", a.T(), [][]complex128{{1, 3}, {2i, 4}})
+	// [1 2j; 3 4][1; j] = [1 - 2; 3 + 4j]
+	x, err := a.MulVec([]complex128{1, 1i})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertComplex(t, "x1", x[0], -1)
+	assertComplex(t, "x2", x[1], 3+4i)
+	// 各列模之和 4 和 6
+	assertFloat(t, "Norm1", a.Norm1(), 6, 1e-12)
+	// 运算不改变原矩阵
+	assertMatrix(t, "A", a, [][]complex128{{1, 2i}, {3, 4}})
+}
+
+func TestMatrixInverseAndSubmatrix(t *testing.T) {
+	a := NewComplexMatrixFrom([][]complex128{{2, 0}, {0, 4i}})
+	inverse, err := a.Inverse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertMatrix(t, "A^-1", inverse, [][]complex128{{0.5, 0}, {0, -0.25i}})
+	identity, err := a.Mul(inverse)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertMatrix(t, "A A^-1", identity, IdentityMatrix(2).Values())
+
+	c := NewComplexMatrixFrom([][]complex128{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}})
+	without, err := c.Without(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertMatrix(t, "C without 2", without, [][]complex128{{1, 3}, {7, 9}})
+	sub, err := c.Submatrix([]int{3, 1}, []int{2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertMatrix(t, "C[3 1; 2]", sub, [][]complex128{{8}, {2}})
+	var unknown *UnknownNodeError
+	if _, err := c.Submatrix([]int{4}, []int{1}); !errors.As(err, &unknown) || unknown.Node != 4 {
+		t.Errorf("Submatrix(4) = %v", err)
+	}
+}
+
+func TestMatrixDimensionErrors(t *testing.T) {
+	a := NewComplexMatrix(2, 2)
+	b := NewComplexMatrix(2, 3)
+	var dimension *DimensionError
+	if _, err := a.Add(b); !errors.As(err, &dimension) {
+		t.Errorf("2×2 + 2×3 = %v", err)
+	}
+	if _, err := b.Mul(a); !errors.As(err, &dimension) {
+		t.Errorf("2×3 * 2×2 = %v", err)
+	}
+	if _, err := b.Inverse(); !errors.As(err, &dimension) {
+		t.Errorf("inverse of 2×3 = %v", err)
+	}
+	if _, err := a.MulVec([]complex128{1}); err == nil {
+		t.Error("vector of the wrong length was accepted")
+	}
+	var singular *SingularMatrixError
+	if _, err := a.Inverse(); !errors.As(err, &singular) {
+		t.Errorf("inverse of the zero matrix = %v", err)
+	}
+}
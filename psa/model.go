@@ -0,0 +1,303 @@
+// Package psa 为各个实验提供公共的电网数据模型、数据文件的读写和短路计算
+package psa
+
+// 母线类型
+const (
+	BusPQ       = 1
+	BusPV       = 2
+	BusSlack    = 3
+	BusIsolated = 4
+)
+
+// 母线
+type Bus struct {
+	// 连续编号后的节点号
+	Node int `json:"node"`
+	// 数据文件中的原始母线号
+	Number int    `json:"number"`
+	Name   string `json:"name,omitempty"`
+	Type   int    `json:"type"`
+	// 基准电压(kV)
+	BaseKV float64 `json:"base_kv"`
+	// 电压幅值(标幺值)和相角(度)
+	V     float64 `json:"v"`
+	Angle float64 `json:"angle"`
+	// 负荷(MW, Mvar)
+	Pd float64 `json:"Pd"`
+	Qd float64 `json:"Qd"`
+	// 发电(MW, Mvar)
+	Pg float64 `json:"Pg"`
+	Qg float64 `json:"Qg"`
+	// 并联电导和电纳(标幺值)
+	Gs float64 `json:"Gs"`
+	Bs float64 `json:"Bs"`
+}
+
+// 标幺值表示的支路
+type Branch struct {
+	// 节点1
+	Node1 int `json:"node_1"`
+	// 节点2
+	Node2 int `json:"node_2"`
+	// 电阻
+	Resistance float64 `json:"resistance"`
+	// 电抗
+	Reactance float64 `json:"reactance"`
+	// 导纳(线路充电电纳的一半)
+	Admittance float64 `json:"admittance"`
+	// 变压器非标准变比, 位于节点1侧, 0表示线路
+	Ratio float64 `json:"ratio,omitempty"`
+	// 移相角(度), 各实验的导纳矩阵是对称的, 不计入移相角
+	Angle float64 `json:"angle,omitempty"`
+	// 长期、短期和紧急容量(MVA)
+	RateA float64 `json:"rate_a,omitempty"`
+	RateB float64 `json:"rate_b,omitempty"`
+	RateC float64 `json:"rate_c,omitempty"`
+	// 停运的支路不参与计算
+	OutOfService bool `json:"out_of_service,omitempty"`
+	// 电源支路的电势, 0表示不是电源
+	E float64 `json:"E,omitempty"`
+}
+
+// 发电机
+type PowerGenerator struct {
+	Node int     `json:"node"`
+	Sn   float64 `json:"Sn"`
+	// 次暂态电抗Xd'', 以Sn为基准的标幺值
+	Xd float64 `json:"xd"`
+	// 暂态电抗Xd'和同步电抗Xd, 只在计算短路电流随时间的变化时使用, 为0时不计该阶段的衰减
+	Xdp float64 `json:"xdp,omitempty"`
+	Xds float64 `json:"xds,omitempty"`
+	// 次暂态、暂态短路时间常数Td''、Td'和直流分量时间常数Ta(s)
+	Tdpp float64 `json:"Tdpp,omitempty"`
+	Tdp  float64 `json:"Tdp,omitempty"`
+	Ta   float64 `json:"Ta,omitempty"`
+	// 惯性时间常数H(s)和阻尼系数D, 以Sn为基准, 只在暂态稳定仿真中使用
+	H float64 `json:"H,omitempty"`
+	D float64 `json:"D,omitempty"`
+	// 如果Sn为0,则使用下面的参数计算
+	Pn  float64 `json:"Pn,omitempty"`
+	Cos float64 `json:"cos,omitempty"`
+	VB  float64 `json:"VB,omitempty"`
+	// 额定电压(kV), 只在IEC 60909的校正系数中使用, 为0时取VB
+	Vn float64 `json:"Vn,omitempty"`
+}
+
+// 线路, 参数为每公里的有名值
+type Circuit struct {
+	Node1 int     `json:"node_1"`
+	Node2 int     `json:"node_2"`
+	R     float64 `json:"r"`
+	X     float64 `json:"x"`
+	B     float64 `json:"b"`
+	L     float64 `json:"l"`
+	// 所在段的基准电压, 为0时使用Vav
+	VB float64 `json:"VB,omitempty"`
+}
+
+// 变压器
+type Transformer struct {
+	Node1 int     `json:"node_1"`
+	Node2 int     `json:"node_2"`
+	Sn    float64 `json:"Sn"`
+	Vs    float64 `json:"Vs"`
+	V1n   float64 `json:"V1n,omitempty"`
+	V2n   float64 `json:"V2n,omitempty"`
+	VB    float64 `json:"VB,omitempty"`
+	// 短路电压的电阻分量(%), 为0时不计绕组电阻
+	Vr float64 `json:"Vr,omitempty"`
+	// 节点1侧的非标准变比(标幺值)和移相角(度), 变比为0时为标准变比
+	Ratio float64 `json:"ratio,omitempty"`
+	Angle float64 `json:"angle,omitempty"`
+}
+
+// 系统电源, 以线路电抗表示系统阻抗
+type SG struct {
+	Node    int `json:"node"`
+	Circuit `json:"circuit"`
+}
+
+// 综合负荷
+type Ld struct {
+	Node int     `json:"node"`
+	Ld   float64 `json:"Ld"`
+	Xid  float64 `json:"Xid"`
+	VB   float64 `json:"VB,omitempty"`
+}
+
+// 异步电动机, 短路时以堵转阻抗后的电势motorE提供短路电流, 交流分量按时间常数T衰减到0
+type Motor struct {
+	Name string `json:"name,omitempty"`
+	Node int    `json:"node"`
+	// 额定输出功率(kW)
+	KW float64 `json:"kW"`
+	// 效率和功率因数
+	Efficiency float64 `json:"eff"`
+	Cos        float64 `json:"cos"`
+	// 堵转电流倍数 ILR/In
+	LockedRotor float64 `json:"ILR"`
+	// 堵转阻抗的X/R
+	XR float64 `json:"XR"`
+	// 同一母线上相同电动机的台数, 为0时取1
+	Count int `json:"count,omitempty"`
+	// 额定电压和所在段的基准电压(kV), 都给出时按电压比换算阻抗
+	Vn float64 `json:"Vn,omitempty"`
+	VB float64 `json:"VB,omitempty"`
+	// 交流分量衰减的时间常数(s), 为0时取defaultMotorT
+	T float64 `json:"T,omitempty"`
+	// 同步转速(r/min), 只在ANSI C37的电动机分类中使用, 为0时按1800及以下
+	RPM float64 `json:"rpm,omitempty"`
+}
+
+// 电动机组的额定输入容量(MVA) Count * P / (η cosφ)
+func (m Motor) Sn() float64 {
+	count := m.Count
+	if count == 0 {
+		count = 1
+	}
+	return float64(count) * m.KW / 1000 / (m.Efficiency * m.Cos)
+}
+
+// 断路器的额定值, 用于ANSI C37的开断能力校验
+type Breaker struct {
+	Name string `json:"name,omitempty"`
+	// 断路器所在节点
+	Node int `json:"node"`
+	// 额定电压(kV), 网络没有Vav时作为换算有名值的基准电压
+	KV float64 `json:"kv,omitempty"`
+	// 额定对称开断电流(kA)
+	InterruptingKA float64 `json:"interrupting_ka"`
+	// 额定关合电流峰值(kA), 为0时不校验
+	ClosingKA float64 `json:"closing_ka,omitempty"`
+	// 额定开断时间(周波): 2, 3, 5或8
+	Cycles float64 `json:"cycles"`
+}
+
+// 过电流继电器, 装在支路Node1-Node2的Node1一端, 保护该支路
+type Relay struct {
+	Name  string `json:"name,omitempty"`
+	Node1 int    `json:"node_1"`
+	Node2 int    `json:"node_2"`
+	// 电流互感器变比, 一次电流/二次电流
+	CT float64 `json:"ct"`
+	// 启动电流(A, 二次值)
+	Pickup float64 `json:"pickup"`
+	// 时间整定: IEC曲线为时间系数TMS, IEEE曲线为时间刻度TD
+	TimeDial float64 `json:"time_dial"`
+	// 反时限特性曲线, 见RelayCurves
+	Curve string `json:"curve"`
+	// 所在段的基准电压(kV), 为0时使用网络的Vav
+	KV float64 `json:"kv,omitempty"`
+}
+
+type PowerNetwork struct {
+	SB              float64          `json:"SB"`
+	Vav             float64          `json:"Vav,omitempty"`
+	SG              *SG              `json:"SG,omitempty"`
+	Lds             []Ld             `json:"lds,omitempty"`
+	Motors          []Motor          `json:"motors,omitempty"`
+	Buses           []Bus            `json:"buses,omitempty"`
+	Branches        []Branch         `json:"branches,omitempty"`
+	PowerGenerators []PowerGenerator `json:"power_generators,omitempty"`
+	Circuits        []Circuit        `json:"circuits,omitempty"`
+	Transformers    []Transformer    `json:"transformers,omitempty"`
+	Switches        []Switch         `json:"switches,omitempty"`
+	Breakers        []Breaker        `json:"breakers,omitempty"`
+	Relays          []Relay          `json:"relays,omitempty"`
+	DistanceRelays  []DistanceRelay  `json:"distance_relays,omitempty"`
+}
+
+// 正序、负序和零序网络, 各序网的支路已经是标幺值
+type SequenceNetwork struct {
+	// 正序
+	Grid1 []Branch `json:"grid1"`
+	F1    int      `json:"f1"`
+	// 负序
+	Grid2 []Branch `json:"grid2"`
+	F2    int      `json:"f2"`
+	// 零序
+	Grid0 []Branch `json:"grid0"`
+	F0    int      `json:"f0"`
+	// 正序和负序网络中的开关, 零序网络中的开关为空时与正序网络相同
+	Switches  []Switch `json:"switches,omitempty"`
+	Switches0 []Switch `json:"switches0,omitempty"`
+	// 距离继电器, 节点号为正序网络中的节点号
+	DistanceRelays []DistanceRelay `json:"distance_relays,omitempty"`
+}
+
+// 网络中出现的最大物理节点号, 建立Parser前据此限制矩阵的规模
+func (network PowerNetwork) MaxNode() int {
+	n := maxNode(network.Branches)
+	nodes := func(nodes ...int) {
+		for _, node := range nodes {
+			if node > n {
+				n = node
+			}
+		}
+	}
+	if network.SG != nil {
+		nodes(network.SG.Node)
+	}
+	for _, ld := range network.Lds {
+		nodes(ld.Node)
+	}
+	for _, motor := range network.Motors {
+		nodes(motor.Node)
+	}
+	for _, bus := range network.Buses {
+		nodes(bus.Node)
+	}
+	for _, generator := range network.PowerGenerators {
+		nodes(generator.Node)
+	}
+	for _, circuit := range network.Circuits {
+		nodes(circuit.Node1, circuit.Node2)
+	}
+	for _, transformer := range network.Transformers {
+		nodes(transformer.Node1, transformer.Node2)
+	}
+	for _, s := range network.Switches {
+		nodes(s.Node1, s.Node2)
+	}
+	for _, breaker := range network.Breakers {
+		nodes(breaker.Node)
+	}
+	for _, relay := range network.Relays {
+		nodes(relay.Node1, relay.Node2)
+	}
+	for _, relay := range network.DistanceRelays {
+		nodes(relay.Node1, relay.Node2, relay.Node01, relay.Node02)
+	}
+	return n
+}
+
+// 各序网络中的最大节点号
+func (network SequenceNetwork) MaxNode() int {
+	n := 0
+	for _, grid := range [][]Branch{network.Grid1, network.Grid2, network.Grid0} {
+		if m := maxNode(grid); m > n {
+			n = m
+		}
+	}
+	for _, relay := range network.DistanceRelays {
+		for _, node := range []int{relay.Node1, relay.Node2, relay.Node01, relay.Node02} {
+			if node > n {
+				n = node
+			}
+		}
+	}
+	for _, s := range append(network.Switches, network.Switches0...) {
+		for _, node := range []int{s.Node1, s.Node2} {
+			if node > n {
+				n = node
+			}
+		}
+	}
+	return n
+}
+
+// 不计电动机的网络, 用于计算最小短路电流
+func (network PowerNetwork) WithoutMotors() PowerNetwork {
+	network.Motors = nil
+	return network
+}
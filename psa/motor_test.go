@@ -0,0 +1,84 @@
+package psa
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+// 两台900kW、η=0.9、cosφ=0.8、ILR=6、X/R=10的6kV电动机接在6.3kV母线上
+func motorNetwork() PowerNetwork {
+	return PowerNetwork{
+		SB:       100,
+		Branches: []Branch{{Node1: 1, Reactance: 0.1, E: 1}},
+		Motors: []Motor{{
+			Node: 1, KW: 900, Efficiency: 0.9, Cos: 0.8, LockedRotor: 6, XR: 10, Count: 2, Vn: 6, VB: 6.3,
+		}},
+	}
+}
+
+func TestMotorBranch(t *testing.T) {
+	network := motorNetwork()
+	// Sn = 2 * 0.9MW/(0.9*0.8) = 2.5MVA
+	assertFloat(t, "Sn", network.Motors[0].Sn(), 2.5, 1e-12)
+	p, err := NewParser(network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources := p.Sources()
+	if len(sources) != 2 || sources[0].Name() != "M1" {
+		t.Fatalf("sources = %+v", sources)
+	}
+	m := sources[0]
+	// |z| = 1/6 * 100/2.5 * (6/6.3)², 按X/R = 10分为电阻和电抗
+	z := 100 / 6.0 / 2.5 * (6 / 6.3) * (6 / 6.3)
+	assertFloat(t, "|z|", cmplx.Abs(m.Z), z, 1e-9)
+	assertFloat(t, "X/R", imag(m.Z)/real(m.Z), 10, 1e-9)
+	assertFloat(t, "E", m.E, motorE, 0)
+}
+
+func TestMotorContribution(t *testing.T) {
+	p, err := NewParser(motorNetwork())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ComputeResult(); err != nil {
+		t.Fatal(err)
+	}
+	c, err := p.ComputeContributions(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 电动机直接接在短路点, 提供 0.9/z
+	m := c.Sources[0]
+	z := m.Z
+	assertComplex(t, "IM", m.I, 0.9/z)
+	assertComplex(t, "If", c.If, 1/0.1i+0.9/z)
+
+	// 电动机的交流分量按默认的0.02s衰减, 系统电源不衰减
+	trace, err := p.ComputeFaultTrace(1, TraceOptions{Duration: 0.01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFloat(t, "Td''", trace.Tdpp, defaultMotorT, 1e-12)
+	assertFloat(t, "I'", trace.Itransient, 10, 1e-9)
+	// 次暂态电流按短路前1∠0计算, 即 1/|0.1j // z|
+	assertFloat(t, "I''", trace.Isubtransient, 1/cmplx.Abs(c.Zff), 1e-9)
+
+	// 不计电动机时只剩系统电源
+	q, err := NewParser(motorNetwork().WithoutMotors())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.ComputeResult(); err != nil {
+		t.Fatal(err)
+	}
+	If, err := q.ComputeShortIf(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFloat(t, "|If| without motors", cmplx.Abs(If), 10, 1e-9)
+	if math.Abs(cmplx.Abs(c.If)-10) < 0.1 {
+		t.Errorf("motor contribution %v is too small to check", c.If)
+	}
+}
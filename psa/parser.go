@@ -0,0 +1,576 @@
+package psa
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// 将网络换算为标幺值支路并计算导纳矩阵和阻抗矩阵
+type Parser struct {
+	SB       float64
+	Vav      float64
+	network  PowerNetwork
+	branches []Branch
+	nodeNum  int
+	// 导纳矩阵
+	resultY *ComplexMatrix
+	// 阻抗矩阵
+	resultZ *ComplexMatrix
+	// 节点-断路器模型的拓扑
+	topology *Topology
+	// LDU分解时判断主元为0的相对容差, 以对角元的最大模为基准
+	PivotTolerance float64
+	// LDU分解时是否按对称主元选择交换行和列
+	Pivoting bool
+	// 主元选择后的节点顺序
+	perm []int
+	// 计算阻抗矩阵的求解器, 为nil时使用LDU和ComputeZ
+	Solver Solver
+	// 计算阻抗矩阵后是否用另一种求解器交叉验证, 默认由环境变量PSA_DEBUG决定
+	Verify bool
+	// 按IEC 60909建立网络时的参数
+	iec *IEC60909
+	// 系统电源和各发电机的电源支路在branches中的下标, 依次为SG(如果有)和各发电机
+	machineBranches []int
+	// 各电动机的电源支路在branches中的下标, 与network.Motors对应
+	motorBranches []int
+}
+
+// LDU分解默认的主元相对容差
+const DefaultPivotTolerance = 1e-12
+
+const (
+	// 异步电动机堵转阻抗后的电势
+	motorE = 0.9
+	// 异步电动机交流分量衰减的典型时间常数(s)
+	defaultMotorT = 0.02
+)
+
+// 检查网络数据后换算为标幺值支路
+func NewParser(network PowerNetwork) (*Parser, error) {
+	if err := network.Validate(); err != nil {
+		return nil, err
+	}
+	p := &Parser{}
+	p.network, p.topology = reduceTopology(network)
+	p.SB = network.SB
+	p.Vav = network.Vav
+	p.parsePowerNetwork()
+	p.init()
+	return p, nil
+}
+
+// 由已经是标幺值的支路构成的网络, 例如实验四的各序网络
+func NewBranchParser(branches []Branch) (*Parser, error) {
+	if len(branches) == 0 {
+		return nil, fmt.Errorf("网络中没有支路")
+	}
+	if err := validateBranches("支路", branches); err != nil {
+		return nil, err
+	}
+	p := &Parser{
+		// 支路同时作为网络数据保存, 以便按电源区分各支路
+		network:  PowerNetwork{Branches: branches},
+		branches: branches,
+		topology: newTopology(),
+	}
+	for _, branch := range branches {
+		p.topology.use(branch.Node1)
+		p.topology.use(branch.Node2)
+	}
+	p.topology.numberBuses()
+	p.init()
+	return p, nil
+}
+
+func (p *Parser) init() {
+	p.PivotTolerance = DefaultPivotTolerance
+	p.Verify = debugMode()
+	for i := 0; i < len(p.branches); i++ {
+		branch := p.branches[i]
+		if branch.Node1 > p.nodeNum {
+			p.nodeNum = branch.Node1
+		}
+		if branch.Node2 > p.nodeNum {
+			p.nodeNum = branch.Node2
+		}
+	}
+	p.resultY = NewComplexMatrix(p.nodeNum, p.nodeNum)
+}
+
+func (p *Parser) parsePowerNetwork() {
+	circuits := p.network.Circuits
+	generators := p.network.PowerGenerators
+	transformers := p.network.Transformers
+	lds := p.network.Lds
+	for _, branch := range p.network.Branches {
+		if !branch.OutOfService {
+			p.branches = append(p.branches, branch)
+		}
+	}
+	for _, bus := range p.network.Buses {
+		p.busShuntToBranch(bus)
+	}
+	// 系统母线经开关接地时不再有支路
+	if p.network.SG != nil && p.network.SG.Node != 0 {
+		p.sgArgsToBranch(*p.network.SG)
+	}
+	for i := 0; i < len(circuits); i++ {
+		p.circuitArgsToBranch(circuits[i])
+	}
+	for i := 0; i < len(generators); i++ {
+		p.powerGeneratorArgsToBranch(generators[i])
+	}
+	for i := 0; i < len(transformers); i++ {
+		p.transformerArgsToBranch(transformers[i])
+	}
+	for i := 0; i < len(lds); i++ {
+		p.ldArgsToBranch(lds[i])
+	}
+	for _, motor := range p.network.Motors {
+		p.motorBranches = append(p.motorBranches, len(p.branches))
+		p.branches = append(p.branches, p.motorBranch(motor))
+	}
+}
+
+// 母线的并联导纳作为接地支路
+func (p *Parser) busShuntToBranch(bus Bus) {
+	if bus.Gs == 0 && bus.Bs == 0 {
+		return
+	}
+	z := 1 / complex(bus.Gs, bus.Bs)
+	p.branches = append(p.branches, Branch{
+		Node1:      bus.Node,
+		Resistance: real(z),
+		Reactance:  imag(z),
+	})
+}
+
+func (p *Parser) sgArgsToBranch(sg SG) {
+	p.machineBranches = append(p.machineBranches, len(p.branches))
+	p.branches = append(p.branches, p.sgBranch(sg))
+}
+
+// 系统电源的电源支路, 电势为1
+func (p *Parser) sgBranch(sg SG) Branch {
+	circuit := sg.Circuit
+	branch := Branch{
+		Node1: sg.Node,
+		E:     1,
+	}
+	branch.Reactance = circuit.X * circuit.L * p.SB / (circuit.VB * circuit.VB)
+	return branch
+}
+
+func (p *Parser) circuitArgsToBranch(circuit Circuit) {
+	branch := Branch{
+		Node1: circuit.Node1,
+		Node2: circuit.Node2,
+	}
+	// 所在段没有给出基准电压时使用平均额定电压
+	vb := circuit.VB
+	if vb == 0 {
+		vb = p.Vav
+	}
+	// 计算电抗
+	branch.Resistance = circuit.R * circuit.L * p.SB / (vb * vb)
+	branch.Reactance = circuit.X * circuit.L * p.SB / (vb * vb)
+	branch.Admittance = 0.5 * circuit.B * circuit.L * vb * vb / p.SB
+	// 添加支路
+	p.branches = append(p.branches, branch)
+}
+
+func (p *Parser) powerGeneratorArgsToBranch(generator PowerGenerator) {
+	p.machineBranches = append(p.machineBranches, len(p.branches))
+	p.branches = append(p.branches, p.powerGeneratorBranch(generator))
+}
+
+// 发电机的电源支路, 电势为1
+func (p *Parser) powerGeneratorBranch(generator PowerGenerator) Branch {
+	branch := Branch{
+		Node1: generator.Node,
+		Node2: 0,
+		E:     1,
+	}
+	if generator.Sn == 0 {
+		generator.Sn = generator.Pn / generator.Cos
+	}
+	branch.Reactance = generator.Xd * p.SB / generator.Sn
+	return branch
+}
+
+func (p *Parser) transformerArgsToBranch(transformer Transformer) {
+	branch := Branch{
+		Node1: transformer.Node1,
+		Node2: transformer.Node2,
+		Ratio: transformer.Ratio,
+		Angle: transformer.Angle,
+	}
+	zb := p.SB / transformer.Sn
+	if transformer.V1n != 0 && transformer.VB != 0 {
+		// 按额定电压换算到所在段的基准电压
+		zb = (transformer.V1n * transformer.V1n / transformer.Sn) * (p.SB / (transformer.VB * transformer.VB))
+	}
+	// 短路电压为阻抗的模, 给出电阻分量时电抗为 √(Vs² - Vr²)
+	vx := transformer.Vs
+	if transformer.Vr != 0 {
+		vx = math.Sqrt(transformer.Vs*transformer.Vs - transformer.Vr*transformer.Vr)
+	}
+	branch.Resistance = (transformer.Vr / 100) * zb
+	branch.Reactance = (vx / 100) * zb
+	p.branches = append(p.branches, branch)
+}
+
+func (p *Parser) ldArgsToBranch(ld Ld) {
+	p.branches = append(p.branches, p.ldBranch(ld))
+}
+
+// 负荷(电动机)的电源支路, 电势为0.8
+func (p *Parser) ldBranch(ld Ld) Branch {
+	branch := Branch{
+		Node1: ld.Node,
+		Node2: 0,
+		E:     0.8,
+	}
+	branch.Reactance = ld.Xid * p.SB / ld.Ld
+	return branch
+}
+
+// 电动机的电源支路: 堵转阻抗 1/(ILR/In), 按X/R分为电阻和电抗, 电势为motorE
+func (p *Parser) motorBranch(motor Motor) Branch {
+	z := 1 / motor.LockedRotor * p.SB / motor.Sn()
+	if motor.Vn != 0 && motor.VB != 0 {
+		z *= motor.Vn * motor.Vn / (motor.VB * motor.VB)
+	}
+	x := z * motor.XR / math.Sqrt(1+motor.XR*motor.XR)
+	return Branch{
+		Node1:      motor.Node,
+		Node2:      0,
+		Resistance: x / motor.XR,
+		Reactance:  x,
+		E:          motorE,
+	}
+}
+
+// 计算节点导纳矩阵
+func (p *Parser) ComputeResultY() {
+	// 每次重新计算, 重复调用不会累加
+	p.resultY = NewComplexMatrix(p.nodeNum, p.nodeNum)
+	for i := 0; i < len(p.branches); i++ {
+		branch := p.branches[i]
+		if branch.Admittance != 0 {
+			p.resultY.m[branch.Node1-1][branch.Node1-1] += -complex(0, branch.Admittance)
+			p.resultY.m[branch.Node2-1][branch.Node2-1] += -complex(0, branch.Admittance)
+		}
+		if node, isGroundBranch := p.isGroundBranch(branch); isGroundBranch {
+			// 改变-yi0的值
+			if branch.Resistance != 0 || branch.Reactance != 0 {
+				p.resultY.m[node-1][node-1] += -1 / complex(branch.Resistance, branch.Reactance)
+			}
+		} else {
+			// 计算Yij
+			p.computeYij(branch)
+		}
+	}
+	for i := 1; i <= p.nodeNum; i++ {
+		p.computeYii(i)
+	}
+}
+
+func (p *Parser) isGroundBranch(branch Branch) (int, bool) {
+	if branch.Node1 == 0 {
+		return branch.Node2, true
+	} else if branch.Node2 == 0 {
+		return branch.Node1, true
+	}
+	return 0, false
+}
+
+func (p *Parser) computeYij(branch Branch) {
+	Yij := -1 / complex(branch.Resistance, branch.Reactance)
+	if branch.Ratio != 0 && branch.Ratio != 1 {
+		// 非标准变比变压器的π型等值电路, 两侧对地支路计入-yi0
+		k := complex(branch.Ratio, 0)
+		y := -Yij
+		p.resultY.m[branch.Node1-1][branch.Node1-1] += -y * (1 - k) / (k * k)
+		p.resultY.m[branch.Node2-1][branch.Node2-1] += -y * (k - 1) / k
+		Yij /= k
+	}
+	// 并联的支路导纳相加
+	p.resultY.m[branch.Node1-1][branch.Node2-1] += Yij
+	p.resultY.m[branch.Node2-1][branch.Node1-1] += Yij
+}
+
+func (p *Parser) computeYii(node int) {
+	Yii := complex(0, 0)
+	// Yii = -(-yi0 + Yi1 + Yi2 + ...)
+	for i := 0; i < p.nodeNum; i++ {
+		Yii -= p.resultY.m[node-1][i]
+	}
+	p.resultY.m[node-1][node-1] = Yii
+}
+
+// 计算导纳矩阵, 再由LDU分解计算阻抗矩阵
+func (p *Parser) ComputeResult() error {
+	p.ComputeResultY()
+	if p.Solver == nil {
+		l, d, u, err := p.LDU()
+		if err != nil {
+			return err
+		}
+		p.resultZ = p.ComputeZ(l, d, u)
+	} else {
+		if err := p.Solver.Factorize(p.resultY); err != nil {
+			return err
+		}
+		Z, err := p.Solver.Inverse()
+		if err != nil {
+			return err
+		}
+		p.resultZ = Z
+	}
+	if p.Verify {
+		return p.VerifyZ()
+	}
+	return nil
+}
+
+// 导纳矩阵的LDU分解, 主元的模不大于PivotTolerance乘以对角元的最大模时返回SingularMatrixError
+//
+// Pivoting为true时按对称主元选择交换行和列, 返回的L、D、U是重新排列后的导纳矩阵的分解,
+// 排列见Permutation, ComputeZ会把阻抗矩阵换回原来的节点顺序
+func (p *Parser) LDU() (l *ComplexMatrix, d *ComplexMatrix, u *ComplexMatrix, err error) {
+	L := NewComplexMatrix(p.nodeNum, p.nodeNum)
+	D := NewComplexMatrix(p.nodeNum, p.nodeNum)
+	U := NewComplexMatrix(p.nodeNum, p.nodeNum)
+	p.perm = make([]int, p.nodeNum)
+	for i := 1; i <= p.nodeNum; i++ {
+		p.perm[i-1] = i - 1
+	}
+	// 判断主元为0的阈值
+	tolerance := p.PivotTolerance * p.maxDiagonal()
+	// 设置L和U对角线上值为1
+	for i := 1; i <= p.nodeNum; i++ {
+		L.rcSet(i, i, 1)
+		U.rcSet(i, i, 1)
+	}
+	for i := 1; i <= p.nodeNum; i++ {
+		if p.Pivoting {
+			p.choosePivot(i, U, D)
+		}
+		// 设置dii
+		Uki2Dkk := complex(0, 0)
+		for k := 1; k <= i-1; k++ {
+			Uki2Dkk += U.rcAt(k, i) * U.rcAt(k, i) * D.rcAt(k, k)
+		}
+		aii := p.pivotedY(i, i)
+		D.rcSet(i, i, aii-Uki2Dkk)
+		if dii := D.rcAt(i, i); cmplx.Abs(dii) <= tolerance || cmplx.IsNaN(dii) {
+			return nil, nil, nil, &SingularMatrixError{Pivot: i, Node: p.perm[i-1] + 1}
+		}
+
+		// 设置uij,(i = 1, 2, ..., n-1    j = i + 1, ..., n)
+		if i != p.nodeNum {
+			for j := i + 1; j <= p.nodeNum; j++ {
+				UkiUkjDkk := complex(0, 0)
+				for k := 1; k <= i-1; k++ {
+					UkiUkjDkk += U.rcAt(k, i) * U.rcAt(k, j) * D.rcAt(k, k)
+				}
+				aij := p.pivotedY(i, j)
+				dii := D.rcAt(i, i)
+				U.rcSet(i, j, (aij-UkiUkjDkk)/dii)
+			}
+		}
+
+		// lij的计算从i=2开始
+		if i == 1 {
+			continue
+		}
+		// 设置lij(i = 2, 3, ..., n   j = 1, 2, ..., i-1)
+		for j := 1; j <= i-1; j++ {
+			LikLjkDkk := complex(0, 0)
+			for k := 1; k <= j-1; k++ {
+				LikLjkDkk += L.rcAt(i, k) * L.rcAt(j, k) * D.rcAt(k, k)
+			}
+			aij := p.pivotedY(i, j)
+			djj := D.rcAt(j, j)
+			L.rcSet(i, j, (aij-LikLjkDkk)/djj)
+		}
+	}
+	return L, D, U, nil
+}
+
+// 重新排列后的导纳矩阵的元素, 行和列从1开始
+func (p *Parser) pivotedY(i, j int) complex128 {
+	return p.resultY.m[p.perm[i-1]][p.perm[j-1]]
+}
+
+func (p *Parser) maxDiagonal() float64 {
+	max := 0.0
+	for i := 0; i < p.nodeNum; i++ {
+		max = math.Max(max, cmplx.Abs(p.resultY.m[i][i]))
+	}
+	return max
+}
+
+// 在剩下的节点中选择消去后对角元的模最大的作为第i个主元, 并交换U中已经计算的列
+func (p *Parser) choosePivot(i int, U, D *ComplexMatrix) {
+	best, bestAbs := i, -1.0
+	for m := i; m <= p.nodeNum; m++ {
+		dmm := p.pivotedY(m, m)
+		for k := 1; k <= i-1; k++ {
+			dmm -= U.rcAt(k, m) * U.rcAt(k, m) * D.rcAt(k, k)
+		}
+		if cmplx.Abs(dmm) > bestAbs {
+			best, bestAbs = m, cmplx.Abs(dmm)
+		}
+	}
+	if best == i {
+		return
+	}
+	p.perm[i-1], p.perm[best-1] = p.perm[best-1], p.perm[i-1]
+	for k := 1; k <= i-1; k++ {
+		uki, ukm := U.rcAt(k, i), U.rcAt(k, best)
+		U.rcSet(k, i, ukm)
+		U.rcSet(k, best, uki)
+	}
+}
+
+// 主元选择后的节点顺序, 第i个主元对应节点Permutation()[i-1]+1
+func (p *Parser) Permutation() []int {
+	return p.perm
+}
+
+// 由LDU分解逐列计算阻抗矩阵, 启用主元选择时换回原来的节点顺序
+func (p *Parser) ComputeZ(l, d, u *ComplexMatrix) *ComplexMatrix {
+	Z := NewComplexMatrix(p.nodeNum, p.nodeNum)
+	for j := 1; j <= p.nodeNum; j++ {
+		p.computeZj(j, l, d, u, Z)
+	}
+	if p.perm != nil {
+		pivoted := Z
+		Z = NewComplexMatrix(p.nodeNum, p.nodeNum)
+		for i := 0; i < p.nodeNum; i++ {
+			for j := 0; j < p.nodeNum; j++ {
+				Z.m[p.perm[i]][p.perm[j]] = pivoted.m[i][j]
+			}
+		}
+	}
+	p.resultZ = Z
+	return Z
+}
+
+// 1-范数条件数 cond(Y) = ||Y||1 * ||Z||1, Z为Y的逆, 需要先计算阻抗矩阵
+func (p *Parser) ConditionNumber() (float64, error) {
+	if p.resultZ == nil {
+		return 0, ErrNotComputed
+	}
+	return p.resultY.Norm1() * p.resultZ.Norm1(), nil
+}
+
+func (p *Parser) computeZj(j int, l, d, u, Z *ComplexMatrix) {
+	length := p.nodeNum
+	f := NewComplexMatrix(1, length)
+	h := NewComplexMatrix(1, length)
+	for i := 1; i <= length; i++ {
+		if i < j {
+			f.rcSet(1, i, 0)
+		} else if i == j {
+			f.rcSet(1, i, 1)
+		} else {
+			sum := complex(0, 0)
+			for k := j; k <= i-1; k++ {
+				sum -= l.rcAt(i, k) * f.rcAt(1, k)
+			}
+			f.rcSet(1, i, sum)
+		}
+	}
+	for i := 1; i <= length; i++ {
+		if i < j {
+			h.rcSet(1, i, 0)
+		} else {
+			h.rcSet(1, i, f.rcAt(1, i)/d.rcAt(i, i))
+		}
+	}
+	for i := length; i >= 1; i-- {
+		sumUikZkj := complex(0, 0)
+		for k := i + 1; k <= length; k++ {
+			sumUikZkj += u.rcAt(i, k) * Z.rcAt(k, j)
+		}
+		Z.rcSet(i, j, h.rcAt(1, i)-sumUikZkj)
+	}
+}
+
+// 节点(母线)数
+func (p *Parser) NodeNum() int {
+	return p.nodeNum
+}
+
+// 换算后的标幺值支路
+func (p *Parser) Branches() []Branch {
+	return p.branches
+}
+
+// 开关化简后的网络, 节点号已经换算为母线号
+func (p *Parser) Network() PowerNetwork {
+	return p.network
+}
+
+// 导出用的网络: 支路为换算后的各标幺值支路, 母线数据按母线合并,
+// 并联导纳已经作为接地支路, 母线中不再重复给出; 没有母线数据的母线作为PQ节点
+func (p *Parser) ExportNetwork() PowerNetwork {
+	network := PowerNetwork{SB: p.SB, Vav: p.Vav, Branches: p.branches}
+	if len(p.network.Buses) == 0 {
+		return network
+	}
+	buses := make([]Bus, p.nodeNum)
+	for i := range buses {
+		buses[i] = Bus{Node: i + 1, Type: BusPQ}
+	}
+	numbers := map[int]bool{}
+	for _, bus := range p.network.Buses {
+		merged := &buses[bus.Node-1]
+		// 拓扑合并到同一母线时取第一条母线的编号和电压, 平衡节点优先于PV节点
+		if merged.Number == 0 {
+			merged.Number, merged.Name, merged.BaseKV = bus.Number, bus.Name, bus.BaseKV
+		}
+		if merged.V == 0 {
+			merged.V, merged.Angle = bus.V, bus.Angle
+		}
+		if bus.Type == BusSlack || (bus.Type == BusPV && merged.Type != BusSlack) {
+			merged.Type = bus.Type
+		}
+		merged.Pd += bus.Pd
+		merged.Qd += bus.Qd
+		merged.Pg += bus.Pg
+		merged.Qg += bus.Qg
+	}
+	// 原始母线号有缺失或重复时改用母线号, 以免导出的母线编号冲突
+	unique := true
+	for _, bus := range buses {
+		if bus.Number == 0 || numbers[bus.Number] {
+			unique = false
+		}
+		numbers[bus.Number] = true
+	}
+	for i := range buses {
+		if !unique {
+			buses[i].Number = 0
+		}
+	}
+	network.Buses = buses
+	return network
+}
+
+func (p *Parser) ResultY() *ComplexMatrix {
+	return p.resultY
+}
+
+func (p *Parser) ResultZ() *ComplexMatrix {
+	return p.resultZ
+}
+
+func (p *Parser) Topology() *Topology {
+	return p.topology
+}
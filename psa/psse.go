@@ -0,0 +1,610 @@
+package psa
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// PSS/E RAW文件中读取的数据段
+const (
+	rawBus         = "BUS"
+	rawLoad        = "LOAD"
+	rawFixedShunt  = "FIXED SHUNT"
+	rawGenerator   = "GENERATOR"
+	rawBranch      = "BRANCH"
+	rawTransformer = "TRANSFORMER"
+	// 版本35的系统数据段, 不需要
+	rawSystem = "SYSTEM-WIDE"
+)
+
+// 没有"BEGIN xxx DATA"注释时数据段的默认顺序
+var rawSections = []string{rawBus, rawLoad, rawFixedShunt, rawGenerator, rawBranch, rawTransformer}
+
+// 读取PSS/E RAW文件(版本33及以上)的母线、负荷、固定并联、发电机、线路和变压器数据
+//
+// 线路、变压器和发电机换算为各实验使用的元件: 线路长度取1km, 各线路按同一平均电压Vav
+// 折算为有名值; 变压器按系统基准容量给出短路电压, 计入绕组电阻、非标准变比和移相角,
+// 不计励磁支路; 三绕组变压器化为星形等值电路, 星形中点编号在所有母线之后.
+// 线路和发电机记录的字段按第1行的版本号区分, 没有版本号时按版本33读取.
+func ReadRaw(r io.Reader) (*PowerNetwork, error) {
+	reader := &rawReader{scanner: bufio.NewScanner(r), nodes: map[int]int{}, version: 33}
+	network := &PowerNetwork{}
+	header, err := reader.record()
+	if err != nil {
+		return nil, fmt.Errorf("raw: %v", err)
+	}
+	if len(header) < 3 {
+		return nil, fmt.Errorf("raw: 第1行的字段不足")
+	}
+	if network.SB, err = strconv.ParseFloat(header[1], 64); err != nil {
+		return nil, fmt.Errorf("raw: 基准容量: %v", err)
+	}
+	if version, err := strconv.ParseFloat(header[2], 64); err == nil {
+		if version < 33 {
+			return nil, fmt.Errorf("raw: 不支持版本%g, 需要33及以上", version)
+		}
+		reader.version = version
+	}
+	// 第2、3行是算例说明
+	reader.line()
+	reader.line()
+
+	section := rawBus
+	next := 1
+	for {
+		line, ok := reader.line()
+		if !ok {
+			break
+		}
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "Q") {
+			break
+		}
+		// 版本35在母线数据之前有以字母开头的系统数据段
+		if section == rawBus && len(network.Buses) == 0 && trimmed != "" && !isDigitStart(trimmed) {
+			section = rawSystem
+			continue
+		}
+		fields := splitRawRecord(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "0" {
+			section, next = nextRawSection(line, section, next)
+			continue
+		}
+		if section == rawSystem {
+			continue
+		}
+		switch section {
+		case rawBus:
+			err = network.readRawBus(fields, reader.nodes)
+		case rawLoad:
+			err = network.readRawLoad(fields, reader.nodes)
+		case rawFixedShunt:
+			err = network.readRawFixedShunt(fields, reader.nodes)
+		case rawGenerator:
+			err = network.readRawGenerator(fields, reader)
+		case rawBranch:
+			err = network.readRawBranch(fields, reader)
+		case rawTransformer:
+			err = network.readRawTransformer(fields, reader)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("raw第%d行: %v", reader.lineNum, err)
+		}
+	}
+	if err := reader.scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(network.Buses) == 0 {
+		return nil, fmt.Errorf("raw文件中没有母线数据")
+	}
+	return network, nil
+}
+
+type rawReader struct {
+	scanner *bufio.Scanner
+	lineNum int
+	// 原始母线号 -> 节点号
+	nodes map[int]int
+	// 第1行给出的版本号
+	version float64
+}
+
+func (reader *rawReader) line() (string, bool) {
+	if !reader.scanner.Scan() {
+		return "", false
+	}
+	reader.lineNum++
+	return reader.scanner.Text(), true
+}
+
+func (reader *rawReader) record() ([]string, error) {
+	line, ok := reader.line()
+	if !ok {
+		return nil, fmt.Errorf("文件在第%d行后意外结束", reader.lineNum)
+	}
+	return splitRawRecord(line), nil
+}
+
+// 由"0 / END OF xxx DATA, BEGIN yyy DATA"确定下一个数据段, 不认识的数据段为空, 其中的记录跳过;
+// 没有注释时按默认顺序
+func nextRawSection(line string, section string, next int) (string, int) {
+	upper := strings.ToUpper(line)
+	if i := strings.Index(upper, "BEGIN "); i >= 0 {
+		name := strings.TrimSpace(upper[i+len("BEGIN "):])
+		name = strings.TrimSpace(strings.TrimSuffix(name, "DATA"))
+		for j, s := range rawSections {
+			if s == name {
+				return s, j + 1
+			}
+		}
+		return "", next
+	}
+	if section == rawSystem {
+		return rawBus, 1
+	}
+	if next < len(rawSections) {
+		return rawSections[next], next + 1
+	}
+	return "", next
+}
+
+func isDigitStart(s string) bool {
+	return s[0] == '-' || (s[0] >= '0' && s[0] <= '9')
+}
+
+// 按逗号或空白分隔字段, 单引号内的内容作为一个字段, "/"之后为注释
+func splitRawRecord(line string) []string {
+	var fields []string
+	var builder strings.Builder
+	inQuote := false
+	hasField := false
+	flush := func() {
+		if hasField {
+			fields = append(fields, strings.TrimSpace(builder.String()))
+		}
+		builder.Reset()
+		hasField = false
+	}
+	for _, c := range line {
+		switch {
+		case c == '\'':
+			inQuote = !inQuote
+			hasField = true
+		case inQuote:
+			builder.WriteRune(c)
+		case c == '/':
+			flush()
+			return fields
+		case c == ',':
+			// 连续的逗号表示使用默认值的空字段
+			hasField = true
+			flush()
+		case c == ' ' || c == '\t':
+			flush()
+		default:
+			builder.WriteRune(c)
+			hasField = true
+		}
+	}
+	flush()
+	return fields
+}
+
+// 取第i个字段的数值, 字段缺省或为空时返回默认值
+func rawFloat(fields []string, i int, defaultValue float64) (float64, error) {
+	if i >= len(fields) || fields[i] == "" {
+		return defaultValue, nil
+	}
+	v, err := strconv.ParseFloat(fields[i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("第%d个字段: %v", i+1, err)
+	}
+	return v, nil
+}
+
+func rawFloats(fields []string, defaults ...float64) ([]float64, error) {
+	values := make([]float64, len(defaults))
+	for i, defaultValue := range defaults {
+		v, err := rawFloat(fields, i, defaultValue)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// 将名称等文本字段置空后再按数值读取
+func blank(fields []string, indices ...int) []string {
+	numeric := make([]string, len(fields))
+	copy(numeric, fields)
+	for _, i := range indices {
+		if i < len(numeric) {
+			numeric[i] = ""
+		}
+	}
+	return numeric
+}
+
+func rawNode(nodes map[int]int, field string) (int, error) {
+	number, err := strconv.Atoi(field)
+	if err != nil {
+		return 0, fmt.Errorf("母线号: %v", err)
+	}
+	// 负号表示计量端, 不影响连接关系
+	if number < 0 {
+		number = -number
+	}
+	node, exist := nodes[number]
+	if !exist {
+		return 0, fmt.Errorf("母线%d不存在", number)
+	}
+	return node, nil
+}
+
+// I, 'NAME', BASKV, IDE, AREA, ZONE, OWNER, VM, VA, ...
+func (network *PowerNetwork) readRawBus(fields []string, nodes map[int]int) error {
+	number, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return fmt.Errorf("母线号: %v", err)
+	}
+	if _, exist := nodes[number]; exist {
+		return fmt.Errorf("母线%d重复", number)
+	}
+	values, err := rawFloats(blank(fields, 1), 0, 0, 0, 1, 1, 1, 1, 1, 0)
+	if err != nil {
+		return err
+	}
+	bus := Bus{
+		Node:   len(network.Buses) + 1,
+		Number: number,
+		BaseKV: values[2],
+		Type:   int(values[3]),
+		V:      values[7],
+		Angle:  values[8],
+	}
+	if len(fields) > 1 {
+		bus.Name = fields[1]
+	}
+	if network.Vav == 0 && bus.BaseKV != 0 {
+		network.Vav = bus.BaseKV
+	}
+	nodes[number] = bus.Node
+	network.Buses = append(network.Buses, bus)
+	return nil
+}
+
+// I, 'ID', STATUS, AREA, ZONE, PL, QL, IP, IQ, YP, YQ, ...
+// 恒电流负荷按额定电压计入, 恒导纳负荷计入母线并联导纳;
+// YP、YQ为1pu电压下的功率, YQ对感性负荷为负, 与母线并联电纳的符号相同
+func (network *PowerNetwork) readRawLoad(fields []string, nodes map[int]int) error {
+	node, err := rawNode(nodes, fields[0])
+	if err != nil {
+		return err
+	}
+	values, err := rawFloats(blank(fields, 1), 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0)
+	if err != nil {
+		return err
+	}
+	if values[2] == 0 {
+		return nil
+	}
+	bus := &network.Buses[node-1]
+	bus.Pd += values[5] + values[7]
+	bus.Qd += values[6] + values[8]
+	bus.Gs += values[9] / network.SB
+	bus.Bs += values[10] / network.SB
+	return nil
+}
+
+// I, 'ID', STATUS, GL, BL
+func (network *PowerNetwork) readRawFixedShunt(fields []string, nodes map[int]int) error {
+	node, err := rawNode(nodes, fields[0])
+	if err != nil {
+		return err
+	}
+	values, err := rawFloats(blank(fields, 1), 0, 0, 1, 0, 0)
+	if err != nil {
+		return err
+	}
+	if values[2] == 0 {
+		return nil
+	}
+	network.Buses[node-1].Gs += values[3] / network.SB
+	network.Buses[node-1].Bs += values[4] / network.SB
+	return nil
+}
+
+// I, 'ID', PG, QG, QT, QB, VS, IREG, MBASE, ZR, ZX, RT, XT, GTAP, STAT, ...
+// 版本35在IREG之后多了NREG; 发电机以机端电抗ZX(MBASE为基准)作为Xd
+func (network *PowerNetwork) readRawGenerator(fields []string, reader *rawReader) error {
+	node, err := rawNode(reader.nodes, fields[0])
+	if err != nil {
+		return err
+	}
+	if reader.version >= 35 && len(fields) > 8 {
+		fields = append(append([]string(nil), fields[:8]...), fields[9:]...)
+	}
+	values, err := rawFloats(blank(fields, 1), 0, 0, 0, 0, 9999, -9999, 1, 0, network.SB, 0, 1, 0, 0, 1, 1)
+	if err != nil {
+		return err
+	}
+	if values[14] == 0 {
+		return nil
+	}
+	bus := &network.Buses[node-1]
+	bus.Pg += values[2]
+	bus.Qg += values[3]
+	if values[10] == 0 {
+		return fmt.Errorf("发电机的电抗ZX为0")
+	}
+	network.PowerGenerators = append(network.PowerGenerators, PowerGenerator{
+		Node: node,
+		Sn:   values[8],
+		Xd:   values[10],
+	})
+	return nil
+}
+
+// 版本33: I, J, 'CKT', R, X, B, RATEA, RATEB, RATEC, GI, BI, GJ, BJ, ST, ...
+// 版本34、35: I, J, 'CKT', R, X, B, 'NAME', RATE1, ..., RATE12, GI, BI, GJ, BJ, ST, ...
+func (network *PowerNetwork) readRawBranch(fields []string, reader *rawReader) error {
+	if len(fields) < 6 {
+		return fmt.Errorf("线路的字段不足")
+	}
+	node1, err := rawNode(reader.nodes, fields[0])
+	if err != nil {
+		return err
+	}
+	node2, err := rawNode(reader.nodes, fields[1])
+	if err != nil {
+		return err
+	}
+	// GI在R之后的位置
+	gi := 6
+	rest := fields[3:]
+	if reader.version >= 34 {
+		rest = blank(rest, 3)
+		gi = 16
+	}
+	values, err := rawFloats(rest, 0, 0, 0)
+	if err != nil {
+		return err
+	}
+	shunts := make([]float64, 5)
+	for k, defaultValue := range []float64{0, 0, 0, 0, 1} {
+		if shunts[k], err = rawFloat(rest, gi+k, defaultValue); err != nil {
+			return err
+		}
+	}
+	if shunts[4] == 0 {
+		return nil
+	}
+	circuit, err := network.perUnitCircuit(node1, node2, values[0], values[1], values[2])
+	if err != nil {
+		return err
+	}
+	bus1 := &network.Buses[node1-1]
+	bus2 := &network.Buses[node2-1]
+	bus1.Gs += shunts[0]
+	bus1.Bs += shunts[1]
+	bus2.Gs += shunts[2]
+	bus2.Bs += shunts[3]
+	network.Circuits = append(network.Circuits, circuit)
+	return nil
+}
+
+// 以Vav为基准电压将标幺值参数折算为长度1km的线路
+func (network *PowerNetwork) perUnitCircuit(node1, node2 int, r, x, b float64) (Circuit, error) {
+	if network.Vav == 0 {
+		return Circuit{}, fmt.Errorf("母线数据中没有基准电压BASKV, 不能折算线路参数")
+	}
+	zb := network.Vav * network.Vav / network.SB
+	return Circuit{
+		Node1: node1,
+		Node2: node2,
+		R:     r * zb,
+		X:     x * zb,
+		B:     b / zb,
+		L:     1,
+		VB:    network.Vav,
+	}, nil
+}
+
+// 以系统基准容量给出的电阻和电抗换算为变压器, ratio为节点1侧的非标准变比, angle为移相角(度);
+// 三绕组变压器星形等值的绕组电阻或电抗可能为负, 不能用短路电压表示, 直接作为标幺值支路
+func (network *PowerNetwork) addRawTransformer(node1, node2 int, r, x, ratio, angle float64) {
+	if r < 0 || x < 0 {
+		branch := Branch{Node1: node1, Node2: node2, Resistance: r, Reactance: x, Angle: angle}
+		if ratio != 1 {
+			branch.Ratio = ratio
+		}
+		network.Branches = append(network.Branches, branch)
+		return
+	}
+	network.Transformers = append(network.Transformers, network.perUnitTransformer(node1, node2, r, x, ratio, angle))
+}
+
+func (network *PowerNetwork) perUnitTransformer(node1, node2 int, r, x, ratio, angle float64) Transformer {
+	transformer := Transformer{
+		Node1: node1,
+		Node2: node2,
+		Sn:    network.SB,
+		Vs:    100 * math.Hypot(r, x),
+		Vr:    100 * r,
+		Angle: angle,
+		V1n:   network.Vav,
+		V2n:   network.Vav,
+		VB:    network.Vav,
+	}
+	if ratio != 1 {
+		transformer.Ratio = ratio
+	}
+	return transformer
+}
+
+// 第1行: I, J, K, 'CKT', CW, CZ, CM, MAG1, MAG2, NMETR, 'NAME', STAT, ...
+// 第2行: R1-2, X1-2, SBASE1-2[, R2-3, X2-3, SBASE2-3, R3-1, X3-1, SBASE3-1, ...]
+// 之后每个绕组一行, 两绕组变压器共4行, 三绕组变压器共5行
+func (network *PowerNetwork) readRawTransformer(fields []string, reader *rawReader) error {
+	if len(fields) < 3 {
+		return fmt.Errorf("变压器的字段不足")
+	}
+	threeWinding := fields[2] != "0"
+	lineCount := 3
+	if threeWinding {
+		lineCount = 4
+	}
+	var lines [][]string
+	for i := 0; i < lineCount; i++ {
+		record, err := reader.record()
+		if err != nil {
+			return err
+		}
+		lines = append(lines, record)
+	}
+	values, err := rawFloats(blank(fields, 3, 10), 0, 0, 0, 0, 1, 1, 1, 0, 0, 0, 0, 1)
+	if err != nil {
+		return err
+	}
+	cw, cz := int(values[4]), int(values[5])
+	status := int(values[11])
+	if status == 0 {
+		return nil
+	}
+	nodes := []int{0, 0, 0}
+	for i := range nodes {
+		if i == 2 && !threeWinding {
+			break
+		}
+		if nodes[i], err = rawNode(reader.nodes, fields[i]); err != nil {
+			return err
+		}
+	}
+	// 各绕组一行: WINDV, NOMV, ANG, ...
+	windings := make([]rawWinding, 0, 3)
+	for i, line := range lines[1:] {
+		w, err := rawFloats(line, 1, 0, 0)
+		if err != nil {
+			return err
+		}
+		winding := rawWinding{node: nodes[i], nomv: w[1], angle: w[2]}
+		if winding.ratio, err = network.rawWindingRatio(cw, winding.node, w[0], w[1]); err != nil {
+			return err
+		}
+		windings = append(windings, winding)
+	}
+	z, err := rawFloats(lines[0], 0, 0, network.SB, 0, 0, network.SB, 0, 0, network.SB)
+	if err != nil {
+		return err
+	}
+	r12, x12, err := network.rawTransformerZ(cz, z[0], z[1], z[2], windings[0])
+	if err != nil {
+		return err
+	}
+	if !threeWinding {
+		// 两侧的变比折算到节点1侧, 移相角为绕组1的ANG1
+		ratio := windings[0].ratio / windings[1].ratio
+		network.addRawTransformer(nodes[0], nodes[1], r12, x12, ratio, windings[0].angle)
+		return nil
+	}
+
+	r23, x23, err := network.rawTransformerZ(cz, z[3], z[4], z[5], windings[1])
+	if err != nil {
+		return err
+	}
+	r31, x31, err := network.rawTransformerZ(cz, z[6], z[7], z[8], windings[2])
+	if err != nil {
+		return err
+	}
+	// 星形等值电路, 中点为新增的节点, 各绕组的变比和移相角位于绕组所在母线一侧
+	star := len(network.Buses) + 1
+	for _, transformer := range network.Transformers {
+		if transformer.Node2 >= star {
+			star = transformer.Node2 + 1
+		}
+	}
+	for _, branch := range network.Branches {
+		if branch.Node2 >= star {
+			star = branch.Node2 + 1
+		}
+	}
+	branches := []struct {
+		r, x float64
+		// STAT为4时绕组1停运, 为2时绕组2停运, 为3时绕组3停运
+		status int
+	}{
+		{(r12 + r31 - r23) / 2, (x12 + x31 - x23) / 2, 4},
+		{(r12 + r23 - r31) / 2, (x12 + x23 - x31) / 2, 2},
+		{(r23 + r31 - r12) / 2, (x23 + x31 - x12) / 2, 3},
+	}
+	for i, branch := range branches {
+		if status == branch.status {
+			continue
+		}
+		w := windings[i]
+		network.addRawTransformer(w.node, star, branch.r, branch.x, w.ratio, w.angle)
+	}
+	return nil
+}
+
+// 变压器一个绕组的数据, ratio为换算到母线基准电压的标幺变比
+type rawWinding struct {
+	node        int
+	ratio       float64
+	nomv, angle float64
+}
+
+// 按CW将绕组的WINDV换算为母线基准电压下的标幺变比
+// CW=1: 母线基准电压的标幺值; CW=2: 绕组电压(kV); CW=3: 绕组额定电压NOMV的标幺值
+func (network *PowerNetwork) rawWindingRatio(cw, node int, windv, nomv float64) (float64, error) {
+	baseKV := network.Buses[node-1].BaseKV
+	switch cw {
+	case 1:
+		return windv, nil
+	case 2:
+		if baseKV == 0 {
+			return 0, fmt.Errorf("CW=2时母线需要给出基准电压BASKV")
+		}
+		return windv / baseKV, nil
+	case 3:
+		if nomv == 0 {
+			return windv, nil
+		}
+		if baseKV == 0 {
+			return 0, fmt.Errorf("CW=3时母线需要给出基准电压BASKV")
+		}
+		return windv * nomv / baseKV, nil
+	}
+	return 0, fmt.Errorf("不支持的CW=%d", cw)
+}
+
+// 按CZ将绕组间的阻抗换算为系统基准容量下的电阻和电抗
+// CZ=1: 系统基准的标幺值; CZ=2: 绕组基准容量和额定电压的标幺值; CZ=3: 负载损耗(W)和阻抗模值
+func (network *PowerNetwork) rawTransformerZ(cz int, r, x, sbase float64, winding rawWinding) (float64, float64, error) {
+	// 绕组额定电压与母线基准电压不同时, 绕组基准的标幺值还要按电压平方折算
+	scale := network.SB / sbase
+	if baseKV := network.Buses[winding.node-1].BaseKV; winding.nomv != 0 && baseKV != 0 {
+		scale *= winding.nomv * winding.nomv / (baseKV * baseKV)
+	}
+	switch cz {
+	case 1:
+		return r, x, nil
+	case 2:
+		return r * scale, x * scale, nil
+	case 3:
+		rpu := r / (1e6 * sbase)
+		if x < rpu {
+			return 0, 0, fmt.Errorf("变压器的阻抗小于电阻")
+		}
+		return rpu * scale, math.Sqrt(x*x-rpu*rpu) * scale, nil
+	}
+	return 0, 0, fmt.Errorf("不支持的CZ=%d", cz)
+}
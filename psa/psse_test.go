@@ -0,0 +1,150 @@
+package psa
+
+import (
+	"strings"
+	"testing"
+)
+
+// 110kV的3条母线: 1-2线路r=0.01、x=0.1、b=0.02, 2-3变压器x=0.2、变比1.05
+const rawV33 = `0, 100.00, 33, 0, 1, 50.00 / PSS/E-33.0
+THREE BUS TEST
+SECOND LINE
+1,'BUS1', 110.0000,3,1,1,1,1.02000,0.0000
+2,'BUS2', 110.0000,1,1,1,1,1.00000,0.0000
+3,'BUS3', 110.0000,1,1,1,1,1.00000,0.0000
+0 / END OF BUS DATA, BEGIN LOAD DATA
+3,'1 ',1,1,1,50.000,20.000,0.000,0.000,0.000,0.000,1,1
+0 / END OF LOAD DATA, BEGIN FIXED SHUNT DATA
+2,'1 ',1,0.000,19.000
+0 / END OF FIXED SHUNT DATA, BEGIN GENERATOR DATA
+1,'1 ',40.000,10.000,99.0,-99.0,1.02000,0,100.000,0.00000,0.25000,0,0,1,1,100.0
+0 / END OF GENERATOR DATA, BEGIN BRANCH DATA
+1,2,'1 ',0.01000,0.10000,0.02000,0,0,0,0,0,0,0,1
+0 / END OF BRANCH DATA, BEGIN TRANSFORMER DATA
+2,3,0,'1 ',1,1,1,0,0,2,'T1',1,1,1
+0.00000,0.20000,100.00
+1.05000,110.000,0.000
+1.00000,110.000
+0 / END OF TRANSFORMER DATA
+Q
+`
+
+func TestReadRaw(t *testing.T) {
+	network, err := ReadRaw(strings.NewReader(rawV33))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if network.SB != 100 || network.Vav != 110 || len(network.Buses) != 3 {
+		t.Fatalf("SB %v, Vav %v, %d buses", network.SB, network.Vav, len(network.Buses))
+	}
+	assertFloat(t, "Pd3", network.Buses[2].Pd, 50, 0)
+	// 19 Mvar / 100 MVA
+	assertFloat(t, "Bs2", network.Buses[1].Bs, 0.19, 1e-12)
+	assertFloat(t, "Pg1", network.Buses[0].Pg, 40, 0)
+	if len(network.PowerGenerators) != 1 || network.PowerGenerators[0].Xd != 0.25 {
+		t.Errorf("generators = %+v", network.PowerGenerators)
+	}
+	// 线路按Zb = 110²/100 = 121Ω折算为1km的有名值
+	if len(network.Circuits) != 1 {
+		t.Fatalf("%d circuits, want 1", len(network.Circuits))
+	}
+	circuit := network.Circuits[0]
+	assertFloat(t, "R", circuit.R, 1.21, 1e-12)
+	assertFloat(t, "X", circuit.X, 12.1, 1e-12)
+	assertFloat(t, "B", circuit.B, 0.02/121, 1e-15)
+	if len(network.Transformers) != 1 {
+		t.Fatalf("%d transformers, want 1", len(network.Transformers))
+	}
+	transformer := network.Transformers[0]
+	assertFloat(t, "Vs", transformer.Vs, 20, 1e-12)
+	assertFloat(t, "ratio", transformer.Ratio, 1.05, 0)
+
+	p, err := NewParser(*network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.ComputeResultY()
+	// 折算回标幺值后 Y12 = -1/(0.01+j0.1), Y23 = j5/1.05
+	assertComplex(t, "Y12", p.ResultY().At(1, 2), -1/complex(0.01, 0.1))
+	assertComplex(t, "Y23", p.ResultY().At(2, 3), complex(0, 5/1.05))
+}
+
+func TestReadRawV34Branch(t *testing.T) {
+	// 版本34的线路在B之后有名称和12个额定值, GI..BJ和ST随之后移
+	v34 := strings.Replace(rawV33, "0, 100.00, 33,", "0, 100.00, 34,", 1)
+	v34 = strings.Replace(v34, "1,2,'1 ',0.01000,0.10000,0.02000,0,0,0,0,0,0,0,1",
+		"1,2,'1 ',0.01000,0.10000,0.02000,'LINE 1',0,0,0,0,0,0,0,0,0,0,0,0,0.0,0.0,0.0,0.0,1", 1)
+	network, err := ReadRaw(strings.NewReader(v34))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(network.Circuits) != 1 {
+		t.Fatalf("%d circuits, want 1", len(network.Circuits))
+	}
+	assertFloat(t, "X", network.Circuits[0].X, 12.1, 1e-12)
+
+	// ST为0的线路停运
+	out := strings.Replace(v34, "0.0,0.0,0.0,0.0,1\n", "0.0,0.0,0.0,0.0,0\n", 1)
+	if network, err = ReadRaw(strings.NewReader(out)); err != nil {
+		t.Fatal(err)
+	}
+	if len(network.Circuits) != 0 {
+		t.Errorf("%d circuits, want the out-of-service line skipped", len(network.Circuits))
+	}
+}
+
+// 三绕组变压器1-2-3: X12 = 0.1, X23 = 0.3, X31 = 0.15, 星形等值
+// x1 = (0.1+0.15-0.3)/2 = -0.025, x2 = (0.1+0.3-0.15)/2 = 0.125, x3 = (0.3+0.15-0.1)/2 = 0.175,
+// 绕组1的变比1.05, 中点为新增的节点4
+func TestReadRawThreeWinding(t *testing.T) {
+	raw := strings.Replace(rawV33, `2,3,0,'1 ',1,1,1,0,0,2,'T1',1,1,1
+0.00000,0.20000,100.00
+1.05000,110.000,0.000
+1.00000,110.000
+`, `1,2,3,'1 ',1,1,1,0,0,2,'T3',1,1,1
+0.00000,0.10000,100.00,0.00000,0.30000,100.00,0.00000,0.15000,100.00,1.0,0.0
+1.05000,110.000,0.000
+1.00000,110.000,0.000
+1.00000,110.000,0.000
+`, 1)
+	// 恒导纳负荷YQ = -19Mvar为感性, 并联电纳为-0.19
+	raw = strings.Replace(raw, "3,'1 ',1,1,1,50.000,20.000,0.000,0.000,0.000,0.000,1,1",
+		"3,'1 ',1,1,1,50.000,20.000,0.000,0.000,0.000,-19.000,1,1", 1)
+	network, err := ReadRaw(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFloat(t, "Bs3", network.Buses[2].Bs, -0.19, 1e-12)
+	// 负电抗的绕组1直接作为支路, 其余两个绕组仍为变压器
+	if len(network.Branches) != 1 || len(network.Transformers) != 2 {
+		t.Fatalf("branches %+v, transformers %+v", network.Branches, network.Transformers)
+	}
+	branch := network.Branches[0]
+	if branch.Node1 != 1 || branch.Node2 != 4 || branch.Ratio != 1.05 {
+		t.Errorf("winding 1 = %+v", branch)
+	}
+	assertFloat(t, "x1", branch.Reactance, -0.025, 1e-12)
+	assertFloat(t, "Vs2", network.Transformers[0].Vs, 12.5, 1e-9)
+	assertFloat(t, "Vs3", network.Transformers[1].Vs, 17.5, 1e-9)
+	if err := network.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewParser(*network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.ComputeResultY()
+	// 支路1-4的导纳 y = 1/(-j0.025) = j40, 变比在节点1侧
+	assertComplex(t, "Y14", p.ResultY().At(1, 4), complex(0, -40/1.05))
+	assertComplex(t, "Y24", p.ResultY().At(2, 4), complex(0, 8))
+	assertComplex(t, "Y34", p.ResultY().At(3, 4), complex(0, 1/0.175))
+	assertComplex(t, "Y44", p.ResultY().At(4, 4), complex(0, 40-8-1/0.175))
+}
+
+func TestReadRawUnsupportedVersion(t *testing.T) {
+	v32 := strings.Replace(rawV33, "0, 100.00, 33,", "0, 100.00, 32,", 1)
+	if _, err := ReadRaw(strings.NewReader(v32)); err == nil {
+		t.Error("version 32 was accepted")
+	}
+}
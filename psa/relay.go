@@ -0,0 +1,212 @@
+package psa
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// 反时限过电流继电器的特性曲线
+// IEC 60255: t = TMS * k / (M^α - 1)
+// IEEE C37.112: t = TD * (A / (M^p - 1) + B)
+type RelayCurve struct {
+	A, B, P float64
+}
+
+// 特性曲线的名称和参数, IEC曲线的B为0
+var RelayCurves = map[string]RelayCurve{
+	"IEC-SI":  {0.14, 0, 0.02},
+	"IEC-VI":  {13.5, 0, 1},
+	"IEC-EI":  {80, 0, 2},
+	"IEC-LTI": {120, 0, 1},
+	"IEEE-MI": {0.0515, 0.114, 0.02},
+	"IEEE-VI": {19.61, 0.491, 2},
+	"IEEE-EI": {28.2, 0.1217, 2},
+}
+
+const (
+	// 默认的配合时间间隔(s)
+	DefaultCTI = 0.3
+	// 电流倍数超过这个值时按这个值计算动作时间
+	relayMaxMultiple = 20
+)
+
+// 电流倍数为M时的动作时间, 不超过启动值时不动作, 返回+Inf
+func (c RelayCurve) Time(timeDial, M float64) float64 {
+	if M <= 1 {
+		return math.Inf(1)
+	}
+	M = math.Min(M, relayMaxMultiple)
+	return timeDial * (c.A/(math.Pow(M, c.P)-1) + c.B)
+}
+
+// 继电器的名称, 没有给出时为R加序号
+func (r Relay) name(i int) string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return fmt.Sprintf("R%d", i+1)
+}
+
+// 一次短路时一个继电器的动作情况
+type RelayOperation struct {
+	Fault int
+	// 继电器在Relays中的下标
+	Relay int
+	Name  string
+	// 流过继电器的一次电流(kA), 负值表示电流从Node2流向Node1(反方向), 此时不动作
+	KA float64
+	// 电流倍数, 二次电流与启动电流之比
+	Multiple float64
+	// 动作时间(s), 不动作时为+Inf
+	Time float64
+}
+
+// 一次短路时主保护和后备保护的配合情况
+type CoordinationPair struct {
+	Fault           int
+	Primary, Backup RelayOperation
+	// 后备保护与主保护的动作时间之差
+	Margin float64
+	// 主保护不动作, 或者后备保护动作且时间间隔小于CTI
+	Violation bool
+}
+
+// 短路扫描的保护配合校验结果
+type Coordination struct {
+	CTI        float64
+	Operations []RelayOperation
+	Pairs      []CoordinationPair
+}
+
+// 不满足配合要求的主保护和后备保护
+func (c *Coordination) Violations() []CoordinationPair {
+	var violations []CoordinationPair
+	for _, pair := range c.Pairs {
+		if pair.Violation {
+			violations = append(violations, pair)
+		}
+	}
+	return violations
+}
+
+// 依次在faults中的各母线(为空时为全部母线)三相短路, 计算各继电器的动作时间并校验配合,
+// 需要先计算阻抗矩阵
+//
+// 继电器装在Node1一端, 只测量Node1-Node2之间第一条支路的电流, 带方向, 反方向电流不动作;
+// 支路末端Node2上短路时为主保护;
+// 装在支路Node1'-Node2'上且Node2'为主保护的Node1(Node1'不是主保护的Node2)的继电器为其后备保护
+func (p *Parser) ComputeCoordination(faults []int, cti float64) (*Coordination, error) {
+	relays := p.network.Relays
+	if len(relays) == 0 {
+		return nil, fmt.Errorf("网络中没有继电器")
+	}
+	if p.resultZ == nil {
+		return nil, ErrNotComputed
+	}
+	if cti == 0 {
+		cti = DefaultCTI
+	}
+	if len(faults) == 0 {
+		faults, _ = remainingNodes(p.nodeNum, nil)
+	}
+	if p.SB == 0 {
+		return nil, fmt.Errorf("没有基准容量SB, 无法换算继电器电流")
+	}
+	c := &Coordination{CTI: cti}
+	for _, f := range faults {
+		U, err := p.ComputeAllNodeShortU(f)
+		if err != nil {
+			return nil, err
+		}
+		If, err := p.ComputeShortIf(f)
+		if err != nil {
+			return nil, err
+		}
+		operations := make([]RelayOperation, len(relays))
+		for i, relay := range relays {
+			op, err := p.relayOperation(f, i, relay, U, If)
+			if err != nil {
+				return nil, err
+			}
+			operations[i] = op
+		}
+		c.Operations = append(c.Operations, operations...)
+		for i, primary := range relays {
+			if primary.Node2 != f {
+				continue
+			}
+			// 主保护没有正方向的电流时不是这次短路的主保护
+			if operations[i].KA <= 0 {
+				continue
+			}
+			for j, backup := range relays {
+				if j == i || backup.Node2 != primary.Node1 || backup.Node1 == primary.Node2 {
+					continue
+				}
+				pair := CoordinationPair{Fault: f, Primary: operations[i], Backup: operations[j]}
+				pair.Margin = operations[j].Time - operations[i].Time
+				tp, tb := operations[i].Time, operations[j].Time
+				pair.Violation = math.IsInf(tp, 1) || (!math.IsInf(tb, 1) && tb-tp < cti)
+				c.Pairs = append(c.Pairs, pair)
+			}
+		}
+	}
+	return c, nil
+}
+
+// 由短路后的节点电压计算流过继电器的电流和动作时间, If为短路电流, 用于判断方向
+func (p *Parser) relayOperation(f, i int, relay Relay, U []complex128, If complex128) (RelayOperation, error) {
+	op := RelayOperation{Fault: f, Relay: i, Name: relay.name(i), Time: math.Inf(1)}
+	n1, n2 := relay.Node1, relay.Node2
+	if n1 <= 0 || n2 <= 0 || n1 == n2 {
+		return op, nil
+	}
+	if n1 > p.nodeNum || n2 > p.nodeNum {
+		return op, fmt.Errorf("继电器%s所在的支路%d-%d不在网络中", op.Name, n1, n2)
+	}
+	branch, err := p.protectedBranch(n1, n2)
+	if err != nil {
+		return op, fmt.Errorf("继电器%s: %w", op.Name, err)
+	}
+	vb := relay.KV
+	if vb == 0 {
+		vb = p.Vav
+	}
+	if vb == 0 {
+		return op, fmt.Errorf("继电器%s没有基准电压, 需要给出Vav或kv", op.Name)
+	}
+	I := branchCurrent(branch, n1, U[n1-1], U[n2-1])
+	op.KA = cmplx.Abs(I) * p.SB / (math.Sqrt(3) * vb)
+	op.Multiple = op.KA * 1000 / relay.CT / relay.Pickup
+	// 不计负荷电流时各支路的短路电流与短路电流同相位, 流向短路点的电流为正方向
+	if real(I*cmplx.Conj(If)) < 0 {
+		op.KA = -op.KA
+		return op, nil
+	}
+	op.Time = RelayCurves[relay.Curve].Time(relay.TimeDial, op.Multiple)
+	return op, nil
+}
+
+// 继电器所在的支路: 两端为n1和n2的第一条支路, 并联的支路只取其中一条
+func (p *Parser) protectedBranch(n1, n2 int) (Branch, error) {
+	for _, branch := range p.branches {
+		if (branch.Node1 == n1 && branch.Node2 == n2) || (branch.Node1 == n2 && branch.Node2 == n1) {
+			return branch, nil
+		}
+	}
+	return Branch{}, fmt.Errorf("节点%d和%d之间没有支路", n1, n2)
+}
+
+// 由支路两端电压计算从节点n1流入支路的串联电流, 计入节点1侧的变比, 不计充电电纳
+func branchCurrent(branch Branch, n1 int, V1, V2 complex128) complex128 {
+	y := 1 / complex(branch.Resistance, branch.Reactance)
+	k := complex(1, 0)
+	if branch.Ratio != 0 {
+		k = complex(branch.Ratio, 0)
+	}
+	if branch.Node1 == n1 {
+		return y * (V1/k - V2) / k
+	}
+	return y * (V1 - V2/k)
+}
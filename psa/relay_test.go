@@ -0,0 +1,71 @@
+package psa
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRelayCurveTime(t *testing.T) {
+	// IEEE-MI, TD = 0.5, M = 10: 0.5*(0.0515/(10^0.02 - 1) + 0.114)
+	assertFloat(t, "IEEE-MI", RelayCurves["IEEE-MI"].Time(0.5, 10), 0.603377961, 1e-8)
+	// 超过20倍时按20倍
+	assertFloat(t, "IEC-SI M=50", RelayCurves["IEC-SI"].Time(1, 50), 2.267356367, 1e-8)
+	if time := RelayCurves["IEC-SI"].Time(1, 1); !math.IsInf(time, 1) {
+		t.Errorf("time at pickup = %v, want +Inf", time)
+	}
+}
+
+func TestComputeCoordination(t *testing.T) {
+	// 115kV的辐射形网络: 电源x=0.1接在节点1, 1-2的线路x=0.2, 2-3的线路x=0.3
+	network := chainNetwork()
+	network.Vav = 115
+	network.Relays = []Relay{
+		{Name: "R12", Node1: 1, Node2: 2, CT: 400, Pickup: 0.5, TimeDial: 0.2, Curve: "IEC-SI"},
+		{Name: "R23", Node1: 2, Node2: 3, CT: 400, Pickup: 1, TimeDial: 0.1, Curve: "IEC-SI"},
+		// 装在节点2看向节点1, 节点3短路时是反方向
+		{Name: "R21", Node1: 2, Node2: 1, CT: 400, Pickup: 1, TimeDial: 0.1, Curve: "IEC-SI"},
+	}
+	p, err := NewParser(network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ComputeResult(); err != nil {
+		t.Fatal(err)
+	}
+	c, err := p.ComputeCoordination([]int{3}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Operations) != 3 {
+		t.Fatalf("%d operations, want 3", len(c.Operations))
+	}
+	// 短路电流 1/0.6 * 100/(√3*115) = 0.83674kA 流过R12和R23
+	r12, r23, r21 := c.Operations[0], c.Operations[1], c.Operations[2]
+	assertFloat(t, "R23 kA", r23.KA, 0.836739521, 1e-8)
+	assertFloat(t, "R23 M", r23.Multiple, 2.091848801, 1e-8)
+	// 0.1*0.14/(M^0.02 - 1)
+	assertFloat(t, "R23 t", r23.Time, 0.941464673, 1e-8)
+	assertFloat(t, "R12 t", r12.Time, 0.964270010, 1e-8)
+	if r21.KA >= 0 || !math.IsInf(r21.Time, 1) {
+		t.Errorf("reverse relay R21 = %+v, want a negative current and no trip", r21)
+	}
+	// R12是R23的后备保护, 时间间隔0.0228s小于0.3s
+	if len(c.Pairs) != 1 {
+		t.Fatalf("pairs = %+v", c.Pairs)
+	}
+	pair := c.Pairs[0]
+	if pair.Primary.Name != "R23" || pair.Backup.Name != "R12" {
+		t.Errorf("pair = %s backed up by %s", pair.Primary.Name, pair.Backup.Name)
+	}
+	assertFloat(t, "margin", pair.Margin, 0.022805337, 1e-8)
+	if !pair.Violation || len(c.Violations()) != 1 {
+		t.Error("0.023 s margin was not reported as a violation")
+	}
+	// 间隔要求降到0.02s时满足配合
+	if c, err = p.ComputeCoordination([]int{3}, 0.02); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Violations()) != 0 {
+		t.Errorf("violations with a 0.02 s CTI: %+v", c.Violations())
+	}
+}
@@ -0,0 +1,338 @@
+package psa
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+	"strconv"
+)
+
+// 结果的单位制
+const (
+	UnitPU = "pu"
+	UnitSI = "si"
+)
+
+// 结果的输出格式, text为各实验原来的打印方式
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+	OutputCSV  = "csv"
+)
+
+// 物理量的种类, 决定标幺值换算为有名值的方式
+type Kind int
+
+const (
+	// 无量纲, 例如L、U因子和分布系数
+	Dimensionless Kind = iota
+	Impedance
+	Admittance
+	Voltage
+	Current
+	Power
+)
+
+// 同时给出直角坐标和极坐标形式的复数, 相角单位为度
+type Complex struct {
+	Re    float64 `json:"re"`
+	Im    float64 `json:"im"`
+	Mag   float64 `json:"mag"`
+	Angle float64 `json:"angle"`
+}
+
+func NewComplex(c complex128) Complex {
+	return Complex{
+		Re:    real(c),
+		Im:    imag(c),
+		Mag:   cmplx.Abs(c),
+		Angle: cmplx.Phase(c) * 180 / math.Pi,
+	}
+}
+
+type Matrix struct {
+	Name   string      `json:"name"`
+	Unit   string      `json:"unit"`
+	Values [][]Complex `json:"values"`
+}
+
+type Quantity struct {
+	Name string `json:"name"`
+	Unit string `json:"unit"`
+	Complex
+}
+
+// 随时间变化的实数量, 采样时刻为Result.Time
+type Waveform struct {
+	Name   string    `json:"name"`
+	Unit   string    `json:"unit"`
+	Values []float64 `json:"values"`
+}
+
+type NodeValue struct {
+	Node int `json:"node"`
+	Complex
+}
+
+type BranchValue struct {
+	Node1 int `json:"node_1"`
+	Node2 int `json:"node_2"`
+	Complex
+}
+
+// 各实验的计算结果
+type Result struct {
+	// 单位制, pu或si
+	Unit string `json:"unit"`
+	// 基准容量(MVA)和基准电压(kV), 用于换算有名值
+	SB       float64  `json:"SB"`
+	VB       float64  `json:"VB,omitempty"`
+	Matrices []Matrix `json:"matrices,omitempty"`
+	// 导纳矩阵和阻抗矩阵的行列为电气母线号, Buses[i]为母线i+1包含的物理节点, 母线号与节点号相同时为空
+	Buses      [][]int    `json:"buses,omitempty"`
+	Quantities []Quantity `json:"quantities,omitempty"`
+	// 短路后各节点电压
+	VoltageUnit string      `json:"voltage_unit,omitempty"`
+	BusVoltages []NodeValue `json:"bus_voltages,omitempty"`
+	// 短路后各支路电流
+	CurrentUnit    string        `json:"current_unit,omitempty"`
+	BranchCurrents []BranchValue `json:"branch_currents,omitempty"`
+	// 各波形共用的采样时刻(s)
+	Time      []float64  `json:"time,omitempty"`
+	Waveforms []Waveform `json:"waveforms,omitempty"`
+}
+
+// 换算有名值需要基准电压vb, 为0时只能输出标幺值
+func NewResult(unit string, sb, vb float64) (*Result, error) {
+	switch unit {
+	case UnitPU, "":
+		unit = UnitPU
+	case UnitSI:
+		if sb == 0 || vb == 0 {
+			return nil, fmt.Errorf("没有基准容量或基准电压, 不能换算为有名值")
+		}
+	default:
+		return nil, fmt.Errorf("未知的单位制: %s", unit)
+	}
+	return &Result{Unit: unit, SB: sb, VB: vb}, nil
+}
+
+// 按网络建立结果, 换算有名值时检查网络只有基准电压为vb的一个电压等级
+func (p *Parser) NewResult(unit string, vb float64) (*Result, error) {
+	result, err := NewResult(unit, p.SB, vb)
+	if err != nil {
+		return nil, err
+	}
+	if result.Unit == UnitSI {
+		if err := p.network.checkBaseVoltage(vb); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// 换算有名值时各节点共用一个基准电压vb, 网络中各元件给出的基准电压都必须是vb, 变压器两侧的额定电压也必须相同;
+// 有多个电压等级的网络各节点的基准电压不同, 只能输出标幺值
+func (network *PowerNetwork) checkBaseVoltage(vb float64) error {
+	type base struct {
+		name string
+		kv   []float64
+	}
+	bases := []base{{name: "系统电源"}, {name: "线路"}, {name: "变压器"}, {name: "发电机"},
+		{name: "负荷"}, {name: "电动机"}, {name: "母线"}, {name: "继电器"}}
+	if network.SG != nil {
+		bases[0].kv = append(bases[0].kv, network.SG.VB)
+	}
+	for _, circuit := range network.Circuits {
+		if circuit.VB == 0 {
+			circuit.VB = network.Vav
+		}
+		bases[1].kv = append(bases[1].kv, circuit.VB)
+	}
+	for i, transformer := range network.Transformers {
+		if transformer.V1n != 0 && transformer.V2n != 0 && transformer.V1n != transformer.V2n {
+			return fmt.Errorf("第%d台变压器两侧的额定电压为%gkV和%gkV, 网络有多个电压等级时只能输出标幺值", i+1, transformer.V1n, transformer.V2n)
+		}
+		bases[2].kv = append(bases[2].kv, transformer.VB)
+	}
+	for _, generator := range network.PowerGenerators {
+		bases[3].kv = append(bases[3].kv, generator.VB)
+	}
+	for _, ld := range network.Lds {
+		bases[4].kv = append(bases[4].kv, ld.VB)
+	}
+	for _, motor := range network.Motors {
+		bases[5].kv = append(bases[5].kv, motor.VB)
+	}
+	for _, bus := range network.Buses {
+		bases[6].kv = append(bases[6].kv, bus.BaseKV)
+	}
+	for _, relay := range network.Relays {
+		bases[7].kv = append(bases[7].kv, relay.KV)
+	}
+	for _, b := range bases {
+		for i, kv := range b.kv {
+			if kv != 0 && kv != vb {
+				return fmt.Errorf("第%d个%s的基准电压为%gkV, 与换算有名值的基准电压%gkV不同, 网络有多个电压等级时只能输出标幺值", i+1, b.name, kv, vb)
+			}
+		}
+	}
+	return nil
+}
+
+// 按单位制换算标幺值, 返回换算系数和单位
+func (r *Result) scale(kind Kind) (float64, string) {
+	if r.Unit != UnitSI || kind == Dimensionless {
+		return 1, UnitPU
+	}
+	zb := r.VB * r.VB / r.SB
+	switch kind {
+	case Impedance:
+		return zb, "Ω"
+	case Admittance:
+		return 1 / zb, "S"
+	case Voltage:
+		return r.VB, "kV"
+	case Current:
+		return r.SB / (math.Sqrt(3) * r.VB), "kA"
+	case Power:
+		return r.SB, "MVA"
+	}
+	return 1, UnitPU
+}
+
+// 记录母线的组成, 以便由矩阵的行列找到物理节点
+func (r *Result) SetBuses(t *Topology) {
+	if !t.Identity() {
+		r.Buses = t.BusNodes()
+	}
+}
+
+func (r *Result) AddMatrix(name string, m *ComplexMatrix, kind Kind) {
+	k, unit := r.scale(kind)
+	matrix := Matrix{Name: name, Unit: unit}
+	for _, row := range m.Values() {
+		values := make([]Complex, len(row))
+		for j, c := range row {
+			values[j] = NewComplex(c * complex(k, 0))
+		}
+		matrix.Values = append(matrix.Values, values)
+	}
+	r.Matrices = append(r.Matrices, matrix)
+}
+
+// 添加标幺值表示的物理量
+func (r *Result) AddQuantity(name string, c complex128, kind Kind) {
+	k, unit := r.scale(kind)
+	r.Quantities = append(r.Quantities, Quantity{Name: name, Unit: unit, Complex: NewComplex(c * complex(k, 0))})
+}
+
+// 添加已经是有名值的物理量, 不随单位制换算
+func (r *Result) AddValue(name string, c complex128, unit string) {
+	r.Quantities = append(r.Quantities, Quantity{Name: name, Unit: unit, Complex: NewComplex(c)})
+}
+
+// 设置波形的采样时刻(s)
+func (r *Result) SetTime(t []float64) {
+	r.Time = t
+}
+
+// 添加标幺值表示的波形, 采样时刻见SetTime
+func (r *Result) AddWaveform(name string, values []float64, kind Kind) {
+	k, unit := r.scale(kind)
+	scaled := make([]float64, len(values))
+	for i, v := range values {
+		scaled[i] = v * k
+	}
+	r.Waveforms = append(r.Waveforms, Waveform{Name: name, Unit: unit, Values: scaled})
+}
+
+// 添加已经是有名值的波形, 不随单位制换算
+func (r *Result) AddWaveformValue(name string, values []float64, unit string) {
+	r.Waveforms = append(r.Waveforms, Waveform{Name: name, Unit: unit, Values: values})
+}
+
+// 节点电压的下标为节点号-1
+func (r *Result) SetBusVoltages(U []complex128) {
+	k, unit := r.scale(Voltage)
+	r.VoltageUnit = unit
+	r.BusVoltages = nil
+	for i, u := range U {
+		r.BusVoltages = append(r.BusVoltages, NodeValue{Node: i + 1, Complex: NewComplex(u * complex(k, 0))})
+	}
+}
+
+func (r *Result) AddBranchCurrent(node1, node2 int, c complex128) {
+	k, unit := r.scale(Current)
+	r.CurrentUnit = unit
+	r.BranchCurrents = append(r.BranchCurrents, BranchValue{Node1: node1, Node2: node2, Complex: NewComplex(c * complex(k, 0))})
+}
+
+func (r *Result) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// 每个数值一行: section, name, row, col, unit, re, im, mag, angle
+// 矩阵的row、col为行列号, 节点电压的row为节点号, 支路电流的row、col为两端节点号,
+// 采样时刻和波形的row为采样序号
+func (r *Result) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"section", "name", "row", "col", "unit", "re", "im", "mag", "angle"})
+	write := func(section, name string, row, col int, unit string, c Complex) {
+		writer.Write([]string{section, name, itoa(row), itoa(col), unit,
+			ftoa(c.Re), ftoa(c.Im), ftoa(c.Mag), ftoa(c.Angle)})
+	}
+	for _, matrix := range r.Matrices {
+		for i, values := range matrix.Values {
+			for j, c := range values {
+				write("matrix", matrix.Name, i+1, j+1, matrix.Unit, c)
+			}
+		}
+	}
+	for _, q := range r.Quantities {
+		write("quantity", q.Name, 0, 0, q.Unit, q.Complex)
+	}
+	for _, v := range r.BusVoltages {
+		write("bus_voltage", "U", v.Node, 0, r.VoltageUnit, v.Complex)
+	}
+	for _, b := range r.BranchCurrents {
+		write("branch_current", "I", b.Node1, b.Node2, r.CurrentUnit, b.Complex)
+	}
+	for i, t := range r.Time {
+		write("time", "t", i+1, 0, "s", NewComplex(complex(t, 0)))
+	}
+	for _, waveform := range r.Waveforms {
+		for i, v := range waveform.Values {
+			write("waveform", waveform.Name, i+1, 0, waveform.Unit, NewComplex(complex(v, 0)))
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// 按输出格式写出结果, text格式由各实验自己打印
+func (r *Result) Write(w io.Writer, output string) error {
+	switch output {
+	case OutputJSON:
+		return r.WriteJSON(w)
+	case OutputCSV:
+		return r.WriteCSV(w)
+	}
+	return fmt.Errorf("未知的输出格式: %s", output)
+}
+
+func itoa(i int) string {
+	if i == 0 {
+		return ""
+	}
+	return strconv.Itoa(i)
+}
+
+func ftoa(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,125 @@
+package psa
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestResultSIScaling(t *testing.T) {
+	r, err := NewResult(UnitSI, 100, 115)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Z := NewComplexMatrix(1, 1)
+	Z.Set(1, 1, 0.1i)
+	r.AddMatrix("Z", Z, Impedance)
+	// 电流基准 100/(√3*115) = 0.50204 kA
+	r.AddQuantity("If", -1i/0.3, Current)
+	r.AddQuantity("k", 2, Dimensionless)
+	r.SetBusVoltages([]complex128{1, 0.5})
+
+	var decoded Result
+	var buffer bytes.Buffer
+	if err := r.WriteJSON(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(buffer.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	// Zb = 115²/100 = 132.25Ω
+	z := decoded.Matrices[0]
+	if z.Unit != "Ω" {
+		t.Errorf("Z unit = %q, want Ω", z.Unit)
+	}
+	assertFloat(t, "Z11", z.Values[0][0].Im, 13.225, 1e-9)
+	current := decoded.Quantities[0]
+	if current.Unit != "kA" {
+		t.Errorf("If unit = %q, want kA", current.Unit)
+	}
+	assertFloat(t, "|If|", current.Mag, 100/(math.Sqrt(3)*115)/0.3, 1e-9)
+	assertFloat(t, "If angle", current.Angle, -90, 1e-9)
+	if k := decoded.Quantities[1]; k.Unit != UnitPU || k.Re != 2 {
+		t.Errorf("dimensionless quantity = %+v", k)
+	}
+	if decoded.VoltageUnit != "kV" || decoded.BusVoltages[1].Node != 2 {
+		t.Errorf("voltages = %s %+v", decoded.VoltageUnit, decoded.BusVoltages)
+	}
+	assertFloat(t, "U2", decoded.BusVoltages[1].Re, 57.5, 1e-9)
+}
+
+func TestResultWriteCSV(t *testing.T) {
+	r, err := NewResult(UnitPU, 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Z := NewComplexMatrix(2, 2)
+	Z.Set(1, 2, 0.1i)
+	r.AddMatrix("Z", Z, Impedance)
+	r.AddBranchCurrent(1, 2, 3)
+	var buffer bytes.Buffer
+	if err := r.Write(&buffer, OutputCSV); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	want := []string{
+		"section,name,row,col,unit,re,im,mag,angle",
+		"matrix,Z,1,1,pu,0,0,0,0",
+		"matrix,Z,1,2,pu,0,0.1,0.1,90",
+		"matrix,Z,2,1,pu,0,0,0,0",
+		"matrix,Z,2,2,pu,0,0,0,0",
+		"branch_current,I,1,2,pu,3,0,3,0",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("csv =\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestNewResultErrors(t *testing.T) {
+	if _, err := NewResult(UnitSI, 100, 0); err == nil {
+		t.Error("si without a base voltage was accepted")
+	}
+	if _, err := NewResult("kA", 100, 115); err == nil {
+		t.Error("unknown unit was accepted")
+	}
+	r, _ := NewResult(UnitPU, 100, 0)
+	if err := r.Write(&bytes.Buffer{}, "xml"); err == nil {
+		t.Error("unknown output format was accepted")
+	}
+}
+
+// 各节点共用一个基准电压, 网络有多个电压等级时不能换算有名值
+func TestParserNewResultVoltageLevels(t *testing.T) {
+	network, err := ImportPowerNetworkFromFile("../lab2/test1.json", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewParser(network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 节点1在230kV侧, 其余在115kV和10.5kV侧
+	if _, err := p.NewResult(UnitSI, 115); err == nil || !strings.Contains(err.Error(), "230kV和110kV") {
+		t.Errorf("lab2 in SI: err = %v, want the 230/110kV transformer reported", err)
+	}
+	if r, err := p.NewResult(UnitPU, 115); err != nil || r.Unit != UnitPU {
+		t.Errorf("lab2 in pu: %v, %v", r, err)
+	}
+
+	single := PowerNetwork{SB: 100, Vav: 115, Circuits: []Circuit{{Node1: 1, Node2: 2, X: 0.4, L: 10}},
+		SG: &SG{Node: 1, Circuit: Circuit{X: 0.4, L: 10, VB: 115}}}
+	if p, err = NewParser(single); err != nil {
+		t.Fatal(err)
+	}
+	r, err := p.NewResult(UnitSI, 115)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFloat(t, "VB", r.VB, 115, 0)
+	// 线路按Vav = 115kV换算, 结果不能用另一个基准电压
+	if _, err := p.NewResult(UnitSI, 10.5); err == nil {
+		t.Error("10.5kV base on a 115kV network: want error")
+	}
+}
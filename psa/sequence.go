@@ -0,0 +1,57 @@
+package psa
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// 短路类型
+const (
+	// 三相短路
+	Fault3Ph = "3ph"
+	// 单相接地短路(a相)
+	Fault1Ph = "1ph"
+	// 两相短路(b、c相)
+	Fault2Ph = "2ph"
+	// 两相接地短路(b、c相)
+	Fault2PhG = "2phg"
+)
+
+// 120°旋转因子 a = e^(j120°)
+var operatorA = cmplx.Rect(1, 2*math.Pi/3)
+
+// 由短路点的各序输入阻抗计算a相各序电流, zf为短路点的过渡阻抗
+func ComputeSequenceFault(faultType string, Zff1, Zff2, Zff0, zf complex128) (Ifa1, Ifa2, Ifa0 complex128, err error) {
+	switch faultType {
+	case Fault3Ph:
+		Ifa1 = 1 / (Zff1 + zf)
+	case Fault1Ph:
+		// 三个序网串联
+		Ifa1 = 1 / (Zff1 + Zff2 + Zff0 + 3*zf)
+		Ifa2 = Ifa1
+		Ifa0 = Ifa1
+	case Fault2Ph:
+		// 正序和负序网络并联
+		Ifa1 = 1 / (Zff1 + Zff2 + zf)
+		Ifa2 = -Ifa1
+	case Fault2PhG:
+		// 负序和零序网络并联后与正序网络串联
+		Z0 := Zff0 + 3*zf
+		Ifa1 = 1 / (Zff1 + Zff2*Z0/(Zff2+Z0))
+		Ifa2 = -Ifa1 * Z0 / (Zff2 + Z0)
+		Ifa0 = -Ifa1 * Zff2 / (Zff2 + Z0)
+	default:
+		return 0, 0, 0, fmt.Errorf("不支持的短路类型: %s", faultType)
+	}
+	return Ifa1, Ifa2, Ifa0, nil
+}
+
+// 由a相的正序、负序和零序分量合成a、b、c三相的相量
+func PhaseComponents(c1, c2, c0 complex128) (a, b, c complex128) {
+	a2 := operatorA * operatorA
+	a = c1 + c2 + c0
+	b = a2*c1 + operatorA*c2 + c0
+	c = operatorA*c1 + a2*c2 + c0
+	return a, b, c
+}
@@ -0,0 +1,175 @@
+package psa
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// 判断特征值实部为正的容差
+const eigenTolerance = 1e-9
+
+// 线性化模型的一个特征值(模式), 频率为Hz
+type Mode struct {
+	Eigenvalue complex128
+	Frequency  float64
+	// 阻尼比 ζ = -σ/|λ|, 特征值为0时为0
+	Damping float64
+	// 各状态变量的参与因子, 与SmallSignal.States对应, 和为1
+	Participation []float64
+}
+
+// 多机系统的小干扰稳定分析结果
+type SmallSignal struct {
+	// 状态变量的名称, 依次为各发电机的Δδ和Δω
+	States []string
+	// 状态矩阵
+	A *ComplexMatrix
+	// 同步功率系数 ∂Pe/∂δ, 与各发电机对应
+	K *ComplexMatrix
+	// 按阻尼比从小到大排列
+	Modes []Mode
+	// 没有实部为正的特征值, 没有无穷大母线时的零特征值(功角参考)不算失稳
+	Stable bool
+}
+
+// 在短路前的运行点线性化经典模型的多机系统: dΔδ/dt = ωs Δω, 2H dΔω/dt = -KΔδ - DΔω,
+// 网络和运行点与暂态稳定仿真相同, 系统电源SG作为无穷大母线, 不作为状态变量
+func (p *Parser) ComputeSmallSignal(frequency float64) (*SmallSignal, error) {
+	if frequency == 0 {
+		frequency = 50
+	}
+	if !positive(frequency) {
+		return nil, fmt.Errorf("频率必须是大于0的有限值")
+	}
+	if p.SB == 0 {
+		return nil, fmt.Errorf("没有基准容量SB")
+	}
+	for i, generator := range p.network.PowerGenerators {
+		if generator.H <= 0 {
+			return nil, fmt.Errorf("第%d台发电机没有给出惯性时间常数H", i+1)
+		}
+	}
+	machines, err := p.stabilityMachines()
+	if err != nil {
+		return nil, err
+	}
+	Y, err := p.stabilityY(machines, [2]int{})
+	if err != nil {
+		return nil, err
+	}
+	Yred, err := reduceToMachines(Y, machines, 0)
+	if err != nil {
+		return nil, err
+	}
+	var generators []int
+	for i, m := range machines {
+		if !m.infinite {
+			generators = append(generators, i)
+		}
+	}
+	n := len(generators)
+	s := &SmallSignal{K: NewComplexMatrix(n, n), A: NewComplexMatrix(2*n, 2*n)}
+	// Pe_i = Σ E_i E_j (G_ij cos δij + B_ij sin δij), 对δj求导, 无穷大母线只计入对角元
+	sync := func(i, j int) float64 {
+		Ei, Ej := machines[i].e, machines[j].e
+		Yij := Yred.rcAt(i+1, j+1)
+		dij := cmplx.Phase(Ei) - cmplx.Phase(Ej)
+		return cmplx.Abs(Ei) * cmplx.Abs(Ej) * (real(Yij)*math.Sin(dij) - imag(Yij)*math.Cos(dij))
+	}
+	for a, i := range generators {
+		kii := 0.0
+		for j := range machines {
+			if j != i {
+				kii -= sync(i, j)
+			}
+		}
+		for b, j := range generators {
+			if a == b {
+				s.K.rcSet(a+1, b+1, complex(kii, 0))
+			} else {
+				s.K.rcSet(a+1, b+1, complex(sync(i, j), 0))
+			}
+		}
+	}
+	omegaS := 2 * math.Pi * frequency
+	A := mat.NewDense(2*n, 2*n, nil)
+	for a, i := range generators {
+		m := machines[i]
+		A.Set(a, n+a, omegaS)
+		for b := range generators {
+			A.Set(n+a, b, -real(s.K.rcAt(a+1, b+1))/(2*m.h))
+		}
+		A.Set(n+a, n+a, -m.d/(2*m.h))
+	}
+	for _, i := range generators {
+		s.States = append(s.States, machines[i].name+".delta")
+	}
+	for _, i := range generators {
+		s.States = append(s.States, machines[i].name+".omega")
+	}
+	for r := 0; r < 2*n; r++ {
+		for c := 0; c < 2*n; c++ {
+			s.A.rcSet(r+1, c+1, complex(A.At(r, c), 0))
+		}
+	}
+	if s.Modes, err = eigenModes(A); err != nil {
+		return nil, err
+	}
+	s.Stable = true
+	for _, mode := range s.Modes {
+		if real(mode.Eigenvalue) > eigenTolerance {
+			s.Stable = false
+		}
+	}
+	return s, nil
+}
+
+// 用gonum计算特征值和左右特征向量, 参与因子 p_ki = |u_ki* v_ki|, 再归一化使各模式的和为1
+func eigenModes(A *mat.Dense) ([]Mode, error) {
+	n, _ := A.Dims()
+	var eigen mat.Eigen
+	if !eigen.Factorize(A, mat.EigenBoth) {
+		return nil, fmt.Errorf("状态矩阵的特征值分解不收敛")
+	}
+	values := eigen.Values(nil)
+	var right, left mat.CDense
+	eigen.VectorsTo(&right)
+	eigen.LeftVectorsTo(&left)
+	modes := make([]Mode, n)
+	for i, lambda := range values {
+		mode := Mode{Eigenvalue: lambda, Frequency: math.Abs(imag(lambda)) / (2 * math.Pi)}
+		if lambda != 0 {
+			mode.Damping = -real(lambda) / cmplx.Abs(lambda)
+		}
+		var norm complex128
+		for k := 0; k < n; k++ {
+			norm += cmplx.Conj(left.At(k, i)) * right.At(k, i)
+		}
+		mode.Participation = make([]float64, n)
+		sum := 0.0
+		for k := 0; k < n; k++ {
+			pk := cmplx.Conj(left.At(k, i)) * right.At(k, i)
+			// 重特征值(没有无穷大母线时的零模式)的左右特征向量可能正交, 只按右特征向量计算
+			if cmplx.Abs(norm) < 1e-12 {
+				pk = right.At(k, i) * cmplx.Conj(right.At(k, i))
+			}
+			mode.Participation[k] = cmplx.Abs(pk)
+			sum += mode.Participation[k]
+		}
+		for k := range mode.Participation {
+			mode.Participation[k] /= sum
+		}
+		modes[i] = mode
+	}
+	sort.SliceStable(modes, func(i, j int) bool {
+		if modes[i].Damping != modes[j].Damping {
+			return modes[i].Damping < modes[j].Damping
+		}
+		return imag(modes[i].Eigenvalue) > imag(modes[j].Eigenvalue)
+	})
+	return modes, nil
+}
@@ -0,0 +1,120 @@
+package psa
+
+import (
+	"math"
+	"testing"
+)
+
+// 单机无穷大系统: Pe = |E'|sinδ/0.7, K = |E'|cosδ0/0.7 = Re(E')/0.7 = 1/0.7,
+// λ² + D/(2H)λ + ωs K/(2H) = 0
+func TestSmallSignalSMIB(t *testing.T) {
+	p, err := NewParser(smibNetwork())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := p.ComputeSmallSignal(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.States) != 2 || s.States[0] != SourceGenerator+"1.delta" || s.States[1] != SourceGenerator+"1.omega" {
+		t.Fatalf("States = %v", s.States)
+	}
+	k := 1 / 0.7
+	omegaS := 100 * math.Pi
+	assertComplex(t, "K", s.K.At(1, 1), complex(k, 0))
+	assertComplex(t, "A12", s.A.At(1, 2), complex(omegaS, 0))
+	assertComplex(t, "A21", s.A.At(2, 1), complex(-k/10, 0))
+	// 无阻尼时 λ = ±j√(ωs K/(2H)) = ±j6.69924, f = 1.0662Hz
+	wn := math.Sqrt(omegaS * k / 10)
+	assertFloat(t, "ωn", wn, 6.699245, 1e-6)
+	if len(s.Modes) != 2 {
+		t.Fatalf("%d modes, want 2", len(s.Modes))
+	}
+	assertFloat(t, "Re λ", real(s.Modes[0].Eigenvalue), 0, 1e-9)
+	assertFloat(t, "Im λ", imag(s.Modes[0].Eigenvalue), wn, 1e-9)
+	assertFloat(t, "Im λ*", imag(s.Modes[1].Eigenvalue), -wn, 1e-9)
+	assertFloat(t, "f", s.Modes[0].Frequency, wn/(2*math.Pi), 1e-9)
+	assertFloat(t, "ζ", s.Modes[0].Damping, 0, 1e-9)
+	for i, pk := range s.Modes[0].Participation {
+		assertFloat(t, s.States[i], pk, 0.5, 1e-9)
+	}
+	if !s.Stable {
+		t.Error("Stable = false, want true")
+	}
+
+	// D = 10: σ = -D/(4H) = -0.5, ωd = √(ωn² - 0.25), ζ = 0.5/ωn
+	network := smibNetwork()
+	network.PowerGenerators[0].D = 10
+	if p, err = NewParser(network); err != nil {
+		t.Fatal(err)
+	}
+	if s, err = p.ComputeSmallSignal(50); err != nil {
+		t.Fatal(err)
+	}
+	assertFloat(t, "σ", real(s.Modes[0].Eigenvalue), -0.5, 1e-9)
+	assertFloat(t, "ωd", imag(s.Modes[0].Eigenvalue), math.Sqrt(wn*wn-0.25), 1e-9)
+	assertFloat(t, "ζ(D=10)", s.Modes[0].Damping, 0.5/wn, 1e-9)
+}
+
+// 两台H=5s的发电机经0.3+0.4+0.3相连, 没有无穷大母线: E1 = 1+j0.24, E2 = 1,
+// K12 = |E1||E2|cosδ12/1.0 = 1, 除功角参考的零特征值外 λ = ±j√(ωs K(1/2H1+1/2H2)) = ±j√(20π)
+func TestSmallSignalTwoMachines(t *testing.T) {
+	network := PowerNetwork{
+		SB: 100,
+		PowerGenerators: []PowerGenerator{
+			{Node: 1, Sn: 100, Xd: 0.3, H: 5, Pn: 80},
+			{Node: 2, Sn: 100, Xd: 0.3, H: 5},
+		},
+		Branches: []Branch{{Node1: 1, Node2: 2, Reactance: 0.4}},
+	}
+	p, err := NewParser(network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := p.ComputeSmallSignal(50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertComplex(t, "K11", s.K.At(1, 1), 1)
+	assertComplex(t, "K12", s.K.At(1, 2), -1)
+	if len(s.Modes) != 4 {
+		t.Fatalf("%d modes, want 4", len(s.Modes))
+	}
+	var oscillating, zero int
+	for _, mode := range s.Modes {
+		switch {
+		case math.Abs(imag(mode.Eigenvalue)) > 1:
+			oscillating++
+			assertFloat(t, "|Im λ|", math.Abs(imag(mode.Eigenvalue)), math.Sqrt(20*math.Pi), 1e-9)
+			assertFloat(t, "Re λ", real(mode.Eigenvalue), 0, 1e-9)
+		case math.Abs(real(mode.Eigenvalue)) < 1e-6:
+			zero++
+		}
+	}
+	if oscillating != 2 || zero != 2 {
+		t.Errorf("modes = %v, want one oscillating pair and a double zero", s.Modes)
+	}
+	if !s.Stable {
+		t.Error("Stable = false, want true")
+	}
+}
+
+func TestSmallSignalErrors(t *testing.T) {
+	p, err := NewParser(smibNetwork())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []float64{-50, math.NaN(), math.Inf(1)} {
+		if _, err := p.ComputeSmallSignal(f); err == nil {
+			t.Errorf("frequency %v: want error", f)
+		}
+	}
+	network := smibNetwork()
+	network.PowerGenerators[0].H = 0
+	if p, err = NewParser(network); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.ComputeSmallSignal(50); err == nil {
+		t.Error("H = 0: want error")
+	}
+}
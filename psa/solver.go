@@ -0,0 +1,255 @@
+package psa
+
+import (
+	"fmt"
+	"math"
+	"os"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// 求解器的名称
+const (
+	// 各实验手写的LDU分解
+	SolverLDU = "ldu"
+	// gonum的LU分解
+	SolverGonum = "gonum"
+)
+
+// 交叉验证时阻抗矩阵元素允许的相对误差, 以阻抗矩阵元素的最大模为基准
+const VerifyTolerance = 1e-8
+
+// 分解导纳矩阵并求解节点电压方程的求解器
+type Solver interface {
+	Name() string
+	// 保存并分解导纳矩阵
+	Factorize(Y *ComplexMatrix) error
+	// 求解 Y x = b
+	Solve(b []complex128) ([]complex128, error)
+	// 导纳矩阵的逆, 即阻抗矩阵
+	Inverse() (*ComplexMatrix, error)
+}
+
+// 按名称选择求解器, ldu使用Parser自身的LDU分解和主元设置
+func (p *Parser) UseSolver(name string) error {
+	switch name {
+	case SolverLDU, "":
+		p.Solver = nil
+	case SolverGonum:
+		p.Solver = &GonumSolver{}
+	default:
+		return fmt.Errorf("未知的求解器: %s", name)
+	}
+	return nil
+}
+
+// 环境变量PSA_DEBUG不为空时, 每次计算阻抗矩阵后都用另一种求解器交叉验证
+func debugMode() bool {
+	return os.Getenv("PSA_DEBUG") != ""
+}
+
+// 手写的LDU分解, 与Parser.LDU和Parser.ComputeZ的算法相同
+type LDUSolver struct {
+	Tolerance float64
+	Pivoting  bool
+	parser    *Parser
+	l, d, u   *ComplexMatrix
+}
+
+func (s *LDUSolver) Name() string {
+	return SolverLDU
+}
+
+func (s *LDUSolver) Factorize(Y *ComplexMatrix) error {
+	n, _ := Y.Dims()
+	s.parser = &Parser{
+		nodeNum:        n,
+		resultY:        Y,
+		PivotTolerance: s.Tolerance,
+		Pivoting:       s.Pivoting,
+	}
+	var err error
+	s.l, s.d, s.u, err = s.parser.LDU()
+	return err
+}
+
+func (s *LDUSolver) Solve(b []complex128) ([]complex128, error) {
+	if s.parser == nil {
+		return nil, ErrNotComputed
+	}
+	n := s.parser.nodeNum
+	if len(b) != n {
+		return nil, fmt.Errorf("右端向量的长度%d与节点数%d不同", len(b), n)
+	}
+	perm := s.parser.perm
+	// 按主元顺序排列后依次求解 L w = b, D h = w, U x = h
+	w := make([]complex128, n)
+	for i := 1; i <= n; i++ {
+		sum := b[perm[i-1]]
+		for k := 1; k <= i-1; k++ {
+			sum -= s.l.rcAt(i, k) * w[k-1]
+		}
+		w[i-1] = sum
+	}
+	for i := 1; i <= n; i++ {
+		w[i-1] /= s.d.rcAt(i, i)
+	}
+	x := make([]complex128, n)
+	for i := n; i >= 1; i-- {
+		sum := w[i-1]
+		for k := i + 1; k <= n; k++ {
+			sum -= s.u.rcAt(i, k) * w[k-1]
+		}
+		w[i-1] = sum
+		x[perm[i-1]] = sum
+	}
+	return x, nil
+}
+
+func (s *LDUSolver) Inverse() (*ComplexMatrix, error) {
+	if s.parser == nil {
+		return nil, ErrNotComputed
+	}
+	return s.parser.ComputeZ(s.l, s.d, s.u), nil
+}
+
+// 用gonum计算的参考实现, 导纳矩阵保存在mat.CDense中,
+// gonum没有复数的LU分解, 将 Y = G + jB 换成实数矩阵 [G -B; B G] 后分解
+type GonumSolver struct {
+	y  *mat.CDense
+	lu mat.LU
+}
+
+func (s *GonumSolver) Name() string {
+	return SolverGonum
+}
+
+func (s *GonumSolver) Factorize(Y *ComplexMatrix) error {
+	n, col := Y.Dims()
+	if n == 0 {
+		return fmt.Errorf("导纳矩阵为空")
+	}
+	if n != col {
+		return &DimensionError{Op: "分解", Row1: n, Col1: col, Row2: col, Col2: n}
+	}
+	s.y = mat.NewCDense(n, n, nil)
+	a := mat.NewDense(2*n, 2*n, nil)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			y := Y.m[i][j]
+			s.y.Set(i, j, y)
+			a.Set(i, j, real(y))
+			a.Set(i, j+n, -imag(y))
+			a.Set(i+n, j, imag(y))
+			a.Set(i+n, j+n, real(y))
+		}
+	}
+	s.lu.Factorize(a)
+	if math.IsInf(s.lu.Cond(), 1) {
+		return &SingularMatrixError{}
+	}
+	return nil
+}
+
+// 求解实数方程组 [G -B; B G][xr; xi] = rhs, 条件数过大只作为警告, 仍然返回结果
+func (s *GonumSolver) solveReal(rhs *mat.Dense) (*mat.Dense, error) {
+	if s.y == nil {
+		return nil, ErrNotComputed
+	}
+	var x mat.Dense
+	if err := s.lu.SolveTo(&x, false, rhs); err != nil {
+		if _, ok := err.(mat.Condition); !ok {
+			return nil, err
+		}
+	}
+	return &x, nil
+}
+
+func (s *GonumSolver) Solve(b []complex128) ([]complex128, error) {
+	if s.y == nil {
+		return nil, ErrNotComputed
+	}
+	n, _ := s.y.Dims()
+	if len(b) != n {
+		return nil, fmt.Errorf("右端向量的长度%d与节点数%d不同", len(b), n)
+	}
+	rhs := mat.NewDense(2*n, 1, nil)
+	for i, c := range b {
+		rhs.Set(i, 0, real(c))
+		rhs.Set(i+n, 0, imag(c))
+	}
+	x, err := s.solveReal(rhs)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]complex128, n)
+	for i := 0; i < n; i++ {
+		result[i] = complex(x.At(i, 0), x.At(i+n, 0))
+	}
+	return result, nil
+}
+
+func (s *GonumSolver) Inverse() (*ComplexMatrix, error) {
+	if s.y == nil {
+		return nil, ErrNotComputed
+	}
+	n, _ := s.y.Dims()
+	// 右端为 [I; 0], 解的上半部分为阻抗矩阵的实部, 下半部分为虚部
+	rhs := mat.NewDense(2*n, n, nil)
+	for i := 0; i < n; i++ {
+		rhs.Set(i, i, 1)
+	}
+	x, err := s.solveReal(rhs)
+	if err != nil {
+		return nil, err
+	}
+	Z := NewComplexMatrix(n, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			Z.m[i][j] = complex(x.At(i, j), x.At(i+n, j))
+		}
+	}
+	return Z, nil
+}
+
+// 两种求解器得到的阻抗矩阵不一致
+type VerificationError struct {
+	Row, Col  int
+	Got, Want complex128
+	// 用于比较的求解器
+	Reference string
+}
+
+func (e *VerificationError) Error() string {
+	return fmt.Sprintf("阻抗矩阵Z%d%d与%s的结果不一致: %v, %s为%v", e.Row, e.Col, e.Reference, e.Got, e.Reference, e.Want)
+}
+
+// 用另一种求解器重新计算阻抗矩阵并与当前结果比较,
+// 当前使用手写的LDU分解时以gonum为参考, 否则以手写的LDU分解为参考
+func (p *Parser) VerifyZ() error {
+	if p.resultZ == nil {
+		return ErrNotComputed
+	}
+	var reference Solver = &GonumSolver{}
+	if p.Solver != nil && p.Solver.Name() == SolverGonum {
+		reference = &LDUSolver{Tolerance: p.PivotTolerance, Pivoting: true}
+	}
+	if err := reference.Factorize(p.resultY); err != nil {
+		return fmt.Errorf("%s: %v", reference.Name(), err)
+	}
+	Z, err := reference.Inverse()
+	if err != nil {
+		return fmt.Errorf("%s: %v", reference.Name(), err)
+	}
+	if tolerance := VerifyTolerance * math.Max(Z.maxAbs(), 1); !p.resultZ.Equal(Z, tolerance) {
+		i, j, _ := p.resultZ.maxDiff(Z)
+		return &VerificationError{
+			Row:       i,
+			Col:       j,
+			Got:       p.resultZ.At(i, j),
+			Want:      Z.At(i, j),
+			Reference: reference.Name(),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package psa
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSolvers(t *testing.T) {
+	for _, solver := range []Solver{&LDUSolver{Tolerance: DefaultPivotTolerance, Pivoting: true}, &GonumSolver{}} {
+		p, err := NewParser(pivotNetwork())
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.ComputeResultY()
+		if err := solver.Factorize(p.ResultY()); err != nil {
+			t.Fatalf("%s: %v", solver.Name(), err)
+		}
+		// 节点1注入单位电流时的电压就是阻抗矩阵的第1列: j/6, j/12
+		x, err := solver.Solve([]complex128{1, 0})
+		if err != nil {
+			t.Fatalf("%s: %v", solver.Name(), err)
+		}
+		assertComplex(t, solver.Name()+" x1", x[0], 1i/6)
+		assertComplex(t, solver.Name()+" x2", x[1], 1i/12)
+		Z, err := solver.Inverse()
+		if err != nil {
+			t.Fatalf("%s: %v", solver.Name(), err)
+		}
+		assertComplex(t, solver.Name()+" Z22", Z.At(2, 2), 11i/120)
+		if _, err := solver.Solve([]complex128{1}); err == nil {
+			t.Errorf("%s: right-hand side of the wrong length was accepted", solver.Name())
+		}
+	}
+}
+
+func TestGonumSolverSingular(t *testing.T) {
+	p, err := NewParser(PowerNetwork{Branches: []Branch{{Node1: 1, Node2: 2, Reactance: 0.2}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.UseSolver(SolverGonum); err != nil {
+		t.Fatal(err)
+	}
+	var singular *SingularMatrixError
+	if err := p.ComputeResult(); !errors.As(err, &singular) {
+		t.Errorf("ComputeResult = %v, want SingularMatrixError", err)
+	}
+	if err := p.UseSolver("cholesky"); err == nil {
+		t.Error("unknown solver was accepted")
+	}
+}
+
+func TestVerifyZ(t *testing.T) {
+	for _, name := range []string{SolverLDU, SolverGonum} {
+		p, err := NewParser(pivotNetwork())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.UseSolver(name); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.ComputeResult(); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.VerifyZ(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		// 改动一个元素后交叉验证应指出这个元素
+		p.resultZ.m[1][0] += 0.01
+		var mismatch *VerificationError
+		if err := p.VerifyZ(); !errors.As(err, &mismatch) || mismatch.Row != 2 || mismatch.Col != 1 {
+			t.Errorf("%s: VerifyZ = %v, want a mismatch at Z21", name, err)
+		}
+	}
+}
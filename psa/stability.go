@@ -0,0 +1,459 @@
+package psa
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// 转子运动方程的数值积分方法
+const (
+	IntegratorRK4 = "rk4"
+	// 隐式梯形法, 每步用迭代求解
+	IntegratorTrapezoidal = "trapezoidal"
+)
+
+const (
+	// 临界切除时间的二分法精度(s)
+	cctTolerance = 1e-3
+	// 梯形法每步迭代的收敛精度和最大次数
+	trapezoidalTolerance = 1e-10
+	trapezoidalMaxIter   = 50
+)
+
+// 暂态稳定仿真的参数
+type StabilityOptions struct {
+	// 三相短路的母线
+	Fault int
+	// 短路切除时间(s)
+	ClearTime float64
+	// 切除短路时断开的线路两端节点, 都为0时只切除短路, 网络恢复到短路前
+	TripNode1, TripNode2 int
+	// 仿真时间(s), 为0时取2s
+	Duration float64
+	// 积分步长(s), 为0时取1ms
+	Step float64
+	// 系统频率(Hz), 为0时取50Hz
+	Frequency float64
+	// 积分方法, 为空时使用RK4
+	Method string
+	// 任意两台发电机的功角差超过这个值(度)时判为失稳, 为0时取180°
+	MaxAngle float64
+}
+
+func (o StabilityOptions) withDefaults() StabilityOptions {
+	if o.Duration == 0 {
+		o.Duration = 2
+	}
+	if o.Step == 0 {
+		o.Step = 0.001
+	}
+	if o.Frequency == 0 {
+		o.Frequency = 50
+	}
+	if o.Method == "" {
+		o.Method = IntegratorRK4
+	}
+	if o.MaxAngle == 0 {
+		o.MaxAngle = 180
+	}
+	return o
+}
+
+func (o StabilityOptions) validate() error {
+	switch o.Method {
+	case IntegratorRK4, IntegratorTrapezoidal:
+	default:
+		return fmt.Errorf("未知的积分方法: %s", o.Method)
+	}
+	if math.IsNaN(o.ClearTime) || o.ClearTime < 0 || !positive(o.Duration, o.Step, o.Frequency, o.MaxAngle) {
+		return fmt.Errorf("切除时间不能为负数, 仿真时间、步长、频率和失稳判据必须是大于0的有限值")
+	}
+	if o.Duration/o.Step > maxTraceSamples {
+		return fmt.Errorf("积分步数超过%d, 需要增大步长或缩短仿真时间", maxTraceSamples)
+	}
+	return nil
+}
+
+// 经典模型的一台电机: 暂态电抗Xd'后的恒定电势E', 系统电源SG作为无穷大母线
+type machine struct {
+	name string
+	node int
+	// 网络中对应的电源支路在Parser.branches中的下标, 由内电势节点代替
+	branch int
+	// 暂态电抗支路的导纳
+	y complex128
+	// 惯性时间常数H和阻尼系数D, 已经换算到系统基准容量
+	h, d float64
+	// 系统电源作为无穷大母线, 功角和转速不变
+	infinite bool
+	e        complex128
+	pm       float64
+}
+
+// 暂态稳定仿真的结果, 功角为度, 转速为标幺值
+type StabilityResult struct {
+	// 各电机的名称, 与Angles、Speeds的下标对应
+	Machines []string
+	// 暂态电势E'和机械功率Pm(标幺值)
+	E  []complex128
+	Pm []float64
+	// 短路前、短路中和短路后收缩到电机内电势节点的导纳矩阵
+	Pre, During, Post *ComplexMatrix
+	Time              []float64
+	Angles, Speeds    [][]float64
+	// 仿真过程中任意两台电机功角差的最大值(度)
+	MaxSeparation float64
+	Stable        bool
+}
+
+// 按经典模型建立各电机, 内电势由母线数据中的电压和发电功率计算, 没有母线数据时取1∠0和Pn
+func (p *Parser) stabilityMachines() ([]machine, error) {
+	V := make([]complex128, p.nodeNum)
+	S := make([]complex128, p.nodeNum)
+	hasBus := make([]bool, p.nodeNum)
+	for i := range V {
+		V[i] = 1
+	}
+	for _, bus := range p.network.Buses {
+		if bus.Node <= 0 || bus.Node > p.nodeNum {
+			continue
+		}
+		if bus.V != 0 {
+			V[bus.Node-1] = cmplx.Rect(bus.V, bus.Angle*math.Pi/180)
+		}
+		S[bus.Node-1] += complex(bus.Pg, bus.Qg) / complex(p.SB, 0)
+		hasBus[bus.Node-1] = hasBus[bus.Node-1] || bus.Pg != 0 || bus.Qg != 0
+	}
+	// 同一母线上的发电功率按额定容量分配
+	capacity := make([]float64, p.nodeNum)
+	for _, generator := range p.network.PowerGenerators {
+		capacity[generator.Node-1] += generatorSn(generator)
+	}
+	var machines []machine
+	if p.network.SG != nil && p.network.SG.Node != 0 {
+		branch := p.sgBranch(*p.network.SG)
+		m := machine{name: SourceSystem, node: branch.Node1, y: 1 / complex(branch.Resistance, branch.Reactance), infinite: true}
+		machines = append(machines, m)
+	}
+	if len(machines)+len(p.network.PowerGenerators) != len(p.machineBranches) {
+		return nil, fmt.Errorf("电源支路与发电机不对应")
+	}
+	for i := range machines {
+		machines[i].branch = p.machineBranches[i]
+	}
+	for i, generator := range p.network.PowerGenerators {
+		generator.Xd = transientReactance(generator)
+		branch := p.powerGeneratorBranch(generator)
+		sn := generatorSn(generator)
+		m := machine{
+			name:   fmt.Sprintf("%s%d", SourceGenerator, i+1),
+			node:   branch.Node1,
+			branch: p.machineBranches[len(machines)],
+			y:      1 / complex(branch.Resistance, branch.Reactance),
+			h:      generator.H * sn / p.SB,
+			d:      generator.D * sn / p.SB,
+		}
+		n := m.node - 1
+		s := complex(generator.Pn/p.SB, 0)
+		if hasBus[n] {
+			s = S[n] * complex(sn/capacity[n], 0)
+		}
+		// E' = V + jXd' I, I = conj(S/V)
+		m.e = V[n] + cmplx.Conj(s/V[n])/m.y
+		machines = append(machines, m)
+	}
+	if len(p.network.PowerGenerators) == 0 {
+		return nil, fmt.Errorf("网络中没有发电机")
+	}
+	// 无穷大母线的电势由其电压和功率计算, 没有母线数据时为1∠0
+	if machines[0].infinite {
+		m := &machines[0]
+		n := m.node - 1
+		m.e = V[n] + cmplx.Conj(S[n]/V[n])/m.y
+	}
+	return machines, nil
+}
+
+// 发电机的额定容量(MVA)
+func generatorSn(generator PowerGenerator) float64 {
+	if generator.Sn != 0 {
+		return generator.Sn
+	}
+	return generator.Pn / generator.Cos
+}
+
+// 收缩到电机内电势节点的导纳矩阵: 在网络的导纳矩阵上增加内电势节点后消去全部母线,
+// Ynn不含电源支路, fault不为0时该母线电压为0
+func reduceToMachines(Ynn *ComplexMatrix, machines []machine, fault int) (*ComplexMatrix, error) {
+	n, _ := Ynn.Dims()
+	Y := NewComplexMatrix(n+len(machines), n+len(machines))
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= n; j++ {
+			Y.rcSet(i, j, Ynn.rcAt(i, j))
+		}
+	}
+	for k, m := range machines {
+		i := n + k + 1
+		Y.rcSet(i, i, m.y)
+		Y.rcSet(m.node, m.node, Y.rcAt(m.node, m.node)+m.y)
+		Y.rcSet(i, m.node, -m.y)
+		Y.rcSet(m.node, i, -m.y)
+	}
+	if fault != 0 {
+		var err error
+		if Y, err = Y.Without(fault); err != nil {
+			return nil, err
+		}
+		n--
+	}
+	buses, _ := remainingNodes(n, nil)
+	return Y.Kron(buses...)
+}
+
+// 不含各电机电源支路、计入恒定阻抗负荷的导纳矩阵, trip给出时去掉这两个节点之间的支路
+func (p *Parser) stabilityY(machines []machine, trip [2]int) (*ComplexMatrix, error) {
+	network := &Parser{nodeNum: p.nodeNum}
+	// 发电机和系统电源的电源支路由内电势节点代替, 负荷和电动机的电源支路作为恒定阻抗
+	replaced := map[int]bool{}
+	for _, m := range machines {
+		replaced[m.branch] = true
+	}
+	found := false
+	for i, branch := range p.branches {
+		if replaced[i] {
+			continue
+		}
+		if trip[0] != 0 && ((branch.Node1 == trip[0] && branch.Node2 == trip[1]) || (branch.Node1 == trip[1] && branch.Node2 == trip[0])) {
+			found = true
+			continue
+		}
+		network.branches = append(network.branches, branch)
+	}
+	if trip[0] != 0 && !found {
+		return nil, fmt.Errorf("节点%d和%d之间没有可以断开的线路", trip[0], trip[1])
+	}
+	network.ComputeResultY()
+	Y := network.resultY
+	// 母线负荷按短路前电压换算为恒定导纳 y = (Pd - jQd)/|V|²
+	for _, bus := range p.network.Buses {
+		if bus.Node <= 0 || bus.Node > p.nodeNum || (bus.Pd == 0 && bus.Qd == 0) {
+			continue
+		}
+		v := bus.V
+		if v == 0 {
+			v = 1
+		}
+		y := complex(bus.Pd, -bus.Qd) / complex(p.SB*v*v, 0)
+		Y.rcSet(bus.Node, bus.Node, Y.rcAt(bus.Node, bus.Node)+y)
+	}
+	return Y, nil
+}
+
+// 各电机的电磁功率 Pe = Re(E conj(YE))
+func electricalPower(Y *ComplexMatrix, E []complex128) []float64 {
+	I, _ := Y.MulVec(E)
+	P := make([]float64, len(E))
+	for i := range E {
+		P[i] = real(E[i] * cmplx.Conj(I[i]))
+	}
+	return P
+}
+
+// 三相短路的暂态稳定仿真: 发电机采用经典模型,
+// 机械功率取短路前的电磁功率, 负荷按短路前电压换算为恒定阻抗, 系统电源SG作为无穷大母线
+func (p *Parser) SimulateStability(opts StabilityOptions) (*StabilityResult, error) {
+	opts = opts.withDefaults()
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if err := p.checkNode(opts.Fault); err != nil {
+		return nil, err
+	}
+	if p.SB == 0 {
+		return nil, fmt.Errorf("没有基准容量SB")
+	}
+	for i, generator := range p.network.PowerGenerators {
+		if generator.H <= 0 {
+			return nil, fmt.Errorf("第%d台发电机没有给出惯性时间常数H", i+1)
+		}
+	}
+	machines, err := p.stabilityMachines()
+	if err != nil {
+		return nil, err
+	}
+	Y, err := p.stabilityY(machines, [2]int{})
+	if err != nil {
+		return nil, err
+	}
+	r := &StabilityResult{}
+	if r.Pre, err = reduceToMachines(Y, machines, 0); err != nil {
+		return nil, fmt.Errorf("短路前网络: %w", err)
+	}
+	if r.During, err = reduceToMachines(Y, machines, opts.Fault); err != nil {
+		return nil, fmt.Errorf("短路中网络: %w", err)
+	}
+	r.Post = r.Pre
+	if opts.TripNode1 != 0 || opts.TripNode2 != 0 {
+		Ypost, err := p.stabilityY(machines, [2]int{opts.TripNode1, opts.TripNode2})
+		if err != nil {
+			return nil, err
+		}
+		if r.Post, err = reduceToMachines(Ypost, machines, 0); err != nil {
+			return nil, fmt.Errorf("短路后网络: %w", err)
+		}
+	}
+	m := len(machines)
+	r.E = make([]complex128, m)
+	for i, mach := range machines {
+		r.Machines = append(r.Machines, mach.name)
+		r.E[i] = mach.e
+	}
+	r.Pm = electricalPower(r.Pre, r.E)
+	r.simulate(machines, opts)
+	return r, nil
+}
+
+// 积分转子运动方程 dδ/dt = ωs(ω-1), 2H dω/dt = Pm - Pe - D(ω-1), 失稳后停止
+func (r *StabilityResult) simulate(machines []machine, opts StabilityOptions) {
+	m := len(machines)
+	omegaS := 2 * math.Pi * opts.Frequency
+	state := make([]float64, 2*m)
+	magnitude := make([]float64, m)
+	for i := range machines {
+		magnitude[i] = cmplx.Abs(r.E[i])
+		state[i] = cmplx.Phase(r.E[i])
+		state[m+i] = 1
+	}
+	derivative := func(Y *ComplexMatrix, x []float64) []float64 {
+		E := make([]complex128, m)
+		for i := range E {
+			E[i] = cmplx.Rect(magnitude[i], x[i])
+		}
+		Pe := electricalPower(Y, E)
+		dx := make([]float64, 2*m)
+		for i, mach := range machines {
+			if mach.infinite {
+				continue
+			}
+			dx[i] = omegaS * (x[m+i] - 1)
+			dx[m+i] = (r.Pm[i] - Pe[i] - mach.d*(x[m+i]-1)) / (2 * mach.h)
+		}
+		return dx
+	}
+	r.Angles = make([][]float64, m)
+	r.Speeds = make([][]float64, m)
+	record := func(t float64) {
+		r.Time = append(r.Time, t)
+		min, max := math.Inf(1), math.Inf(-1)
+		for i := 0; i < m; i++ {
+			delta := state[i] * 180 / math.Pi
+			r.Angles[i] = append(r.Angles[i], delta)
+			r.Speeds[i] = append(r.Speeds[i], state[m+i])
+			min, max = math.Min(min, delta), math.Max(max, delta)
+		}
+		r.MaxSeparation = math.Max(r.MaxSeparation, max-min)
+	}
+	r.Stable = true
+	record(0)
+	n := int(math.Floor(opts.Duration/opts.Step + 1e-9))
+	for k := 0; k < n; k++ {
+		t := float64(k) * opts.Step
+		// 切除时刻落在步长中间时, 这一步仍按短路中的网络计算
+		Y := r.During
+		if t+1e-9 >= opts.ClearTime {
+			Y = r.Post
+		}
+		h := opts.Step
+		switch opts.Method {
+		case IntegratorRK4:
+			state = rk4Step(state, h, func(x []float64) []float64 { return derivative(Y, x) })
+		case IntegratorTrapezoidal:
+			state = trapezoidalStep(state, h, func(x []float64) []float64 { return derivative(Y, x) })
+		}
+		record(t + h)
+		if r.MaxSeparation > opts.MaxAngle {
+			r.Stable = false
+			return
+		}
+	}
+}
+
+func rk4Step(x []float64, h float64, f func([]float64) []float64) []float64 {
+	add := func(a, b []float64, k float64) []float64 {
+		c := make([]float64, len(a))
+		for i := range a {
+			c[i] = a[i] + k*b[i]
+		}
+		return c
+	}
+	k1 := f(x)
+	k2 := f(add(x, k1, h/2))
+	k3 := f(add(x, k2, h/2))
+	k4 := f(add(x, k3, h))
+	next := make([]float64, len(x))
+	for i := range x {
+		next[i] = x[i] + h/6*(k1[i]+2*k2[i]+2*k3[i]+k4[i])
+	}
+	return next
+}
+
+// 隐式梯形法 x' = x + h/2 (f(x) + f(x')), 用不动点迭代求解
+func trapezoidalStep(x []float64, h float64, f func([]float64) []float64) []float64 {
+	fx := f(x)
+	next := make([]float64, len(x))
+	for i := range x {
+		next[i] = x[i] + h*fx[i]
+	}
+	for iter := 0; iter < trapezoidalMaxIter; iter++ {
+		fn := f(next)
+		diff := 0.0
+		for i := range x {
+			v := x[i] + h/2*(fx[i]+fn[i])
+			diff = math.Max(diff, math.Abs(v-next[i]))
+			next[i] = v
+		}
+		if diff < trapezoidalTolerance {
+			break
+		}
+	}
+	return next
+}
+
+// 用二分法求临界切除时间, 在0到仿真时间之间搜索, opts.ClearTime不使用;
+// 切除时刻按积分步长取整, 精度不高于步长; 短路不切除也不失稳时返回+Inf, 立即切除也失稳时返回0
+func (p *Parser) CriticalClearingTime(opts StabilityOptions) (float64, error) {
+	opts = opts.withDefaults()
+	stable := func(tc float64) (bool, error) {
+		o := opts
+		o.ClearTime = tc
+		r, err := p.SimulateStability(o)
+		if err != nil {
+			return false, err
+		}
+		return r.Stable, nil
+	}
+	lo, hi := 0.0, opts.Duration
+	ok, err := stable(hi)
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		return math.Inf(1), nil
+	}
+	if ok, err = stable(lo); err != nil || !ok {
+		return 0, err
+	}
+	for hi-lo > cctTolerance {
+		mid := (lo + hi) / 2
+		if ok, err = stable(mid); err != nil {
+			return 0, err
+		}
+		if ok {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo, nil
+}
@@ -0,0 +1,121 @@
+package psa
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// 单机无穷大系统: 发电机xd=0.3(没有xd'时作为暂态电抗)、H=5s、Pn=80MW接在节点1, 经x=0.2的线路接到节点2,
+// 系统电源经0.4Ω/km×50km(100kV, 0.2)接在节点2, 没有母线数据时端电压取1∠0
+func smibNetwork() PowerNetwork {
+	return PowerNetwork{
+		SB: 100,
+		SG: &SG{Node: 2, Circuit: Circuit{X: 0.4, L: 50, VB: 100}},
+		PowerGenerators: []PowerGenerator{{
+			Node: 1, Sn: 100, Xd: 0.3, H: 5, Pn: 80,
+		}},
+		Branches: []Branch{{Node1: 1, Node2: 2, Reactance: 0.2}},
+	}
+}
+
+func TestSimulateStability(t *testing.T) {
+	p, err := NewParser(smibNetwork())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := p.SimulateStability(StabilityOptions{Fault: 1, ClearTime: 0.1, Duration: 0.1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Machines) != 2 || r.Machines[0] != SourceSystem {
+		t.Fatalf("Machines = %v", r.Machines)
+	}
+	// E' = 1 + j0.3×0.8, 无穷大母线E = 1
+	assertComplex(t, "E[SG]", r.E[0], 1)
+	assertComplex(t, "E[G1]", r.E[1], complex(1, 0.24))
+	// 短路前电机间的总电抗 0.3+0.2+0.2 = 0.7, Pm = |E'|·1·sinδ0/0.7 = 0.24/0.7
+	assertComplex(t, "Pre[1,2]", r.Pre.At(1, 2), complex(0, 1/0.7))
+	assertFloat(t, "Pm", r.Pm[1], 0.24/0.7, 1e-9)
+	// 发电机端短路, 电机间没有联系
+	assertComplex(t, "During[1,2]", r.During.At(1, 2), 0)
+	// 短路中Pe = 0, 匀加速: Δδ = ωs·Pm/(2H)·t²/2, RK4对二次多项式是精确的
+	delta0 := math.Atan(0.24) * 180 / math.Pi
+	last := len(r.Time) - 1
+	assertFloat(t, "t", r.Time[last], 0.1, 1e-9)
+	assertFloat(t, "δ(0)", r.Angles[1][0], delta0, 1e-9)
+	dw := 0.24 / 0.7 / 10 * 0.1
+	assertFloat(t, "ω(0.1)", r.Speeds[1][last], 1+dw, 1e-9)
+	assertFloat(t, "δ(0.1)", r.Angles[1][last], delta0+100*math.Pi*dw*0.1/2*180/math.Pi, 1e-9)
+	if !r.Stable {
+		t.Error("Stable = false, want true")
+	}
+}
+
+// 等面积定则: δ0 = 13.496°, δmax = 166.504°, cosδc = sinδ0(δmax-δ0)+cosδmax, δc = 110.436°,
+// tc = √(4H(δc-δ0)/(ωs Pm)) = 0.5605s
+func TestCriticalClearingTime(t *testing.T) {
+	p, err := NewParser(smibNetwork())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, method := range []string{IntegratorRK4, IntegratorTrapezoidal} {
+		opts := StabilityOptions{Fault: 1, Method: method}
+		for _, c := range []struct {
+			clear  float64
+			stable bool
+		}{{0.5, true}, {0.62, false}} {
+			opts.ClearTime = c.clear
+			r, err := p.SimulateStability(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Stable != c.stable {
+				t.Errorf("%s tc = %v: Stable = %v, want %v (MaxSeparation %v)", method, c.clear, r.Stable, c.stable, r.MaxSeparation)
+			}
+		}
+		tc, err := p.CriticalClearingTime(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertFloat(t, method+" CCT", tc, 0.5605, 0.005)
+	}
+}
+
+func TestSimulateStabilityErrors(t *testing.T) {
+	p, err := NewParser(smibNetwork())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.SimulateStability(StabilityOptions{Fault: 1, Method: "euler"}); err == nil {
+		t.Error("unknown method: want error")
+	}
+	if _, err := p.SimulateStability(StabilityOptions{Fault: 1, ClearTime: -1}); err == nil {
+		t.Error("negative clear time: want error")
+	}
+	for _, opts := range []StabilityOptions{
+		{Fault: 1, ClearTime: math.NaN()},
+		{Fault: 1, Step: math.NaN()},
+		{Fault: 1, Duration: math.Inf(1)},
+		{Fault: 1, MaxAngle: math.NaN()},
+	} {
+		if _, err := p.SimulateStability(opts); err == nil {
+			t.Errorf("%+v: want error", opts)
+		}
+	}
+	if _, err := p.SimulateStability(StabilityOptions{Fault: 1, TripNode1: 1, TripNode2: 3}); err == nil {
+		t.Error("missing trip line: want error")
+	}
+	var unknown *UnknownNodeError
+	if _, err := p.SimulateStability(StabilityOptions{Fault: 3}); !errors.As(err, &unknown) {
+		t.Errorf("fault at node 3: err = %v, want UnknownNodeError", err)
+	}
+	network := smibNetwork()
+	network.PowerGenerators[0].H = 0
+	if p, err = NewParser(network); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.SimulateStability(StabilityOptions{Fault: 1}); err == nil {
+		t.Error("H = 0: want error")
+	}
+}
@@ -0,0 +1,79 @@
+package psa
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// 戴维南等值电阻与阻抗模之比不大于这个值时视为纯电抗
+const theveninResistanceTolerance = 1e-12
+
+// 从一个节点对地或两个节点之间看进去的戴维南等值
+type TheveninEquivalent struct {
+	// Node2为0表示节点Node1对地
+	Node1, Node2 int
+	// 开路电压, 即故障前Node1与Node2的电压差
+	Voc complex128
+	Zth complex128
+	// 电抗与电阻之比, 电阻为0时为+Inf
+	XR float64
+	// 额定电压(1标幺值)下的短路容量 Sk = 1/|Zth|, 标幺值
+	Sk float64
+}
+
+// 由电源支路的电势计算故障前的节点电压 U = ZI, 网络中没有电源时返回nil
+func (p *Parser) PrefaultVoltages() ([]complex128, error) {
+	if p.resultZ == nil {
+		return nil, ErrNotComputed
+	}
+	I, hasSource := p.sourceInjections()
+	if !hasSource {
+		return nil, nil
+	}
+	return p.ComputeUBeforeShort(I)
+}
+
+// 节点node1对地(node2为0)或两个节点之间的戴维南等值,
+// U为故障前的节点电压, 为nil时各节点取1∠0, 此时两个节点之间的开路电压为0,
+// 负序和零序网络没有电源, 应给出全为0的U
+//
+// 两个节点之间 Zth = Z11 + Z22 - Z12 - Z21
+func (p *Parser) Thevenin(node1, node2 int, U []complex128) (*TheveninEquivalent, error) {
+	if err := p.checkFault(node1); err != nil {
+		return nil, err
+	}
+	if node2 != 0 {
+		if err := p.checkNode(node2); err != nil {
+			return nil, err
+		}
+		if node2 == node1 {
+			return nil, &InvalidFaultError{Node1: node1, Node2: node2, Reason: "两个节点相同"}
+		}
+	}
+	if U == nil {
+		U = make([]complex128, p.nodeNum)
+		for i := range U {
+			U[i] = 1
+		}
+	} else if len(U) != p.nodeNum {
+		return nil, &DimensionError{Op: "求开路电压", Row1: p.nodeNum, Col1: p.nodeNum, Row2: len(U), Col2: 1}
+	}
+	th := &TheveninEquivalent{Node1: node1, Node2: node2, Voc: U[node1-1], Zth: p.resultZ.rcAt(node1, node1)}
+	if node2 != 0 {
+		th.Voc -= U[node2-1]
+		th.Zth += p.resultZ.rcAt(node2, node2) - p.resultZ.rcAt(node1, node2) - p.resultZ.rcAt(node2, node1)
+	}
+	if th.Zth == 0 {
+		return nil, &InvalidFaultError{Node1: node1, Node2: node2, Reason: "戴维南等值阻抗为0"}
+	}
+	// 两个节点之间相减后电阻可能只剩舍入误差, 按0处理
+	if math.Abs(real(th.Zth)) <= theveninResistanceTolerance*cmplx.Abs(th.Zth) {
+		th.Zth = complex(0, imag(th.Zth))
+	}
+	th.XR = imag(th.Zth) / real(th.Zth)
+	if real(th.Zth) == 0 {
+		th.XR = math.Inf(1)
+	}
+	th.Sk = 1 / cmplx.Abs(th.Zth)
+	return th, nil
+}
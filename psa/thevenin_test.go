@@ -0,0 +1,73 @@
+package psa
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestThevenin(t *testing.T) {
+	// 电源z=0.02+j0.1、E=1.05接在节点1, 1-2的线路x=0.2
+	p, err := NewParser(PowerNetwork{Branches: []Branch{
+		{Node1: 1, Resistance: 0.02, Reactance: 0.1, E: 1.05},
+		{Node1: 1, Node2: 2, Reactance: 0.2},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ComputeResult(); err != nil {
+		t.Fatal(err)
+	}
+	U, err := p.PrefaultVoltages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 空载时各节点电压都等于电源电势
+	assertComplex(t, "U2", U[1], 1.05)
+
+	th, err := p.Thevenin(2, 0, U)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Zth = Z22 = 0.02 + j0.3, X/R = 15, Sk = 1/|Zth|
+	assertComplex(t, "Voc", th.Voc, 1.05)
+	assertComplex(t, "Zth", th.Zth, 0.02+0.3i)
+	assertFloat(t, "X/R", th.XR, 15, 1e-9)
+	assertFloat(t, "Sk", th.Sk, 1/math.Hypot(0.02, 0.3), 1e-9)
+
+	// 两个节点之间 Zth = Z11 + Z22 - 2Z12 = j0.2, 就是线路本身
+	th, err = p.Thevenin(1, 2, U)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertComplex(t, "Voc12", th.Voc, 0)
+	assertComplex(t, "Zth12", th.Zth, 0.2i)
+	if !math.IsInf(th.XR, 1) {
+		t.Errorf("X/R = %v, want +Inf for a pure reactance", th.XR)
+	}
+}
+
+func TestTheveninErrors(t *testing.T) {
+	p, err := NewParser(twoNodeNetwork())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Thevenin(1, 0, nil); err != ErrNotComputed {
+		t.Errorf("before ComputeResult: %v, want ErrNotComputed", err)
+	}
+	if err := p.ComputeResult(); err != nil {
+		t.Fatal(err)
+	}
+	var invalid *InvalidFaultError
+	if _, err := p.Thevenin(1, 1, nil); !errors.As(err, &invalid) {
+		t.Errorf("same node twice: %v", err)
+	}
+	var unknown *UnknownNodeError
+	if _, err := p.Thevenin(1, 3, nil); !errors.As(err, &unknown) {
+		t.Errorf("unknown second node: %v", err)
+	}
+	var dimension *DimensionError
+	if _, err := p.Thevenin(1, 0, []complex128{1}); !errors.As(err, &dimension) {
+		t.Errorf("short voltage vector: %v", err)
+	}
+}
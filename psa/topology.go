@@ -0,0 +1,325 @@
+package psa
+
+import (
+	"fmt"
+	"sort"
+)
+
+// 开关(断路器/隔离开关)
+type Switch struct {
+	Name string `json:"name"`
+	// 类型: breaker(断路器) 或 disconnector(隔离开关)
+	Kind  string `json:"kind"`
+	Node1 int    `json:"node_1"`
+	Node2 int    `json:"node_2"`
+	// 是否闭合
+	Closed bool `json:"closed"`
+}
+
+// 节点-断路器模型化简为节点-支路模型后的拓扑
+type Topology struct {
+	parent map[int]int
+	// 连接了元件的节点
+	used map[int]bool
+	// 物理节点号 -> 电气母线号, 与地合并的节点对应母线0, 不带电的节点对应-1
+	nodeToBus map[int]int
+	// busNodes[bus-1]为该母线包含的物理节点
+	busNodes [][]int
+	maxNode  int
+}
+
+func newTopology() *Topology {
+	t := &Topology{
+		parent:    map[int]int{},
+		used:      map[int]bool{},
+		nodeToBus: map[int]int{},
+	}
+	t.add(0)
+	return t
+}
+
+func (t *Topology) add(node int) {
+	if _, exist := t.parent[node]; !exist {
+		t.parent[node] = node
+	}
+	if node > t.maxNode {
+		t.maxNode = node
+	}
+}
+
+func (t *Topology) use(node int) {
+	t.add(node)
+	t.used[node] = true
+}
+
+func (t *Topology) find(node int) int {
+	for t.parent[node] != node {
+		t.parent[node] = t.parent[t.parent[node]]
+		node = t.parent[node]
+	}
+	return node
+}
+
+func (t *Topology) union(node1, node2 int) {
+	root1 := t.find(node1)
+	root2 := t.find(node2)
+	if root1 == root2 {
+		return
+	}
+	// 保证地节点始终为根
+	if root2 < root1 {
+		root1, root2 = root2, root1
+	}
+	t.parent[root2] = root1
+}
+
+// 按每组中最小的物理节点号排序后依次编号, 没有闭合开关时母线号与节点号相同
+func (t *Topology) numberBuses() {
+	nodes := make([]int, 0, len(t.parent))
+	for node := range t.parent {
+		nodes = append(nodes, node)
+	}
+	sort.Ints(nodes)
+	ground := t.find(0)
+	energized := map[int]bool{}
+	for node := range t.used {
+		energized[t.find(node)] = true
+	}
+	rootToBus := map[int]int{}
+	for _, node := range nodes {
+		root := t.find(node)
+		if root == ground {
+			t.nodeToBus[node] = 0
+			continue
+		}
+		// 只经断开的开关相连的节点不构成母线
+		if !energized[root] {
+			t.nodeToBus[node] = -1
+			continue
+		}
+		bus, exist := rootToBus[root]
+		if !exist {
+			t.busNodes = append(t.busNodes, nil)
+			bus = len(t.busNodes)
+			rootToBus[root] = bus
+		}
+		t.nodeToBus[node] = bus
+		t.busNodes[bus-1] = append(t.busNodes[bus-1], node)
+	}
+}
+
+// 物理节点所在的电气母线
+func (t *Topology) BusOf(node int) int {
+	return t.nodeToBus[node]
+}
+
+// 将按母线计算的结果映射回物理节点, 下标为物理节点号-1
+func (t *Topology) ToNodeValues(busValues []complex128) []complex128 {
+	values := make([]complex128, t.maxNode)
+	for node, bus := range t.nodeToBus {
+		if node == 0 || bus <= 0 {
+			continue
+		}
+		values[node-1] = busValues[bus-1]
+	}
+	return values
+}
+
+// 每条母线包含的物理节点, 下标为母线号-1
+func (t *Topology) BusNodes() [][]int {
+	return t.busNodes
+}
+
+// 母线中最小的物理节点号, 用于按物理节点标注按母线计算的结果
+func (t *Topology) NodeOf(bus int) int {
+	if bus <= 0 || bus > len(t.busNodes) {
+		return bus
+	}
+	return t.busNodes[bus-1][0]
+}
+
+// 是否每条母线只含一个节点, 并且母线号与节点号相同, 即没有经开关合并或重新编号
+func (t *Topology) Identity() bool {
+	for i, nodes := range t.busNodes {
+		if len(nodes) != 1 || nodes[0] != i+1 {
+			return false
+		}
+	}
+	return true
+}
+
+// 合并由闭合开关连接的节点, 返回只含电气母线的网络
+func reduceTopology(network PowerNetwork) (PowerNetwork, *Topology) {
+	t := newTopology()
+	if network.SG != nil {
+		t.use(network.SG.Node)
+	}
+	for _, generator := range network.PowerGenerators {
+		t.use(generator.Node)
+	}
+	for _, circuit := range network.Circuits {
+		t.use(circuit.Node1)
+		t.use(circuit.Node2)
+	}
+	for _, transformer := range network.Transformers {
+		t.use(transformer.Node1)
+		t.use(transformer.Node2)
+	}
+	for _, ld := range network.Lds {
+		t.use(ld.Node)
+	}
+	for _, motor := range network.Motors {
+		t.use(motor.Node)
+	}
+	for _, branch := range network.Branches {
+		t.use(branch.Node1)
+		t.use(branch.Node2)
+	}
+	for _, bus := range network.Buses {
+		t.add(bus.Node)
+	}
+	for _, breaker := range network.Breakers {
+		t.add(breaker.Node)
+	}
+	for _, relay := range network.Relays {
+		t.add(relay.Node1)
+		t.add(relay.Node2)
+	}
+	for _, relay := range network.DistanceRelays {
+		t.add(relay.Node1)
+		t.add(relay.Node2)
+	}
+	for _, s := range network.Switches {
+		t.add(s.Node1)
+		t.add(s.Node2)
+		if s.Closed {
+			t.union(s.Node1, s.Node2)
+		}
+	}
+	t.numberBuses()
+
+	reduced := network
+	reduced.Switches = nil
+	reduced.PowerGenerators = nil
+	reduced.Circuits = nil
+	reduced.Transformers = nil
+	reduced.Branches = nil
+	reduced.Lds = nil
+	reduced.Motors = nil
+	reduced.Buses = nil
+	reduced.Breakers = nil
+	reduced.Relays = nil
+	reduced.DistanceRelays = nil
+	if network.SG != nil {
+		sg := *network.SG
+		sg.Node = t.BusOf(sg.Node)
+		reduced.SG = &sg
+	}
+	for _, generator := range network.PowerGenerators {
+		generator.Node = t.BusOf(generator.Node)
+		// 接地的发电机不再有支路
+		if generator.Node != 0 {
+			reduced.PowerGenerators = append(reduced.PowerGenerators, generator)
+		}
+	}
+	for _, circuit := range network.Circuits {
+		circuit.Node1 = t.BusOf(circuit.Node1)
+		circuit.Node2 = t.BusOf(circuit.Node2)
+		// 两端被开关短接的线路不流过电流
+		if circuit.Node1 != circuit.Node2 {
+			reduced.Circuits = append(reduced.Circuits, circuit)
+		}
+	}
+	for _, transformer := range network.Transformers {
+		transformer.Node1 = t.BusOf(transformer.Node1)
+		transformer.Node2 = t.BusOf(transformer.Node2)
+		if transformer.Node1 != transformer.Node2 {
+			reduced.Transformers = append(reduced.Transformers, transformer)
+		}
+	}
+	for _, ld := range network.Lds {
+		ld.Node = t.BusOf(ld.Node)
+		if ld.Node != 0 {
+			reduced.Lds = append(reduced.Lds, ld)
+		}
+	}
+	for _, motor := range network.Motors {
+		motor.Node = t.BusOf(motor.Node)
+		if motor.Node != 0 {
+			reduced.Motors = append(reduced.Motors, motor)
+		}
+	}
+	for _, branch := range network.Branches {
+		branch.Node1 = t.BusOf(branch.Node1)
+		branch.Node2 = t.BusOf(branch.Node2)
+		if branch.Node1 != branch.Node2 {
+			reduced.Branches = append(reduced.Branches, branch)
+		}
+	}
+	for _, bus := range network.Buses {
+		bus.Node = t.BusOf(bus.Node)
+		// 同一母线上的并联导纳分别保留
+		if bus.Node > 0 {
+			reduced.Buses = append(reduced.Buses, bus)
+		}
+	}
+	for _, breaker := range network.Breakers {
+		breaker.Node = t.BusOf(breaker.Node)
+		// 接地或不带电母线上的断路器不开断短路电流
+		if breaker.Node > 0 {
+			reduced.Breakers = append(reduced.Breakers, breaker)
+		}
+	}
+	// 继电器全部保留, 两端在同一母线上时不流过电流
+	for _, relay := range network.Relays {
+		relay.Node1 = t.BusOf(relay.Node1)
+		relay.Node2 = t.BusOf(relay.Node2)
+		reduced.Relays = append(reduced.Relays, relay)
+	}
+	for _, relay := range network.DistanceRelays {
+		relay.Node1 = t.BusOf(relay.Node1)
+		relay.Node2 = t.BusOf(relay.Node2)
+		reduced.DistanceRelays = append(reduced.DistanceRelays, relay)
+	}
+	return reduced, t
+}
+
+// 分别合并各序网络中由闭合开关连接的节点, 短路点和距离继电器换算为各序网络的母线号,
+// 返回的拓扑依次为正序、负序和零序网络的拓扑
+func (network SequenceNetwork) ReduceTopology() (SequenceNetwork, [3]*Topology, error) {
+	switches0 := network.Switches0
+	if len(switches0) == 0 {
+		switches0 = network.Switches
+	}
+	var topologies [3]*Topology
+	reduced := network
+	reduced.Switches = nil
+	reduced.Switches0 = nil
+	grids := []struct {
+		name     string
+		branches *[]Branch
+		f        *int
+		switches []Switch
+	}{{"正序", &reduced.Grid1, &reduced.F1, network.Switches}, {"负序", &reduced.Grid2, &reduced.F2, network.Switches},
+		{"零序", &reduced.Grid0, &reduced.F0, switches0}}
+	for i, grid := range grids {
+		reducedGrid, t := reduceTopology(PowerNetwork{Branches: *grid.branches, Switches: grid.switches})
+		bus := t.BusOf(*grid.f)
+		if bus <= 0 {
+			return network, topologies, fmt.Errorf("%s网络的短路点%d已接地或不带电", grid.name, *grid.f)
+		}
+		*grid.branches = reducedGrid.Branches
+		*grid.f = bus
+		topologies[i] = t
+	}
+	reduced.DistanceRelays = nil
+	for _, relay := range network.DistanceRelays {
+		n01, n02 := relay.zeroNodes()
+		relay.Node1 = topologies[0].BusOf(relay.Node1)
+		relay.Node2 = topologies[0].BusOf(relay.Node2)
+		relay.Node01 = topologies[2].BusOf(n01)
+		relay.Node02 = topologies[2].BusOf(n02)
+		reduced.DistanceRelays = append(reduced.DistanceRelays, relay)
+	}
+	return reduced, topologies, nil
+}
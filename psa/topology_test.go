@@ -0,0 +1,115 @@
+package psa
+
+import (
+	"math/cmplx"
+	"reflect"
+	"testing"
+)
+
+const testTolerance = 1e-9
+
+func assertComplex(t *testing.T, name string, got, want complex128) {
+	t.Helper()
+	if cmplx.Abs(got-want) > testTolerance*(1+cmplx.Abs(want)) {
+		t.Errorf("%s = %v, want %v", name, got, want)
+	}
+}
+
+func assertFloat(t *testing.T, name string, got, want, tolerance float64) {
+	t.Helper()
+	if d := got - want; d > tolerance || d < -tolerance {
+		t.Errorf("%s = %v, want %v", name, got, want)
+	}
+}
+
+// 电源x=0.1接在节点1, 1-2和3-4的线路x=0.2, 开关2-3闭合、4-5断开
+func switchedNetwork() PowerNetwork {
+	return PowerNetwork{
+		Branches: []Branch{
+			{Node1: 1, Reactance: 0.1, E: 1},
+			{Node1: 1, Node2: 2, Reactance: 0.2},
+			{Node1: 3, Node2: 4, Reactance: 0.2},
+		},
+		Switches: []Switch{
+			{Name: "Q1", Kind: "breaker", Node1: 2, Node2: 3, Closed: true},
+			{Name: "Q2", Kind: "disconnector", Node1: 4, Node2: 5},
+		},
+	}
+}
+
+func TestReduceTopologyMergesClosedSwitches(t *testing.T) {
+	p, err := NewParser(switchedNetwork())
+	if err != nil {
+		t.Fatal(err)
+	}
+	topology := p.Topology()
+	if p.NodeNum() != 3 {
+		t.Fatalf("NodeNum = %d, want 3", p.NodeNum())
+	}
+	if !reflect.DeepEqual(topology.BusNodes(), [][]int{{1}, {2, 3}, {4}}) {
+		t.Errorf("BusNodes = %v", topology.BusNodes())
+	}
+	// 只经断开的开关相连的节点不带电
+	if bus := topology.BusOf(5); bus != -1 {
+		t.Errorf("BusOf(5) = %d, want -1", bus)
+	}
+	if node := topology.NodeOf(2); node != 2 {
+		t.Errorf("NodeOf(2) = %d, want 2", node)
+	}
+	if topology.Identity() {
+		t.Error("Identity() = true for a network with a closed switch")
+	}
+	if err := p.ComputeResult(); err != nil {
+		t.Fatal(err)
+	}
+	// 节点4经0.1+0.2+0.2接到电源
+	f := topology.BusOf(4)
+	assertComplex(t, "Z44", p.ResultZ().At(f, f), 0.5i)
+	U, err := p.ComputeAllNodeShortU(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 节点2和3在同一母线上, 电压都是 1 - Z(2,4)/Z44 = 1 - 0.3/0.5 = 0.4
+	values := topology.ToNodeValues(U)
+	assertComplex(t, "V2", values[1], 0.4)
+	assertComplex(t, "V3", values[2], 0.4)
+	assertComplex(t, "V5", values[4], 0)
+}
+
+func TestSequenceNetworkReduceTopology(t *testing.T) {
+	grid := []Branch{
+		{Node1: 1, Reactance: 0.1},
+		{Node1: 1, Node2: 2, Reactance: 0.2},
+		{Node1: 3, Node2: 4, Reactance: 0.2},
+	}
+	network := SequenceNetwork{
+		Grid1: grid, F1: 4,
+		Grid2: grid, F2: 4,
+		// 零序网络中2-3之间的开关断开, 3和4不与2合并
+		Grid0: grid, F0: 2,
+		Switches:       []Switch{{Node1: 2, Node2: 3, Closed: true}},
+		Switches0:      []Switch{{Node1: 2, Node2: 3}},
+		DistanceRelays: []DistanceRelay{{Node1: 3, Node2: 4}},
+	}
+	reduced, topologies, err := network.ReduceTopology()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reduced.F1 != 3 || reduced.F2 != 3 || reduced.F0 != 2 {
+		t.Errorf("F1, F2, F0 = %d, %d, %d, want 3, 3, 2", reduced.F1, reduced.F2, reduced.F0)
+	}
+	if len(reduced.Grid1) != 3 || reduced.Grid1[2].Node1 != 2 || reduced.Grid1[2].Node2 != 3 {
+		t.Errorf("Grid1 = %v", reduced.Grid1)
+	}
+	relay := reduced.DistanceRelays[0]
+	if relay.Node1 != 2 || relay.Node2 != 3 || relay.Node01 != 3 || relay.Node02 != 4 {
+		t.Errorf("relay nodes = %d-%d, zero sequence %d-%d", relay.Node1, relay.Node2, relay.Node01, relay.Node02)
+	}
+	if topologies[2].BusOf(3) == topologies[2].BusOf(2) {
+		t.Error("open zero-sequence switch merged nodes 2 and 3")
+	}
+	network.F0 = 5
+	if _, _, err := network.ReduceTopology(); err == nil {
+		t.Error("fault on a node outside the zero-sequence network was accepted")
+	}
+}
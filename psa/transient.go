@@ -0,0 +1,220 @@
+package psa
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// 采样点数的上限, 避免时间窗口和步长不合理时占用过多内存
+const maxTraceSamples = 1000000
+
+// 短路电流随时间变化的计算参数
+type TraceOptions struct {
+	// 时间窗口(s), 为0时取1s
+	Duration float64
+	// 采样步长(s), 为0时取1ms
+	Step float64
+	// 系统频率(Hz), 为0时取50Hz
+	Frequency float64
+}
+
+func (o TraceOptions) withDefaults() TraceOptions {
+	if o.Duration == 0 {
+		o.Duration = 1
+	}
+	if o.Step == 0 {
+		o.Step = 0.001
+	}
+	if o.Frequency == 0 {
+		o.Frequency = 50
+	}
+	return o
+}
+
+// 时间、步长和频率等参数必须是大于0的有限值, NaN和+Inf都不满足
+func positive(values ...float64) bool {
+	for _, v := range values {
+		if !(v > 0) || math.IsInf(v, 1) {
+			return false
+		}
+	}
+	return true
+}
+
+func (o TraceOptions) validate() error {
+	if !positive(o.Duration, o.Step, o.Frequency) {
+		return fmt.Errorf("时间窗口、步长和频率必须是大于0的有限值")
+	}
+	if o.Duration/o.Step > maxTraceSamples {
+		return fmt.Errorf("采样点数超过%d, 需要增大步长或缩短时间窗口", maxTraceSamples)
+	}
+	return nil
+}
+
+// 三相短路电流随时间的变化, 电流为标幺值
+type FaultTrace struct {
+	// 次暂态、暂态和稳态短路电流的有效值
+	Isubtransient float64
+	Itransient    float64
+	Isteady       float64
+	// 按各发电机次暂态电流加权的时间常数(s), 没有发电机给出Ta时按短路点的X/(ωR)计算, 纯电抗时为+Inf
+	Tdpp, Tdp, Ta float64
+	// 采样时刻(s)
+	Time []float64
+	// 交流分量幅值与直流分量之和, 即电流的上包络线
+	Envelope []float64
+	// 直流分量
+	DC []float64
+	// 瞬时电流, 按直流分量最大的情况(短路瞬间电压过零)
+	Current []float64
+}
+
+// 把各发电机的电抗换成暂态或同步电抗后建立网络, 电动机的交流分量已经衰减到0, 不再计入,
+// 系统电源SG和负荷的电抗各阶段相同
+func (p *Parser) stageParser(reactance func(PowerGenerator) float64) (*Parser, error) {
+	network := p.network
+	network.PowerGenerators = append([]PowerGenerator(nil), network.PowerGenerators...)
+	for i := range network.PowerGenerators {
+		network.PowerGenerators[i].Xd = reactance(network.PowerGenerators[i])
+	}
+	network.Motors = nil
+	q := &Parser{SB: p.SB, Vav: p.Vav, network: network, topology: p.topology, nodeNum: p.nodeNum}
+	q.parsePowerNetwork()
+	q.init()
+	q.PivotTolerance, q.Pivoting, q.Solver, q.Verify = p.PivotTolerance, p.Pivoting, p.Solver, p.Verify
+	if err := q.ComputeResult(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func transientReactance(generator PowerGenerator) float64 {
+	if generator.Xdp != 0 {
+		return generator.Xdp
+	}
+	return generator.Xd
+}
+
+func synchronousReactance(generator PowerGenerator) float64 {
+	if generator.Xds != 0 {
+		return generator.Xds
+	}
+	return transientReactance(generator)
+}
+
+// 计算节点f三相短路后电流的次暂态、暂态和稳态分量及其在时间窗口内的采样波形,
+// 需要先计算阻抗矩阵, 发电机的Xd作为次暂态电抗, 没有给出xdp或xds时该阶段不衰减
+func (p *Parser) ComputeFaultTrace(f int, opts TraceOptions) (*FaultTrace, error) {
+	opts = opts.withDefaults()
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	Zff, err := p.zff(f)
+	if err != nil {
+		return nil, err
+	}
+	transient, err := p.stageParser(transientReactance)
+	if err != nil {
+		return nil, fmt.Errorf("暂态网络: %w", err)
+	}
+	steady, err := p.stageParser(synchronousReactance)
+	if err != nil {
+		return nil, fmt.Errorf("稳态网络: %w", err)
+	}
+	Zp, err := transient.zff(f)
+	if err != nil {
+		return nil, err
+	}
+	Zs, err := steady.zff(f)
+	if err != nil {
+		return nil, err
+	}
+	t := &FaultTrace{
+		Isubtransient: 1 / cmplx.Abs(Zff),
+		Itransient:    1 / cmplx.Abs(Zp),
+		Isteady:       1 / cmplx.Abs(Zs),
+	}
+	omega := 2 * math.Pi * opts.Frequency
+	p.traceTimeConstants(f, Zff, t)
+	if t.Ta == 0 {
+		t.Ta = math.Inf(1)
+		if real(Zff) > 0 {
+			t.Ta = imag(Zff) / (omega * real(Zff))
+		}
+	}
+	n := int(math.Floor(opts.Duration/opts.Step+1e-9)) + 1
+	t.Time = make([]float64, n)
+	t.Envelope = make([]float64, n)
+	t.DC = make([]float64, n)
+	t.Current = make([]float64, n)
+	for i := 0; i < n; i++ {
+		time := float64(i) * opts.Step
+		// ac(t) = √2[(I''-I')e^(-t/Td'') + (I'-I)e^(-t/Td') + I], dc(t) = √2 I'' e^(-t/Ta)
+		ac := math.Sqrt2 * ((t.Isubtransient-t.Itransient)*decay(time, t.Tdpp) +
+			(t.Itransient-t.Isteady)*decay(time, t.Tdp) + t.Isteady)
+		dc := math.Sqrt2 * t.Isubtransient * decay(time, t.Ta)
+		t.Time[i] = time
+		t.Envelope[i] = dc + ac
+		t.DC[i] = dc
+		t.Current[i] = dc - ac*math.Cos(omega*time)
+	}
+	return t, nil
+}
+
+// 以各发电机的次暂态短路电流 |Zgf/Zff|/xd” 为权重平均发电机的时间常数
+func (p *Parser) traceTimeConstants(f int, Zff complex128, t *FaultTrace) {
+	var tdpp, tdp, ta, wdpp, wdp, wa float64
+	for _, generator := range p.network.PowerGenerators {
+		g := generator.Node
+		sn := generator.Sn
+		if sn == 0 && generator.Cos != 0 {
+			sn = generator.Pn / generator.Cos
+		}
+		if g <= 0 || g > p.nodeNum || sn == 0 || generator.Xd == 0 {
+			continue
+		}
+		w := cmplx.Abs(p.resultZ.rcAt(g, f)/Zff) / (generator.Xd * p.SB / sn)
+		if generator.Xdp != 0 {
+			tdpp += w * generator.Tdpp
+			wdpp += w
+		}
+		if generator.Xds != 0 {
+			tdp += w * generator.Tdp
+			wdp += w
+		}
+		if generator.Ta != 0 {
+			ta += w * generator.Ta
+			wa += w
+		}
+	}
+	// 电动机的电流在次暂态阶段按各自的时间常数衰减到0
+	for _, motor := range p.network.Motors {
+		branch := p.motorBranch(motor)
+		z := complex(branch.Resistance, branch.Reactance)
+		w := cmplx.Abs(p.resultZ.rcAt(motor.Node, f)/Zff) * branch.E / cmplx.Abs(z)
+		T := motor.T
+		if T == 0 {
+			T = defaultMotorT
+		}
+		tdpp += w * T
+		wdpp += w
+	}
+	if wdpp > 0 {
+		t.Tdpp = tdpp / wdpp
+	}
+	if wdp > 0 {
+		t.Tdp = tdp / wdp
+	}
+	if wa > 0 {
+		t.Ta = ta / wa
+	}
+}
+
+// e^(-t/T), T为0时该分量不存在, 为+Inf时不衰减
+func decay(t, T float64) float64 {
+	if T == 0 {
+		return 0
+	}
+	return math.Exp(-t / T)
+}
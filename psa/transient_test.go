@@ -0,0 +1,77 @@
+package psa
+
+import (
+	"math"
+	"testing"
+)
+
+// 100MVA的发电机接在节点1: xd”=0.2, xd'=0.3, xd=1.0, Td”=0.03s, Td'=1s, Ta=0.2s
+func generatorNetwork() PowerNetwork {
+	return PowerNetwork{SB: 100, PowerGenerators: []PowerGenerator{{
+		Node: 1, Sn: 100, Xd: 0.2, Xdp: 0.3, Xds: 1.0, Tdpp: 0.03, Tdp: 1, Ta: 0.2,
+	}}}
+}
+
+func TestFaultTrace(t *testing.T) {
+	p, err := NewParser(generatorNetwork())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ComputeResult(); err != nil {
+		t.Fatal(err)
+	}
+	trace, err := p.ComputeFaultTrace(1, TraceOptions{Duration: 0.1, Step: 0.05})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// I” = 1/0.2, I' = 1/0.3, I = 1/1.0
+	assertFloat(t, "I''", trace.Isubtransient, 5, 1e-9)
+	assertFloat(t, "I'", trace.Itransient, 1/0.3, 1e-9)
+	assertFloat(t, "I", trace.Isteady, 1, 1e-9)
+	assertFloat(t, "Td''", trace.Tdpp, 0.03, 1e-12)
+	assertFloat(t, "Td'", trace.Tdp, 1, 1e-12)
+	assertFloat(t, "Ta", trace.Ta, 0.2, 1e-12)
+	if len(trace.Time) != 3 || trace.Time[2] != 0.1 {
+		t.Fatalf("Time = %v, want [0 0.05 0.1]", trace.Time)
+	}
+	// t = 0时交流和直流分量的幅值都是√2 I”, 瞬时电流为0
+	assertFloat(t, "envelope(0)", trace.Envelope[0], 10*math.Sqrt2, 1e-9)
+	assertFloat(t, "i(0)", trace.Current[0], 0, 1e-9)
+	// t = 0.1s: ac = √2[(5-3.333)e^(-0.1/0.03) + 2.333e^(-0.1) + 1] = 4.48411,
+	// dc = √2*5e^(-0.5) = 4.28882, cos(ωt) = 1
+	assertFloat(t, "dc(0.1)", trace.DC[2], 4.288819425, 1e-8)
+	assertFloat(t, "envelope(0.1)", trace.Envelope[2], 8.772928542, 1e-8)
+	assertFloat(t, "i(0.1)", trace.Current[2], -0.195289692, 1e-8)
+}
+
+func TestFaultTraceDefaults(t *testing.T) {
+	// 电源支路没有时间常数时Ta按 X/(ωR) = 0.1/(100π*0.01)
+	p, err := NewParser(PowerNetwork{Branches: []Branch{{Node1: 1, Resistance: 0.01, Reactance: 0.1, E: 1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ComputeResult(); err != nil {
+		t.Fatal(err)
+	}
+	trace, err := p.ComputeFaultTrace(1, TraceOptions{Duration: 0.01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFloat(t, "Ta", trace.Ta, 0.1/(100*math.Pi*0.01), 1e-12)
+	// 没有发电机时各阶段的电流相同
+	assertFloat(t, "I", trace.Isteady, trace.Isubtransient, 1e-12)
+	if len(trace.Time) != 11 {
+		t.Errorf("%d samples with the default 1ms step, want 11", len(trace.Time))
+	}
+	if _, err := p.ComputeFaultTrace(1, TraceOptions{Duration: 10, Step: 1e-6}); err == nil {
+		t.Error("10 million samples were accepted")
+	}
+	if _, err := p.ComputeFaultTrace(1, TraceOptions{Step: -1}); err == nil {
+		t.Error("negative step was accepted")
+	}
+	for _, step := range []float64{math.NaN(), math.Inf(1)} {
+		if _, err := p.ComputeFaultTrace(1, TraceOptions{Step: step}); err == nil {
+			t.Errorf("step %v was accepted", step)
+		}
+	}
+}
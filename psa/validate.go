@@ -0,0 +1,165 @@
+package psa
+
+import (
+	"fmt"
+	"math"
+)
+
+// 检查网络数据, 返回发现的第一个错误
+func (network *PowerNetwork) Validate() error {
+	hasComponents := network.SG != nil || len(network.Lds) > 0 || len(network.PowerGenerators) > 0 ||
+		len(network.Circuits) > 0 || len(network.Transformers) > 0 || len(network.Motors) > 0
+	if !hasComponents && len(network.Branches) == 0 {
+		return fmt.Errorf("网络中没有元件和支路")
+	}
+	if hasComponents && network.SB <= 0 {
+		return fmt.Errorf("基准容量SB必须大于0")
+	}
+	if network.SG != nil && network.SG.VB <= 0 {
+		return fmt.Errorf("系统电源的基准电压VB必须大于0")
+	}
+	for i, circuit := range network.Circuits {
+		if err := checkNodes("线路", i, circuit.Node1, circuit.Node2); err != nil {
+			return err
+		}
+		if circuit.VB <= 0 && network.Vav <= 0 {
+			return fmt.Errorf("第%d条线路没有基准电压, 需要给出VB或Vav", i+1)
+		}
+		if (circuit.R == 0 && circuit.X == 0) || circuit.L <= 0 {
+			return fmt.Errorf("第%d条线路的阻抗为0", i+1)
+		}
+	}
+	for i, generator := range network.PowerGenerators {
+		if err := checkNodes("发电机", i, generator.Node, 0); err != nil {
+			return err
+		}
+		if generator.Sn <= 0 && (generator.Pn <= 0 || generator.Cos <= 0) {
+			return fmt.Errorf("第%d台发电机需要给出Sn, 或者Pn和cos", i+1)
+		}
+		if generator.Xdp != 0 && (generator.Xdp < generator.Xd || generator.Tdpp <= 0) {
+			return fmt.Errorf("第%d台发电机的xdp不能小于xd, 并且需要给出Tdpp", i+1)
+		}
+		if generator.Xds != 0 && (generator.Xds < math.Max(generator.Xd, generator.Xdp) || generator.Tdp <= 0) {
+			return fmt.Errorf("第%d台发电机的xds不能小于xd和xdp, 并且需要给出Tdp", i+1)
+		}
+		if generator.Tdpp < 0 || generator.Tdp < 0 || generator.Ta < 0 {
+			return fmt.Errorf("第%d台发电机的时间常数不能为负数", i+1)
+		}
+		if generator.H < 0 || generator.D < 0 {
+			return fmt.Errorf("第%d台发电机的H和D不能为负数", i+1)
+		}
+	}
+	for i, transformer := range network.Transformers {
+		if err := checkNodes("变压器", i, transformer.Node1, transformer.Node2); err != nil {
+			return err
+		}
+		if transformer.Sn <= 0 || transformer.Vs <= 0 {
+			return fmt.Errorf("第%d台变压器的Sn和Vs必须大于0", i+1)
+		}
+		if transformer.Vr < 0 || transformer.Vr > transformer.Vs || transformer.Ratio < 0 {
+			return fmt.Errorf("第%d台变压器的Vr应在0和Vs之间, 变比不能为负数", i+1)
+		}
+	}
+	for i, ld := range network.Lds {
+		if err := checkNodes("负荷", i, ld.Node, 0); err != nil {
+			return err
+		}
+		if ld.Ld <= 0 {
+			return fmt.Errorf("第%d个负荷的容量必须大于0", i+1)
+		}
+	}
+	for i, motor := range network.Motors {
+		if err := checkNodes("电动机", i, motor.Node, 0); err != nil {
+			return err
+		}
+		if motor.KW <= 0 || motor.LockedRotor <= 0 || motor.XR <= 0 {
+			return fmt.Errorf("第%d台电动机的kW、ILR和XR必须大于0", i+1)
+		}
+		if motor.Efficiency <= 0 || motor.Efficiency > 1 || motor.Cos <= 0 || motor.Cos > 1 {
+			return fmt.Errorf("第%d台电动机的效率和功率因数应在0到1之间", i+1)
+		}
+		if motor.Count < 0 || motor.T < 0 || motor.RPM < 0 {
+			return fmt.Errorf("第%d台电动机的台数、时间常数和转速不能为负数", i+1)
+		}
+	}
+	for i, breaker := range network.Breakers {
+		if breaker.Node <= 0 {
+			return fmt.Errorf("第%d台断路器的节点号必须大于0", i+1)
+		}
+		if breaker.InterruptingKA <= 0 {
+			return fmt.Errorf("第%d台断路器的额定开断电流必须大于0", i+1)
+		}
+		if _, ok := contactParting[breaker.Cycles]; !ok {
+			return fmt.Errorf("第%d台断路器的额定开断时间应为2、3、5或8周波", i+1)
+		}
+	}
+	for i, relay := range network.Relays {
+		if relay.Node1 <= 0 || relay.Node2 <= 0 || relay.Node1 == relay.Node2 {
+			return fmt.Errorf("第%d个继电器应装在两个不同节点之间的支路上", i+1)
+		}
+		if relay.CT <= 0 || relay.Pickup <= 0 || relay.TimeDial <= 0 {
+			return fmt.Errorf("第%d个继电器的ct、pickup和time_dial必须大于0", i+1)
+		}
+		if _, ok := RelayCurves[relay.Curve]; !ok {
+			return fmt.Errorf("第%d个继电器的特性曲线未知: %s", i+1, relay.Curve)
+		}
+	}
+	if err := validateDistanceRelays(network.DistanceRelays); err != nil {
+		return err
+	}
+	return validateBranches("支路", network.Branches)
+}
+
+// 检查各序网络和短路点
+func (network *SequenceNetwork) Validate() error {
+	grids := []struct {
+		name     string
+		branches []Branch
+		f        int
+	}{{"正序", network.Grid1, network.F1}, {"负序", network.Grid2, network.F2}, {"零序", network.Grid0, network.F0}}
+	for _, grid := range grids {
+		if len(grid.branches) == 0 {
+			return fmt.Errorf("缺少%s网络数据", grid.name)
+		}
+		if err := validateBranches(grid.name+"支路", grid.branches); err != nil {
+			return err
+		}
+		if grid.f <= 0 || grid.f > maxNode(grid.branches) {
+			return fmt.Errorf("短路点%d不在%s网络中", grid.f, grid.name)
+		}
+	}
+	return nil
+}
+
+func validateBranches(name string, branches []Branch) error {
+	for i, branch := range branches {
+		if err := checkNodes(name, i, branch.Node1, branch.Node2); err != nil {
+			return err
+		}
+		// 接地支路阻抗为0时不计入, 节点之间的支路不能为0
+		if branch.Node1 != 0 && branch.Node2 != 0 && branch.Resistance == 0 && branch.Reactance == 0 {
+			return fmt.Errorf("第%d条%s的阻抗为0", i+1, name)
+		}
+	}
+	return nil
+}
+
+func checkNodes(name string, i int, node1, node2 int) error {
+	if node1 < 0 || node2 < 0 {
+		return fmt.Errorf("第%d个%s的节点号不能为负数", i+1, name)
+	}
+	return nil
+}
+
+func maxNode(branches []Branch) int {
+	n := 0
+	for _, branch := range branches {
+		if branch.Node1 > n {
+			n = branch.Node1
+		}
+		if branch.Node2 > n {
+			n = branch.Node2
+		}
+	}
+	return n
+}
@@ -0,0 +1,984 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"power-system-analysis-labs/psa"
+)
+
+// POST /ybus[?node=N]: 节点导纳矩阵, 给出node时同时返回该节点三相短路后的导纳矩阵
+func handleYbus(r *http.Request) (*psa.Result, error) {
+	node, err := intParam(r, "node")
+	if err != nil {
+		return nil, err
+	}
+	parser, err := decodeNetwork(r)
+	if err != nil {
+		return nil, err
+	}
+	parser.ComputeResultY()
+	result, err := newResult(r, parser, parser.Vav)
+	if err != nil {
+		return nil, err
+	}
+	result.SetBuses(parser.Topology())
+	result.AddMatrix("Y", parser.ResultY(), psa.Admittance)
+	if node != 0 {
+		bus, err := busOf(parser, node)
+		if err != nil {
+			return nil, err
+		}
+		Y, err := parser.ShortCircuitMatrix(bus)
+		if err != nil {
+			return nil, calcError(err)
+		}
+		result.AddMatrix(fmt.Sprintf("Y_short_%d", node), Y, psa.Admittance)
+	}
+	return result, nil
+}
+
+// POST /zbus: 导纳矩阵、LDU分解和阻抗矩阵
+func handleZbus(r *http.Request) (*psa.Result, error) {
+	parser, err := decodeNetwork(r)
+	if err != nil {
+		return nil, err
+	}
+	result, err := newResult(r, parser, parser.Vav)
+	if err != nil {
+		return nil, err
+	}
+	if err := parser.ComputeResult(); err != nil {
+		return nil, calcError(err)
+	}
+	l, d, u, err := parser.LDU()
+	if err != nil {
+		return nil, calcError(err)
+	}
+	result.SetBuses(parser.Topology())
+	result.AddMatrix("Y", parser.ResultY(), psa.Admittance)
+	result.AddMatrix("L", l, psa.Dimensionless)
+	result.AddMatrix("D", d, psa.Admittance)
+	result.AddMatrix("U", u, psa.Dimensionless)
+	result.AddMatrix("Z", parser.ResultZ(), psa.Impedance)
+	cond, err := parser.ConditionNumber()
+	if err != nil {
+		return nil, err
+	}
+	result.AddValue("cond", complex(cond, 0), "")
+	return result, nil
+}
+
+// POST /fault3ph?node=N: 三相短路电流、各节点电压和各支路电流
+func handleFault3ph(r *http.Request) (*psa.Result, error) {
+	node, err := intParam(r, "node")
+	if err != nil {
+		return nil, err
+	}
+	if node <= 0 {
+		return nil, badRequest("缺少参数node")
+	}
+	parser, err := decodeNetwork(r)
+	if err != nil {
+		return nil, err
+	}
+	f, err := busOf(parser, node)
+	if err != nil {
+		return nil, err
+	}
+	result, err := newResult(r, parser, parser.Vav)
+	if err != nil {
+		return nil, err
+	}
+	if err := parser.ComputeResult(); err != nil {
+		return nil, calcError(err)
+	}
+	If, err := parser.ComputeShortIf(f)
+	if err != nil {
+		return nil, calcError(err)
+	}
+	result.SetBuses(parser.Topology())
+	result.AddMatrix("Y", parser.ResultY(), psa.Admittance)
+	result.AddMatrix("Z", parser.ResultZ(), psa.Impedance)
+	result.AddQuantity("If", If, psa.Current)
+	U, err := parser.ComputeAllNodeShortU(f)
+	if err != nil {
+		return nil, calcError(err)
+	}
+	result.SetBusVoltages(parser.Topology().ToNodeValues(U))
+	Iij, err := parser.ComputeIij(U)
+	if err != nil {
+		return nil, calcError(err)
+	}
+	// 支路电流按母线计算, 以母线中最小的物理节点号标注
+	t := parser.Topology()
+	Y := parser.ResultY()
+	for i := 1; i <= parser.NodeNum(); i++ {
+		for j := i + 1; j <= parser.NodeNum(); j++ {
+			if Y.At(i, j) != 0 {
+				result.AddBranchCurrent(t.NodeOf(i), t.NodeOf(j), Iij.At(i, j))
+			}
+		}
+	}
+	return result, nil
+}
+
+// POST /contrib?node=N[&vb=]: 三相短路时各电源的电流分布系数和提供的短路电流
+func handleContrib(r *http.Request) (*psa.Result, error) {
+	node, err := intParam(r, "node")
+	if err != nil {
+		return nil, err
+	}
+	if node <= 0 {
+		return nil, badRequest("缺少参数node")
+	}
+	vb, err := floatParam(r, "vb")
+	if err != nil {
+		return nil, err
+	}
+	parser, err := decodeNetwork(r)
+	if err != nil {
+		return nil, err
+	}
+	f, err := busOf(parser, node)
+	if err != nil {
+		return nil, err
+	}
+	result, err := newResult(r, parser, parser.Vav)
+	if err != nil {
+		return nil, err
+	}
+	if err := parser.ComputeResult(); err != nil {
+		return nil, calcError(err)
+	}
+	c, err := parser.ComputeContributions(f, vb)
+	if err != nil {
+		return nil, calcError(err)
+	}
+	result.AddQuantity("Zff", c.Zff, psa.Impedance)
+	result.AddQuantity("Uf", c.Uf, psa.Voltage)
+	result.AddQuantity("If", c.If, psa.Current)
+	for _, s := range c.Sources {
+		name := s.Name()
+		result.AddQuantity(name+".zf", s.Zf, psa.Impedance)
+		result.AddValue(name+".c", s.C, "")
+		result.AddQuantity(name+".I", s.I, psa.Current)
+		if s.KA != 0 {
+			result.AddValue(name+".kA", complex(s.KA, 0), "kA")
+		}
+	}
+	result.AddQuantity("sum", c.Sum, psa.Current)
+	result.AddValue("c.sum", c.CSum, "")
+	return result, nil
+}
+
+// 由一个序网的支路建立Parser并计算阻抗矩阵
+func sequenceParser(r *http.Request, branches []psa.Branch) (*psa.Parser, error) {
+	parser, err := psa.NewBranchParser(branches)
+	if err != nil {
+		return nil, unprocessable("%v", err)
+	}
+	if err := checkNodeNum(parser); err != nil {
+		return nil, err
+	}
+	if err := parser.UseSolver(r.URL.Query().Get("solver")); err != nil {
+		return nil, badRequest("%v", err)
+	}
+	if r.URL.Query().Get("verify") == "true" {
+		parser.Verify = true
+	}
+	if err := parser.ComputeResult(); err != nil {
+		return nil, calcError(err)
+	}
+	return parser, nil
+}
+
+// POST /distance[?seq=true&type=1ph][&points=0.2,0.5,0.8]: 距离继电器的测量阻抗和各段保护范围校验,
+// seq=true时请求体为各序网络
+func handleDistance(r *http.Request) (*psa.Result, error) {
+	faultType := r.URL.Query().Get("type")
+	if faultType == "" {
+		faultType = psa.Fault3Ph
+	}
+	var points []float64
+	if s := r.URL.Query().Get("points"); s != "" {
+		for _, field := range strings.Split(s, ",") {
+			x, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil {
+				return nil, badRequest("参数points应为x,y,z形式的数: %s", s)
+			}
+			points = append(points, x)
+		}
+	}
+	var checks []psa.DistanceCheck
+	if r.URL.Query().Get("seq") == "true" {
+		var network psa.SequenceNetwork
+		if err := decodeBody(r, &network); err != nil {
+			return nil, err
+		}
+		if err := checkMaxNode(network.MaxNode()); err != nil {
+			return nil, err
+		}
+		if err := network.Validate(); err != nil {
+			return nil, unprocessable("%v", err)
+		}
+		reduced, _, err := network.ReduceTopology()
+		if err != nil {
+			return nil, unprocessable("%v", err)
+		}
+		network = reduced
+		var parsers [3]*psa.Parser
+		for i, grid := range [][]psa.Branch{network.Grid1, network.Grid2, network.Grid0} {
+			parser, err := sequenceParser(r, grid)
+			if err != nil {
+				return nil, err
+			}
+			parsers[i] = parser
+		}
+		if checks, err = network.CheckDistanceRelays(parsers[0], parsers[1], parsers[2], faultType, points); err != nil {
+			return nil, distanceError(err)
+		}
+	} else {
+		if faultType != psa.Fault3Ph {
+			return nil, badRequest("不对称短路需要各序网络, 使用seq=true")
+		}
+		parser, err := decodeNetwork(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := parser.ComputeResult(); err != nil {
+			return nil, calcError(err)
+		}
+		if checks, err = parser.CheckDistanceRelays(points); err != nil {
+			return nil, distanceError(err)
+		}
+	}
+	// 测量阻抗是标幺值, 只能输出标幺值
+	result, err := newResult(r, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range checks {
+		name := fmt.Sprintf("%s.bus%d", c.Relay, c.Node)
+		if c.Location == psa.FaultInternal {
+			name = fmt.Sprintf("%s.x%g", c.Relay, c.X)
+		}
+		if !math.IsInf(c.Percent, 0) {
+			result.AddQuantity(name+".Z", c.Z, psa.Impedance)
+		}
+		for k := range c.Operate {
+			operate := 0.0
+			if c.Operate[k] {
+				operate = 1
+			}
+			result.AddValue(fmt.Sprintf("%s.zone%d", name, k+1), complex(operate, 0), "")
+		}
+		result.AddValue(name+".violations", complex(float64(len(c.Violations)), 0), "")
+	}
+	return result, nil
+}
+
+// 没有距离继电器、继电器不在线路上或短路点位置不正确也是输入的问题
+func distanceError(err error) error {
+	if e := calcError(err); e != err {
+		return e
+	}
+	return unprocessable("%v", err)
+}
+
+// POST /faultseq?type=1ph[&node=N]: 用对称分量法计算不对称短路, 请求体为各序网络
+func handleFaultseq(r *http.Request) (*psa.Result, error) {
+	node, err := intParam(r, "node")
+	if err != nil {
+		return nil, err
+	}
+	faultType := r.URL.Query().Get("type")
+	if faultType == "" {
+		faultType = psa.Fault1Ph
+	}
+	var network psa.SequenceNetwork
+	if err := decodeBody(r, &network); err != nil {
+		return nil, err
+	}
+	if err := checkMaxNode(network.MaxNode()); err != nil {
+		return nil, err
+	}
+	if node != 0 {
+		network.F1, network.F2, network.F0 = node, node, node
+	}
+	if err := network.Validate(); err != nil {
+		return nil, unprocessable("%v", err)
+	}
+	network, _, err = network.ReduceTopology()
+	if err != nil {
+		return nil, unprocessable("%v", err)
+	}
+	var Zff [3]complex128
+	for i, grid := range []struct {
+		branches []psa.Branch
+		f        int
+	}{{network.Grid1, network.F1}, {network.Grid2, network.F2}, {network.Grid0, network.F0}} {
+		parser, err := sequenceParser(r, grid.branches)
+		if err != nil {
+			return nil, err
+		}
+		Zff[i] = parser.ResultZ().At(grid.f, grid.f)
+	}
+	Ifa1, Ifa2, Ifa0, err := psa.ComputeSequenceFault(faultType, Zff[0], Zff[1], Zff[2], 0)
+	if err != nil {
+		return nil, badRequest("%v", err)
+	}
+	// 序网参数已经是标幺值, 只能输出标幺值
+	result, err := newResult(r, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	Ifa, Ifb, Ifc := psa.PhaseComponents(Ifa1, Ifa2, Ifa0)
+	Vfa, Vfb, Vfc := psa.PhaseComponents(1-Zff[0]*Ifa1, -Zff[1]*Ifa2, -Zff[2]*Ifa0)
+	result.AddQuantity("Zff(1)", Zff[0], psa.Impedance)
+	result.AddQuantity("Zff(2)", Zff[1], psa.Impedance)
+	result.AddQuantity("Zff(0)", Zff[2], psa.Impedance)
+	result.AddQuantity("Ifa(1)", Ifa1, psa.Current)
+	result.AddQuantity("Ifa(2)", Ifa2, psa.Current)
+	result.AddQuantity("Ifa(0)", Ifa0, psa.Current)
+	result.AddQuantity("Ifa", Ifa, psa.Current)
+	result.AddQuantity("Ifb", Ifb, psa.Current)
+	result.AddQuantity("Ifc", Ifc, psa.Current)
+	result.AddQuantity("Vfa", Vfa, psa.Voltage)
+	result.AddQuantity("Vfb", Vfb, psa.Voltage)
+	result.AddQuantity("Vfc", Vfc, psa.Voltage)
+	return result, nil
+}
+
+// POST /iec60909?node=N[&case=min][&method=A|B|C][&c=][&un=][&tmin=][&tk=][&f=]: 按IEC 60909计算三相短路电流
+func handleIEC60909(r *http.Request) (*psa.Result, error) {
+	node, err := intParam(r, "node")
+	if err != nil {
+		return nil, err
+	}
+	if node <= 0 {
+		return nil, badRequest("缺少参数node")
+	}
+	s := psa.IEC60909{Method: r.URL.Query().Get("method")}
+	switch r.URL.Query().Get("case") {
+	case "", "max":
+		s.Max = true
+	case "min":
+	default:
+		return nil, badRequest("未知的计算情况: %s", r.URL.Query().Get("case"))
+	}
+	for name, v := range map[string]*float64{"c": &s.C, "un": &s.Un, "tmin": &s.Tmin, "tk": &s.Tk, "f": &s.Frequency} {
+		if *v, err = floatParam(r, name); err != nil {
+			return nil, err
+		}
+	}
+	parser, err := decodeNetworkWith(r, func(network psa.PowerNetwork) (*psa.Parser, error) {
+		return psa.NewIEC60909Parser(network, s)
+	})
+	if err != nil {
+		return nil, err
+	}
+	f, err := busOf(parser, node)
+	if err != nil {
+		return nil, err
+	}
+	// 电流是以Vav为基准的标幺值, 已经按Un/Vav计入了标称电压; 没有Vav时按Un换算有名值
+	vb := parser.Vav
+	if vb == 0 {
+		vb = s.Un
+	}
+	result, err := newResult(r, parser, vb)
+	if err != nil {
+		return nil, err
+	}
+	if err := parser.ComputeResult(); err != nil {
+		return nil, calcError(err)
+	}
+	ir, err := parser.ComputeIEC60909(f)
+	if err != nil {
+		return nil, calcError(err)
+	}
+	result.AddValue("c", complex(ir.C, 0), "")
+	result.AddQuantity("Zk", ir.Zk, psa.Impedance)
+	// JSON不能表示无穷大, 纯电阻时不输出R/X
+	if !math.IsInf(ir.RX, 0) {
+		result.AddValue("R/X", complex(ir.RX, 0), "")
+	}
+	result.AddValue("kappa", complex(ir.Kappa, 0), "")
+	result.AddQuantity("Ik''", complex(ir.Ik, 0), psa.Current)
+	result.AddQuantity("ip", complex(ir.Ip, 0), psa.Current)
+	result.AddQuantity("Ib", complex(ir.Ib, 0), psa.Current)
+	result.AddQuantity("Ith", complex(ir.Ith, 0), psa.Current)
+	return result, nil
+}
+
+// POST /trace?node=N[&duration=1][&step=0.001][&f=50]: 三相短路电流随时间的变化和采样波形
+func handleTrace(r *http.Request) (*psa.Result, error) {
+	node, err := intParam(r, "node")
+	if err != nil {
+		return nil, err
+	}
+	if node <= 0 {
+		return nil, badRequest("缺少参数node")
+	}
+	var opts psa.TraceOptions
+	for name, v := range map[string]*float64{"duration": &opts.Duration, "step": &opts.Step, "f": &opts.Frequency} {
+		if *v, err = floatParam(r, name); err != nil {
+			return nil, err
+		}
+	}
+	parser, err := decodeNetwork(r)
+	if err != nil {
+		return nil, err
+	}
+	f, err := busOf(parser, node)
+	if err != nil {
+		return nil, err
+	}
+	result, err := newResult(r, parser, parser.Vav)
+	if err != nil {
+		return nil, err
+	}
+	if err := parser.ComputeResult(); err != nil {
+		return nil, calcError(err)
+	}
+	t, err := parser.ComputeFaultTrace(f, opts)
+	if err != nil {
+		// 时间窗口和步长不合理也是输入的问题
+		if e := calcError(err); e != err {
+			return nil, e
+		}
+		return nil, unprocessable("%v", err)
+	}
+	result.AddQuantity("I''", complex(t.Isubtransient, 0), psa.Current)
+	result.AddQuantity("I'", complex(t.Itransient, 0), psa.Current)
+	result.AddQuantity("I", complex(t.Isteady, 0), psa.Current)
+	result.AddValue("Td''", complex(t.Tdpp, 0), "s")
+	result.AddValue("Td'", complex(t.Tdp, 0), "s")
+	// JSON不能表示无穷大, 直流分量不衰减时不输出Ta
+	if !math.IsInf(t.Ta, 0) {
+		result.AddValue("Ta", complex(t.Ta, 0), "s")
+	}
+	result.SetTime(t.Time)
+	result.AddWaveform("envelope", t.Envelope, psa.Current)
+	result.AddWaveform("dc", t.DC, psa.Current)
+	result.AddWaveform("i", t.Current, psa.Current)
+	return result, nil
+}
+
+// POST /stability?node=N&clear=0.1[&trip=i,j][&duration=2][&step=0.001][&f=50][&method=rk4][&max_angle=180][&cct=true]:
+// 三相短路的暂态稳定仿真, 各电机的功角和转速曲线, cct=true时搜索临界切除时间
+func handleStability(r *http.Request) (*psa.Result, error) {
+	node, err := intParam(r, "node")
+	if err != nil {
+		return nil, err
+	}
+	if node <= 0 {
+		return nil, badRequest("缺少参数node")
+	}
+	query := r.URL.Query()
+	cct := query.Get("cct") == "true"
+	if query.Get("clear") == "" && !cct {
+		return nil, badRequest("缺少参数clear")
+	}
+	opts := psa.StabilityOptions{Method: query.Get("method")}
+	for name, v := range map[string]*float64{"clear": &opts.ClearTime, "duration": &opts.Duration, "step": &opts.Step, "f": &opts.Frequency, "max_angle": &opts.MaxAngle} {
+		if *v, err = floatParam(r, name); err != nil {
+			return nil, err
+		}
+	}
+	parser, err := decodeNetwork(r)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Fault, err = busOf(parser, node); err != nil {
+		return nil, err
+	}
+	if opts.TripNode1, opts.TripNode2, err = tripParam(r, parser); err != nil {
+		return nil, err
+	}
+	result, err := newResult(r, parser, parser.Vav)
+	if err != nil {
+		return nil, err
+	}
+	s, err := parser.SimulateStability(opts)
+	if err != nil {
+		// 没有发电机、缺少H或者仿真参数不合理也是输入的问题
+		if e := calcError(err); e != err {
+			return nil, e
+		}
+		return nil, unprocessable("%v", err)
+	}
+	if cct {
+		tc, err := parser.CriticalClearingTime(opts)
+		if err != nil {
+			return nil, calcError(err)
+		}
+		// JSON不能表示无穷大, 短路不切除也不失稳时不输出tcr
+		if !math.IsInf(tc, 1) {
+			result.AddValue("tcr", complex(tc, 0), "s")
+		}
+	}
+	for i, name := range s.Machines {
+		result.AddQuantity(name+".E'", s.E[i], psa.Voltage)
+		result.AddQuantity(name+".Pm", complex(s.Pm[i], 0), psa.Power)
+	}
+	result.AddValue("max_separation", complex(s.MaxSeparation, 0), "°")
+	stable := 0.0
+	if s.Stable {
+		stable = 1
+	}
+	result.AddValue("stable", complex(stable, 0), "")
+	result.AddMatrix("Y_pre", s.Pre, psa.Admittance)
+	result.AddMatrix("Y_during", s.During, psa.Admittance)
+	result.AddMatrix("Y_post", s.Post, psa.Admittance)
+	result.SetTime(s.Time)
+	for i, name := range s.Machines {
+		result.AddWaveformValue(name+".delta", s.Angles[i], "°")
+		result.AddWaveform(name+".omega", s.Speeds[i], psa.Dimensionless)
+	}
+	return result, nil
+}
+
+// 参数trip给出的线路两端节点, 没有给出时都为0
+func tripParam(r *http.Request, parser *psa.Parser) (int, int, error) {
+	trip := r.URL.Query().Get("trip")
+	if trip == "" {
+		return 0, 0, nil
+	}
+	fields := strings.Split(trip, ",")
+	if len(fields) != 2 {
+		return 0, 0, badRequest("参数trip应为i,j的形式: %s", trip)
+	}
+	var nodes [2]int
+	for i, field := range fields {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return 0, 0, badRequest("参数trip应为i,j的形式: %s", trip)
+		}
+		if nodes[i], err = busOf(parser, n); err != nil {
+			return 0, 0, err
+		}
+	}
+	return nodes[0], nodes[1], nil
+}
+
+// POST /eac?node=N[&gen=k][&trip=i,j][&clear=0.1][&f=50]: 单机无穷大系统的等面积定则,
+// 功角特性曲线以delta波形为横坐标
+func handleEAC(r *http.Request) (*psa.Result, error) {
+	node, err := intParam(r, "node")
+	if err != nil {
+		return nil, err
+	}
+	if node <= 0 {
+		return nil, badRequest("缺少参数node")
+	}
+	var opts psa.EqualAreaOptions
+	if opts.Generator, err = intParam(r, "gen"); err != nil {
+		return nil, err
+	}
+	if opts.ClearTime, err = floatParam(r, "clear"); err != nil {
+		return nil, err
+	}
+	if opts.Frequency, err = floatParam(r, "f"); err != nil {
+		return nil, err
+	}
+	parser, err := decodeNetwork(r)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Fault, err = busOf(parser, node); err != nil {
+		return nil, err
+	}
+	if opts.TripNode1, opts.TripNode2, err = tripParam(r, parser); err != nil {
+		return nil, err
+	}
+	result, err := newResult(r, parser, parser.Vav)
+	if err != nil {
+		return nil, err
+	}
+	a, err := parser.ComputeEqualArea(opts)
+	if err != nil {
+		// 没有无穷大母线、缺少H或者短路后不能保持同步也是输入的问题
+		if e := calcError(err); e != err {
+			return nil, e
+		}
+		return nil, unprocessable("%v", err)
+	}
+	result.AddQuantity(a.Machine+".E'", complex(a.E, 0), psa.Voltage)
+	result.AddQuantity("V", complex(a.V, 0), psa.Voltage)
+	result.AddValue("H", complex(a.H, 0), "s")
+	result.AddQuantity("Pm", complex(a.Pm, 0), psa.Power)
+	for _, c := range []struct {
+		name  string
+		curve psa.PowerAngleCurve
+	}{{"pre", a.Pre}, {"during", a.During}, {"post", a.Post}} {
+		result.AddQuantity(c.name+".Z", c.curve.Z, psa.Impedance)
+		result.AddQuantity(c.name+".Pc", complex(c.curve.Pc, 0), psa.Power)
+		result.AddQuantity(c.name+".Pmax", complex(c.curve.Pmax, 0), psa.Power)
+		result.AddValue(c.name+".gamma", complex(c.curve.Gamma*180/math.Pi, 0), "°")
+	}
+	result.AddValue("delta0", complex(a.Delta0, 0), "°")
+	result.AddValue("delta_max", complex(a.DeltaMax, 0), "°")
+	result.AddValue("delta_c", complex(a.CriticalAngle, 0), "°")
+	// JSON不能表示无穷大, 持续短路也不失稳时不输出tcr
+	if !math.IsInf(a.CriticalTime, 1) {
+		result.AddValue("tcr", complex(a.CriticalTime, 0), "s")
+	}
+	if opts.ClearTime > 0 {
+		result.AddValue("delta_clear", complex(a.ClearingAngle, 0), "°")
+		stable := 0.0
+		if a.Stable {
+			stable = 1
+		}
+		result.AddValue("stable", complex(stable, 0), "")
+	}
+	result.AddWaveformValue("delta", a.Angles, "°")
+	result.AddWaveform("Pe_pre", a.PePre, psa.Power)
+	result.AddWaveform("Pe_during", a.PeDuring, psa.Power)
+	result.AddWaveform("Pe_post", a.PePost, psa.Power)
+	return result, nil
+}
+
+// POST /modes[?f=50]: 小干扰稳定分析, 状态矩阵、各模式的特征值、频率、阻尼比和参与因子
+func handleModes(r *http.Request) (*psa.Result, error) {
+	frequency, err := floatParam(r, "f")
+	if err != nil {
+		return nil, err
+	}
+	parser, err := decodeNetwork(r)
+	if err != nil {
+		return nil, err
+	}
+	result, err := newResult(r, parser, parser.Vav)
+	if err != nil {
+		return nil, err
+	}
+	s, err := parser.ComputeSmallSignal(frequency)
+	if err != nil {
+		// 没有发电机或缺少H也是输入的问题
+		if e := calcError(err); e != err {
+			return nil, e
+		}
+		return nil, unprocessable("%v", err)
+	}
+	result.AddMatrix("A", s.A, psa.Dimensionless)
+	result.AddMatrix("K", s.K, psa.Power)
+	participation := psa.NewComplexMatrix(len(s.States), len(s.Modes))
+	for i, mode := range s.Modes {
+		name := fmt.Sprintf("mode%d", i+1)
+		result.AddValue(name+".lambda", mode.Eigenvalue, "")
+		result.AddValue(name+".f", complex(mode.Frequency, 0), "Hz")
+		result.AddValue(name+".zeta", complex(mode.Damping, 0), "")
+		for k, p := range mode.Participation {
+			participation.Set(k+1, i+1, complex(p, 0))
+		}
+	}
+	// 行为状态变量, 依次为各发电机的Δδ和Δω, 列为模式
+	result.AddMatrix("participation", participation, psa.Dimensionless)
+	stable := 0.0
+	if s.Stable {
+		stable = 1
+	}
+	result.AddValue("stable", complex(stable, 0), "")
+	return result, nil
+}
+
+// 参数name给出的节点列表i,j,k, 换算为节点号, 没有给出时为nil
+func nodesParam(r *http.Request, name string, parser *psa.Parser) ([]int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	var buses []int
+	for _, field := range strings.Split(value, ",") {
+		node, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, badRequest("参数%s应为i,j,k的形式: %s", name, value)
+		}
+		bus, err := busOf(parser, node)
+		if err != nil {
+			return nil, err
+		}
+		buses = append(buses, bus)
+	}
+	return buses, nil
+}
+
+// POST /cpf[?buses=i,j][&gen=true][&step=0.1][&max_steps=1000][&qv=i,j][&vmax=1.2][&vmin=0.5][&vstep=0.01]:
+// 连续潮流的P-V曲线和负荷裕度, P-V曲线以lambda波形为横坐标, qv给出的节点的Q-V曲线为QVi.V和QVi.Q
+func handleCPF(r *http.Request) (*psa.Result, error) {
+	opts := psa.CPFOptions{Generation: r.URL.Query().Get("gen") == "true"}
+	var qvOpts psa.QVOptions
+	var err error
+	for name, v := range map[string]*float64{"step": &opts.Step, "vmax": &qvOpts.Vmax, "vmin": &qvOpts.Vmin, "vstep": &qvOpts.Step} {
+		if *v, err = floatParam(r, name); err != nil {
+			return nil, err
+		}
+	}
+	if opts.MaxSteps, err = intParam(r, "max_steps"); err != nil {
+		return nil, err
+	}
+	parser, err := decodeNetwork(r)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Buses, err = nodesParam(r, "buses", parser); err != nil {
+		return nil, err
+	}
+	qvBuses, err := nodesParam(r, "qv", parser)
+	if err != nil {
+		return nil, err
+	}
+	result, err := newResult(r, parser, parser.Vav)
+	if err != nil {
+		return nil, err
+	}
+	// 没有母线数据、没有平衡节点或初始潮流不收敛也是输入的问题
+	inputError := func(err error) error {
+		if e := calcError(err); e != err {
+			return e
+		}
+		return unprocessable("%v", err)
+	}
+	c, err := parser.ComputePVCurve(opts)
+	if err != nil {
+		return nil, inputError(err)
+	}
+	result.AddValue("lambda_max", complex(c.LambdaMax, 0), "")
+	result.AddValue("P_max", complex(c.LoadMax, 0), "MW")
+	result.AddValue("critical_bus", complex(float64(c.CriticalBus), 0), "")
+	result.AddWaveformValue("lambda", c.Lambda, "")
+	result.AddWaveformValue("P", c.Load, "MW")
+	for i, v := range c.V {
+		result.AddWaveform(fmt.Sprintf("V%d", i+1), v, psa.Voltage)
+	}
+	for _, bus := range qvBuses {
+		q, err := parser.ComputeQVCurve(bus, qvOpts)
+		if err != nil {
+			return nil, inputError(err)
+		}
+		name := fmt.Sprintf("QV%d", q.Bus)
+		result.AddQuantity(name+".Qmin", complex(q.Qmin, 0), psa.Power)
+		result.AddQuantity(name+".V_Qmin", complex(q.VAtQmin, 0), psa.Voltage)
+		result.AddWaveform(name+".V", q.V, psa.Voltage)
+		result.AddWaveform(name+".Q", q.Q, psa.Power)
+	}
+	return result, nil
+}
+
+// POST /breaker[?e=1.0]: 按ANSI C37的E/X法校验请求体中给出的各断路器
+func handleBreaker(r *http.Request) (*psa.Result, error) {
+	e, err := floatParam(r, "e")
+	if err != nil {
+		return nil, err
+	}
+	parser, err := decodeNetwork(r)
+	if err != nil {
+		return nil, err
+	}
+	result, err := newResult(r, parser, parser.Vav)
+	if err != nil {
+		return nil, err
+	}
+	duties, err := parser.ComputeBreakerDuty(e)
+	if err != nil {
+		// 没有断路器或缺少基准电压也是输入的问题
+		if e := calcError(err); e != err {
+			return nil, e
+		}
+		return nil, unprocessable("%v", err)
+	}
+	for i, d := range duties {
+		name := d.Breaker.Name
+		if name == "" {
+			name = fmt.Sprintf("CB%d", i+1)
+		}
+		result.AddQuantity(name+".E/X", complex(d.EX, 0), psa.Current)
+		result.AddQuantity(name+".E/X1", complex(d.FirstCycleEX, 0), psa.Current)
+		result.AddValue(name+".X/R", complex(d.XR, 0), "")
+		result.AddValue(name+".NACD", complex(d.NACD, 0), "")
+		result.AddValue(name+".MF", complex(d.MF, 0), "")
+		result.AddValue(name+".interrupting", complex(d.InterruptingKA, 0), "kA")
+		result.AddValue(name+".closing", complex(d.ClosingKA, 0), "kA")
+		pass := 0.0
+		if d.Pass {
+			pass = 1
+		}
+		result.AddValue(name+".pass", complex(pass, 0), "")
+	}
+	return result, nil
+}
+
+// POST /coord[?faults=i,j,k][&cti=0.3]: 依次在各母线三相短路, 校验过电流继电器的配合
+func handleCoord(r *http.Request) (*psa.Result, error) {
+	cti, err := floatParam(r, "cti")
+	if err != nil {
+		return nil, err
+	}
+	parser, err := decodeNetwork(r)
+	if err != nil {
+		return nil, err
+	}
+	var buses []int
+	if faults := r.URL.Query().Get("faults"); faults != "" {
+		for _, field := range strings.Split(faults, ",") {
+			node, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil {
+				return nil, badRequest("参数faults应为i,j,k的形式: %s", faults)
+			}
+			bus, err := busOf(parser, node)
+			if err != nil {
+				return nil, err
+			}
+			buses = append(buses, bus)
+		}
+	}
+	result, err := newResult(r, parser, parser.Vav)
+	if err != nil {
+		return nil, err
+	}
+	if err := parser.ComputeResult(); err != nil {
+		return nil, calcError(err)
+	}
+	c, err := parser.ComputeCoordination(buses, cti)
+	if err != nil {
+		// 没有继电器、缺少基准电压或继电器不在支路上也是输入的问题
+		if e := calcError(err); e != err {
+			return nil, e
+		}
+		return nil, unprocessable("%v", err)
+	}
+	result.AddValue("CTI", complex(c.CTI, 0), "s")
+	// JSON不能表示无穷大, 不动作的继电器不输出动作时间
+	for _, op := range c.Operations {
+		name := fmt.Sprintf("F%d.%s", op.Fault, op.Name)
+		result.AddValue(name+".I", complex(op.KA, 0), "kA")
+		if !math.IsInf(op.Time, 0) {
+			result.AddValue(name+".t", complex(op.Time, 0), "s")
+		}
+	}
+	for _, pair := range c.Pairs {
+		name := fmt.Sprintf("F%d.%s/%s", pair.Fault, pair.Primary.Name, pair.Backup.Name)
+		if !math.IsInf(pair.Margin, 0) && !math.IsNaN(pair.Margin) {
+			result.AddValue(name+".margin", complex(pair.Margin, 0), "s")
+		}
+		violation := 0.0
+		if pair.Violation {
+			violation = 1
+		}
+		result.AddValue(name+".violation", complex(violation, 0), "")
+	}
+	return result, nil
+}
+
+// POST /thevenin?node=N[&node2=M]: 节点对地或两个节点之间的戴维南等值
+func handleThevenin(r *http.Request) (*psa.Result, error) {
+	node, err := intParam(r, "node")
+	if err != nil {
+		return nil, err
+	}
+	if node <= 0 {
+		return nil, badRequest("缺少参数node")
+	}
+	node2, err := intParam(r, "node2")
+	if err != nil {
+		return nil, err
+	}
+	parser, err := decodeNetwork(r)
+	if err != nil {
+		return nil, err
+	}
+	bus1, err := busOf(parser, node)
+	if err != nil {
+		return nil, err
+	}
+	bus2 := 0
+	if node2 != 0 {
+		if bus2, err = busOf(parser, node2); err != nil {
+			return nil, err
+		}
+	}
+	result, err := newResult(r, parser, parser.Vav)
+	if err != nil {
+		return nil, err
+	}
+	if err := parser.ComputeResult(); err != nil {
+		return nil, calcError(err)
+	}
+	U, err := parser.PrefaultVoltages()
+	if err != nil {
+		return nil, calcError(err)
+	}
+	th, err := parser.Thevenin(bus1, bus2, U)
+	if err != nil {
+		return nil, calcError(err)
+	}
+	result.AddQuantity("Zth", th.Zth, psa.Impedance)
+	result.AddQuantity("Voc", th.Voc, psa.Voltage)
+	// JSON不能表示无穷大, 纯电抗时不输出X/R
+	if !math.IsInf(th.XR, 0) {
+		result.AddValue("X/R", complex(th.XR, 0), "")
+	}
+	result.AddQuantity("Sk", complex(th.Sk, 0), psa.Power)
+	return result, nil
+}
+
+// POST /equiv?retain=i,j,k[&ward=true]: 消去保留节点以外的节点后的等值网络
+func handleEquiv(r *http.Request) (*psa.Result, error) {
+	retain := r.URL.Query().Get("retain")
+	if retain == "" {
+		return nil, badRequest("缺少参数retain")
+	}
+	parser, err := decodeNetwork(r)
+	if err != nil {
+		return nil, err
+	}
+	var buses []int
+	for _, field := range strings.Split(retain, ",") {
+		node, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, badRequest("参数retain应为i,j,k的形式: %s", retain)
+		}
+		bus, err := busOf(parser, node)
+		if err != nil {
+			return nil, err
+		}
+		buses = append(buses, bus)
+	}
+	var eq *psa.Equivalent
+	if r.URL.Query().Get("ward") == "true" {
+		eq, err = parser.WardEquivalent(buses)
+	} else {
+		eq, err = parser.KronEquivalent(buses)
+	}
+	if err != nil {
+		// 保留节点重复、缺少SB或内部网络奇异都是输入的问题
+		return nil, unprocessable("%v", err)
+	}
+	result, err := newResult(r, parser, parser.Vav)
+	if err != nil {
+		return nil, err
+	}
+	result.AddMatrix("Y_eq", eq.Y, psa.Admittance)
+	for i, node := range eq.Retained {
+		result.AddQuantity(fmt.Sprintf("y%d0", node), eq.Shunts[i], psa.Admittance)
+		result.AddQuantity(fmt.Sprintf("I%d", node), eq.Injections[i], psa.Current)
+		if eq.Powers != nil {
+			result.AddQuantity(fmt.Sprintf("S%d", node), eq.Powers[i], psa.Power)
+		}
+	}
+	return result, nil
+}
+
+// 输入的是物理节点, 换算为所在母线
+func busOf(parser *psa.Parser, node int) (int, error) {
+	bus := parser.Topology().BusOf(node)
+	if bus <= 0 || bus > parser.NodeNum() {
+		return 0, unprocessable("节点%d不在网络中、已接地或不带电", node)
+	}
+	return bus, nil
+}
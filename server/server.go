@@ -0,0 +1,236 @@
+// Package server 以HTTP/JSON的方式提供导纳矩阵、阻抗矩阵和短路计算
+//
+// 各接口都使用POST, 请求体为PowerNetwork(faultseq为SequenceNetwork)的json,
+// 短路点等参数放在查询字符串中, 返回与psa命令行-output json相同的结果.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"power-system-analysis-labs/psa"
+)
+
+const (
+	// 请求体的最大字节数
+	MaxBodyBytes = 1 << 20
+	// 允许计算的最大节点数, LDU分解和阻抗矩阵的计算量与节点数的三次方成正比
+	MaxNodes = 500
+)
+
+// 返回给客户端的错误
+type httpError struct {
+	code    int
+	message string
+}
+
+func (e *httpError) Error() string {
+	return e.message
+}
+
+func badRequest(format string, a ...interface{}) error {
+	return &httpError{http.StatusBadRequest, fmt.Sprintf(format, a...)}
+}
+
+func unprocessable(format string, a ...interface{}) error {
+	return &httpError{http.StatusUnprocessableEntity, fmt.Sprintf(format, a...)}
+}
+
+type handlerFunc func(r *http.Request) (*psa.Result, error)
+
+func NewHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/ybus", handlerFunc(handleYbus))
+	mux.Handle("/zbus", handlerFunc(handleZbus))
+	mux.Handle("/fault3ph", handlerFunc(handleFault3ph))
+	mux.Handle("/contrib", handlerFunc(handleContrib))
+	mux.Handle("/faultseq", handlerFunc(handleFaultseq))
+	mux.Handle("/iec60909", handlerFunc(handleIEC60909))
+	mux.Handle("/trace", handlerFunc(handleTrace))
+	mux.Handle("/stability", handlerFunc(handleStability))
+	mux.Handle("/eac", handlerFunc(handleEAC))
+	mux.Handle("/modes", handlerFunc(handleModes))
+	mux.Handle("/cpf", handlerFunc(handleCPF))
+	mux.Handle("/breaker", handlerFunc(handleBreaker))
+	mux.Handle("/coord", handlerFunc(handleCoord))
+	mux.Handle("/distance", handlerFunc(handleDistance))
+	mux.Handle("/thevenin", handlerFunc(handleThevenin))
+	mux.Handle("/equiv", handlerFunc(handleEquiv))
+	return mux
+}
+
+func (h handlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, &httpError{http.StatusMethodNotAllowed, "只支持POST请求"})
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, MaxBodyBytes)
+	result, err := h.serve(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if err := result.WriteJSON(w); err != nil {
+		log.Printf("%s: 写出结果失败: %v", r.URL.Path, err)
+	}
+}
+
+// 计算中的越界等错误转换为500, 不让服务退出
+func (h handlerFunc) serve(r *http.Request) (result *psa.Result, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			log.Printf("%s: %v", r.URL.Path, v)
+			err = &httpError{http.StatusInternalServerError, "计算失败"}
+		}
+	}()
+	return h(r)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	if e, ok := err.(*httpError); ok {
+		code = e.code
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{err.Error()})
+}
+
+// 读取请求体中的json
+func decodeBody(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		if err.Error() == "http: request body too large" {
+			return &httpError{http.StatusRequestEntityTooLarge, fmt.Sprintf("请求体超过%d字节", MaxBodyBytes)}
+		}
+		return badRequest("解析请求体失败: %v", err)
+	}
+	return nil
+}
+
+func decodeNetwork(r *http.Request) (*psa.Parser, error) {
+	return decodeNetworkWith(r, psa.NewParser)
+}
+
+// 用给定的方式由请求体中的网络建立Parser, 例如psa.NewIEC60909Parser
+func decodeNetworkWith(r *http.Request, newParser func(psa.PowerNetwork) (*psa.Parser, error)) (*psa.Parser, error) {
+	var network psa.PowerNetwork
+	if err := decodeBody(r, &network); err != nil {
+		return nil, err
+	}
+	// nomotors=true时不计电动机, 用于计算最小短路电流
+	if r.URL.Query().Get("nomotors") == "true" {
+		network = network.WithoutMotors()
+	}
+	// 在建立导纳矩阵之前按物理节点号检查规模, 避免按过大的节点号分配矩阵
+	if err := checkMaxNode(network.MaxNode()); err != nil {
+		return nil, err
+	}
+	parser, err := newParser(network)
+	if err != nil {
+		return nil, unprocessable("%v", err)
+	}
+	// 查询字符串中给出pivot=true时LDU分解按对称主元选择
+	parser.Pivoting = r.URL.Query().Get("pivot") == "true"
+	// solver=gonum时用gonum计算阻抗矩阵, verify=true时交叉验证
+	if err := parser.UseSolver(r.URL.Query().Get("solver")); err != nil {
+		return nil, badRequest("%v", err)
+	}
+	if r.URL.Query().Get("verify") == "true" {
+		parser.Verify = true
+	}
+	if err := checkNodeNum(parser); err != nil {
+		return nil, err
+	}
+	return parser, nil
+}
+
+func checkMaxNode(node int) error {
+	if node > MaxNodes {
+		return unprocessable("节点号%d超过上限%d", node, MaxNodes)
+	}
+	return nil
+}
+
+func checkNodeNum(parser *psa.Parser) error {
+	if parser.NodeNum() == 0 {
+		return unprocessable("网络中没有节点")
+	}
+	if parser.NodeNum() > MaxNodes {
+		return unprocessable("节点数%d超过上限%d", parser.NodeNum(), MaxNodes)
+	}
+	return nil
+}
+
+// 查询字符串中的整数参数, 没有给出时返回0
+func intParam(r *http.Request, name string) (int, error) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, badRequest("参数%s不是整数: %s", name, s)
+	}
+	return v, nil
+}
+
+// 查询字符串中的实数参数, 没有给出时返回0
+func floatParam(r *http.Request, name string) (float64, error) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	// ParseFloat接受NaN和Inf, 它们不是有效的参数
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, badRequest("参数%s不是数字: %s", name, s)
+	}
+	return v, nil
+}
+
+// 按查询字符串中的unit和vb创建结果, vb没有给出时使用网络的Vav
+func newResult(r *http.Request, parser *psa.Parser, vb float64) (*psa.Result, error) {
+	if s := r.URL.Query().Get("vb"); s != "" {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || !(v > 0) || math.IsInf(v, 1) {
+			return nil, badRequest("参数vb必须是正数: %s", s)
+		}
+		vb = v
+	}
+	// 没有网络时(序网参数已经是标幺值)只能输出标幺值
+	unit := r.URL.Query().Get("unit")
+	var result *psa.Result
+	var err error
+	if parser == nil {
+		result, err = psa.NewResult(unit, 0, vb)
+	} else {
+		result, err = parser.NewResult(unit, vb)
+	}
+	if err != nil {
+		return nil, badRequest("%v", err)
+	}
+	return result, nil
+}
+
+// 将计算中的错误转换为返回给客户端的错误, 网络或短路位置的问题为422
+func calcError(err error) error {
+	var singular *psa.SingularMatrixError
+	var unknownNode *psa.UnknownNodeError
+	var missingSource *psa.MissingSourceError
+	var invalidFault *psa.InvalidFaultError
+	switch {
+	case errors.As(err, &singular), errors.As(err, &unknownNode),
+		errors.As(err, &missingSource), errors.As(err, &invalidFault):
+		return unprocessable("%v", err)
+	}
+	return err
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"power-system-analysis-labs/psa"
+)
+
+// 电源x=0.1接在节点1, 1-2的线路x=0.2
+const twoNodeNetwork = `{"SB": 100, "branches": [
+	{"node_1": 1, "node_2": 0, "reactance": 0.1, "E": 1},
+	{"node_1": 1, "node_2": 2, "reactance": 0.2}
+]}`
+
+// 各序网络都是节点1经x=0.1接地
+const sequenceNetwork = `{
+	"grid1": [{"node_1": 1, "node_2": 0, "reactance": 0.1}],
+	"grid2": [{"node_1": 1, "node_2": 0, "reactance": 0.1}],
+	"grid0": [{"node_1": 1, "node_2": 0, "reactance": 0.1}],
+	"F1": 1, "F2": 1, "F0": 1
+}`
+
+// 发送请求, 返回状态码和解码后的结果
+func post(t *testing.T, method, target, body string) (int, *psa.Result) {
+	t.Helper()
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	NewHandler().ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK {
+		return recorder.Code, nil
+	}
+	var result psa.Result
+	if err := json.Unmarshal(recorder.Body.Bytes(), &result); err != nil {
+		t.Fatalf("%s: %v in %s", target, err, recorder.Body.String())
+	}
+	return recorder.Code, &result
+}
+
+func quantity(t *testing.T, result *psa.Result, name string) psa.Quantity {
+	t.Helper()
+	for _, q := range result.Quantities {
+		if q.Name == name {
+			return q
+		}
+	}
+	t.Fatalf("missing quantity %s", name)
+	return psa.Quantity{}
+}
+
+func TestFault3ph(t *testing.T) {
+	code, result := post(t, http.MethodPost, "/fault3ph?node=2", twoNodeNetwork)
+	if code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	// If = 1/j0.3
+	if If := quantity(t, result, "If"); math.Abs(If.Mag-1/0.3) > 1e-9 || If.Angle != -90 {
+		t.Errorf("If = %+v", If)
+	}
+	if z := result.Matrices[1].Values[1][1]; math.Abs(z.Im-0.3) > 1e-12 {
+		t.Errorf("Z22 = %+v", z)
+	}
+	if len(result.BranchCurrents) != 1 || math.Abs(result.BranchCurrents[0].Mag-1/0.3) > 1e-9 {
+		t.Errorf("branch currents = %+v", result.BranchCurrents)
+	}
+}
+
+// 电源x=0.1接在节点1, 节点1到112串联x=0.01的线路, 节点112短路时各段都流过 1/j1.21
+func TestFault3phThreeDigitNodes(t *testing.T) {
+	branches := []string{`{"node_1": 1, "node_2": 0, "reactance": 0.1, "E": 1}`}
+	for i := 1; i < 112; i++ {
+		branches = append(branches, fmt.Sprintf(`{"node_1": %d, "node_2": %d, "reactance": 0.01}`, i, i+1))
+	}
+	network := `{"SB": 100, "branches": [` + strings.Join(branches, ",") + `]}`
+	code, result := post(t, http.MethodPost, "/fault3ph?node=112", network)
+	if code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	if len(result.BranchCurrents) != 111 {
+		t.Fatalf("%d branch currents, want 111", len(result.BranchCurrents))
+	}
+	for _, b := range result.BranchCurrents {
+		if b.Node2 != b.Node1+1 || math.Abs(b.Mag-1/1.21) > 1e-9 || math.Abs(b.Angle+90) > 1e-9 {
+			t.Errorf("I%d-%d = %+v, want 0.826446∠-90°", b.Node1, b.Node2, b.Complex)
+		}
+	}
+}
+
+func TestFaultseqSinglePhase(t *testing.T) {
+	code, result := post(t, http.MethodPost, "/faultseq?type=1ph", sequenceNetwork)
+	if code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	// Ifa(1) = 1/(j0.1*3), Ifa = 3Ifa(1) = -j10
+	if Ifa := quantity(t, result, "Ifa"); math.Abs(Ifa.Mag-10) > 1e-9 || math.Abs(Ifa.Angle+90) > 1e-9 {
+		t.Errorf("Ifa = %+v", Ifa)
+	}
+	if Ifb := quantity(t, result, "Ifb"); Ifb.Mag > 1e-9 {
+		t.Errorf("Ifb = %+v", Ifb)
+	}
+	if Vfa := quantity(t, result, "Vfa"); Vfa.Mag > 1e-9 {
+		t.Errorf("Vfa = %+v", Vfa)
+	}
+}
+
+// 以Vav = 115kV为基准的网络, Un = 110kV: Ik” = cUn/(√3|Zk|), Zk = |0.01+j0.1|×115²/100 Ω
+func TestIEC60909NominalVoltage(t *testing.T) {
+	network := `{"SB": 100, "Vav": 115, "branches": [
+		{"node_1": 1, "node_2": 0, "resistance": 0.01, "reactance": 0.1, "E": 1}
+	]}`
+	code, result := post(t, http.MethodPost, "/iec60909?node=1&un=110&unit=si", network)
+	if code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	zk := math.Hypot(0.01, 0.1) * 115 * 115 / 100
+	if ik := quantity(t, result, "Ik''"); ik.Unit != "kA" || math.Abs(ik.Mag-1.1*110/(math.Sqrt(3)*zk)) > 1e-9 {
+		t.Errorf("Ik'' = %+v, want %.6f kA", ik, 1.1*110/(math.Sqrt(3)*zk))
+	}
+}
+
+func TestRequestErrors(t *testing.T) {
+	for _, c := range []struct {
+		name, method, target, body string
+		code                       int
+	}{
+		{"GET", http.MethodGet, "/fault3ph?node=2", twoNodeNetwork, http.StatusMethodNotAllowed},
+		{"missing node", http.MethodPost, "/fault3ph", twoNodeNetwork, http.StatusBadRequest},
+		{"bad node", http.MethodPost, "/fault3ph?node=x", twoNodeNetwork, http.StatusBadRequest},
+		{"bad json", http.MethodPost, "/fault3ph?node=2", "{", http.StatusBadRequest},
+		{"node outside the network", http.MethodPost, "/fault3ph?node=3", twoNodeNetwork, http.StatusUnprocessableEntity},
+		{"bad unit", http.MethodPost, "/fault3ph?node=2&unit=kA", twoNodeNetwork, http.StatusBadRequest},
+		// 10.5kV的线路不能按115kV换算有名值
+		{"several voltage levels in SI", http.MethodPost, "/ybus?unit=si&vb=115",
+			`{"SB": 100, "Vav": 115, "circuits": [{"node_1": 1, "node_2": 2, "x": 0.4, "l": 10, "VB": 10.5}],
+			"SG": {"node": 1, "circuit": {"x": 0.4, "l": 10, "VB": 115}}}`,
+			http.StatusBadRequest},
+		// 按节点号分配矩阵之前就要拒绝过大的节点号
+		{"huge node number", http.MethodPost, "/ybus",
+			`{"SB": 100, "branches": [{"node_1": 100000000, "node_2": 0, "reactance": 0.1}]}`,
+			http.StatusUnprocessableEntity},
+		{"huge sequence fault node", http.MethodPost, "/faultseq?node=100000000", sequenceNetwork,
+			http.StatusUnprocessableEntity},
+		{"NaN vb", http.MethodPost, "/ybus?vb=NaN", twoNodeNetwork, http.StatusBadRequest},
+		{"NaN step", http.MethodPost, "/trace?node=2&step=NaN", twoNodeNetwork, http.StatusBadRequest},
+		{"unknown fault type", http.MethodPost, "/faultseq?type=4ph", sequenceNetwork, http.StatusBadRequest},
+		{"body too large", http.MethodPost, "/ybus", strings.Repeat(" ", MaxBodyBytes+1), http.StatusRequestEntityTooLarge},
+	} {
+		if code, _ := post(t, c.method, c.target, c.body); code != c.code {
+			t.Errorf("%s: status %d, want %d", c.name, code, c.code)
+		}
+	}
+}